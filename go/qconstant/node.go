@@ -48,6 +48,7 @@ const (
 	PassengerStatusMask                = "PassengerStatusMask"
 	PassengerStatusNotPassedThrough    = "PassengerStatusNotPassedThrough"
 	PassengerStatusNameMissmatch       = "PassengerStatusNameMissmatch"
+	PassengerStatusChipMismatch        = "PassengerStatusChipMismatch"
 	PassengerStatusInternalError       = "PassengerStatusInternalError"
 	PassengerStatusException           = "PassengerStatusException"
 )
@@ -0,0 +1,262 @@
+package qbarcode
+
+import "errors"
+
+const (
+	aztecDefaultECC = 23
+	aztecMaxLayers  = 32
+)
+
+// aztecWordSize is the codeword size in bits by number of layers.
+func aztecWordSize(layers int) int {
+	switch {
+	case layers <= 2:
+		return 6
+	case layers <= 8:
+		return 8
+	case layers <= 22:
+		return 10
+	default:
+		return 12
+	}
+}
+
+func aztecTotalBits(layers int, compact bool) int {
+	if compact {
+		return (88 + 16*layers) * layers
+	}
+	return (112 + 16*layers) * layers
+}
+
+// encodeAztec encodes data with a single binary shift from the initial
+// upper case mode, which every reader supports, and picks the smallest
+// compact or full range symbol leaving eccPercent of it for check
+// words.
+func encodeAztec(data []byte, eccPercent int) ([][]bool, error) {
+	if eccPercent < 0 {
+		eccPercent = aztecDefaultECC
+	}
+	if eccPercent < 5 || eccPercent > 95 {
+		return nil, errors.New("invalid Aztec error correction percentage")
+	}
+
+	bits := aztecBinaryShift(data)
+	eccBits := bits.len()*eccPercent/100 + 11
+
+	var stuffed *bitBuffer
+	layers, wordSize, compact := 0, 0, false
+	for i := 0; ; i++ {
+		if i > aztecMaxLayers {
+			return nil, ErrDataTooLong
+		}
+		compact = i <= 3
+		layers = i
+		if compact {
+			layers = i + 1
+		}
+		total := aztecTotalBits(layers, compact)
+		if bits.len()+eccBits > total {
+			continue
+		}
+		if stuffed == nil || wordSize != aztecWordSize(layers) {
+			wordSize = aztecWordSize(layers)
+			stuffed = aztecStuffBits(bits, wordSize)
+		}
+		usable := total - total%wordSize
+		if compact && stuffed.len() > wordSize*64 {
+			continue
+		}
+		if stuffed.len()+eccBits <= usable {
+			break
+		}
+	}
+
+	messageWords := stuffed.len() / wordSize
+	message := aztecCheckWords(stuffed, aztecTotalBits(layers, compact), wordSize)
+	mode := &bitBuffer{}
+	if compact {
+		mode.append(layers-1, 2)
+		mode.append(messageWords-1, 6)
+		mode = aztecCheckWords(mode, 28, 4)
+	} else {
+		mode.append(layers-1, 5)
+		mode.append(messageWords-1, 11)
+		mode = aztecCheckWords(mode, 40, 4)
+	}
+
+	base := 14 + layers*4
+	if compact {
+		base = 11 + layers*4
+	}
+	align := make([]int, base)
+	size := base
+	if compact {
+		for i := range align {
+			align[i] = i
+		}
+	} else {
+		size = base + 1 + 2*((base/2-1)/15)
+		origCenter, center := base/2, size/2
+		for i := 0; i < origCenter; i++ {
+			offset := i + i/15
+			align[origCenter-i-1] = center - offset - 1
+			align[origCenter+i] = center + offset + 1
+		}
+	}
+
+	grid := newGrid(size, size)
+	set := func(x, y int) { grid[y][x] = true }
+	for i, rowOffset := 0, 0; i < layers; i++ {
+		rowSize := (layers-i)*4 + 12
+		if compact {
+			rowSize = (layers-i)*4 + 9
+		}
+		for j := 0; j < rowSize; j++ {
+			columnOffset := j * 2
+			for k := 0; k < 2; k++ {
+				if message.bits[rowOffset+columnOffset+k] {
+					set(align[i*2+k], align[i*2+j])
+				}
+				if message.bits[rowOffset+rowSize*2+columnOffset+k] {
+					set(align[i*2+j], align[base-1-i*2-k])
+				}
+				if message.bits[rowOffset+rowSize*4+columnOffset+k] {
+					set(align[base-1-i*2-k], align[base-1-i*2-j])
+				}
+				if message.bits[rowOffset+rowSize*6+columnOffset+k] {
+					set(align[base-1-i*2-j], align[i*2+k])
+				}
+			}
+		}
+		rowOffset += rowSize * 8
+	}
+
+	center := size / 2
+	drawAztecMode(grid, mode, center, compact)
+	if compact {
+		drawBullsEye(grid, center, 5)
+	} else {
+		drawBullsEye(grid, center, 7)
+		for i, j := 0, 0; i < base/2-1; i, j = i+15, j+16 {
+			for k := center & 1; k < size; k += 2 {
+				set(center-j, k)
+				set(center+j, k)
+				set(k, center-j)
+				set(k, center+j)
+			}
+		}
+	}
+	return grid, nil
+}
+
+// aztecBinaryShift encodes data as binary shift runs. Runs of 32 to 62
+// bytes are split in two, longer runs use the 11 bit length.
+func aztecBinaryShift(data []byte) *bitBuffer {
+	bits := &bitBuffer{}
+	for len(data) > 0 {
+		n := len(data)
+		if n > 2078 {
+			n = 2078
+		}
+		bits.append(31, 5)
+		switch {
+		case n > 62:
+			bits.append(n-31, 16)
+		case n > 31:
+			n = 31
+			fallthrough
+		default:
+			bits.append(n, 5)
+		}
+		for _, b := range data[:n] {
+			bits.append(int(b), 8)
+		}
+		data = data[n:]
+	}
+	return bits
+}
+
+// aztecStuffBits splits bits into words, inserting a bit after any
+// word that would be all zeros or all ones.
+func aztecStuffBits(bits *bitBuffer, wordSize int) *bitBuffer {
+	out := &bitBuffer{}
+	n := bits.len()
+	mask := (1 << wordSize) - 2
+	for i := 0; i < n; i += wordSize {
+		word := 0
+		for j := 0; j < wordSize; j++ {
+			if i+j >= n || bits.bits[i+j] {
+				word |= 1 << (wordSize - 1 - j)
+			}
+		}
+		switch word & mask {
+		case mask:
+			out.append(word&mask, wordSize)
+			i--
+		case 0:
+			out.append(word|1, wordSize)
+			i--
+		default:
+			out.append(word, wordSize)
+		}
+	}
+	return out
+}
+
+// aztecCheckWords appends Reed-Solomon check words to the message,
+// filling totalBits, with the leftover bits as leading zeros.
+func aztecCheckWords(message *bitBuffer, totalBits, wordSize int) *bitBuffer {
+	data := message.words(wordSize)
+	check := aztecField[wordSize].encode(data, totalBits/wordSize-len(data))
+	out := &bitBuffer{}
+	out.append(0, totalBits%wordSize)
+	for _, w := range append(data, check...) {
+		out.append(w, wordSize)
+	}
+	return out
+}
+
+func drawAztecMode(grid [][]bool, mode *bitBuffer, center int, compact bool) {
+	set := func(x, y int, dark bool) {
+		if dark {
+			grid[y][x] = true
+		}
+	}
+	bits := mode.bits
+	if compact {
+		for i := 0; i < 7; i++ {
+			offset := center - 3 + i
+			set(offset, center-5, bits[i])
+			set(center+5, offset, bits[i+7])
+			set(offset, center+5, bits[20-i])
+			set(center-5, offset, bits[27-i])
+		}
+		return
+	}
+	for i := 0; i < 10; i++ {
+		offset := center - 5 + i + i/5
+		set(offset, center-7, bits[i])
+		set(center+7, offset, bits[i+10])
+		set(offset, center+7, bits[29-i])
+		set(center-7, offset, bits[39-i])
+	}
+}
+
+// drawBullsEye draws the finder rings and the orientation marks.
+func drawBullsEye(grid [][]bool, center, size int) {
+	set := func(x, y int) { grid[y][x] = true }
+	for i := 0; i < size; i += 2 {
+		for j := center - i; j <= center+i; j++ {
+			set(j, center-i)
+			set(j, center+i)
+			set(center-i, j)
+			set(center+i, j)
+		}
+	}
+	set(center-size, center-size)
+	set(center-size+1, center-size)
+	set(center-size, center-size+1)
+	set(center+size, center-size)
+	set(center+size, center-size+1)
+	set(center+size, center+size-1)
+}
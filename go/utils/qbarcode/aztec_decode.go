@@ -0,0 +1,759 @@
+package qbarcode
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Aztec high level modes.
+const (
+	aztecUpper = iota
+	aztecLower
+	aztecMixed
+	aztecPunct
+	aztecDigit
+	aztecBinary
+)
+
+// aztecTables lists the characters of each mode. Entries starting with
+// a NUL byte are controls: shift (S) or latch (L) to the mode named by
+// their letter, B for binary shift, F for FLG(n).
+var aztecTables = [5][]string{
+	aztecUpper: append(append([]string{"\x00PS", " "}, letters('A')...), "\x00LL", "\x00ML", "\x00DL", "\x00BS"),
+	aztecLower: append(append([]string{"\x00PS", " "}, letters('a')...), "\x00US", "\x00ML", "\x00DL", "\x00BS"),
+	aztecMixed: {
+		"\x00PS", " ", "\x01", "\x02", "\x03", "\x04", "\x05", "\x06", "\x07", "\b", "\t", "\n",
+		"\x0b", "\f", "\r", "\x1b", "\x1c", "\x1d", "\x1e", "\x1f", "@", "\\", "^", "_", "`",
+		"|", "~", "\x7f", "\x00LL", "\x00UL", "\x00PL", "\x00BS",
+	},
+	aztecPunct: {
+		"\x00F", "\r", "\r\n", ". ", ", ", ": ", "!", "\"", "#", "$", "%", "&", "'", "(", ")",
+		"*", "+", ",", "-", ".", "/", ":", ";", "<", "=", ">", "?", "[", "]", "{", "}", "\x00UL",
+	},
+	aztecDigit: {"\x00PS", " ", "0", "1", "2", "3", "4", "5", "6", "7", "8", "9", ",", ".", "\x00UL", "\x00US"},
+}
+
+var aztecModes = map[byte]int{'U': aztecUpper, 'L': aztecLower, 'M': aztecMixed, 'P': aztecPunct, 'D': aztecDigit, 'B': aztecBinary}
+
+func letters(first byte) []string {
+	s := make([]string, 26)
+	for i := range s {
+		s[i] = string(first + byte(i))
+	}
+	return s
+}
+
+// aztecGrid samples modules around the centre of a bull's eye through
+// t, x and y being offsets from the central module.
+type aztecGrid struct {
+	m        *bitMatrix
+	t        perspective
+	rotation int
+}
+
+func (g aztecGrid) get(x, y int) bool {
+	for i := 0; i < g.rotation; i++ {
+		x, y = -y, x
+	}
+	return g.m.at(g.t.apply(point{float64(x), float64(y)}))
+}
+
+// adjust returns the grid turned by angle degrees and scaled around the
+// central module.
+func (g aztecGrid) adjust(angle, scale float64) aztecGrid {
+	a := angle * math.Pi / 180
+	cos, sin := math.Cos(a)*scale, math.Sin(a)*scale
+	g.t = g.t.times(perspective{cos, -sin, 0, sin, cos, 0, 0, 0, 1})
+	return g
+}
+
+// maxAztecCandidates bounds the bull's eyes tried. A real one is found
+// on many rows, so it sorts ahead of the single row matches noise and
+// text produce by the thousand.
+const maxAztecCandidates = 16
+
+// decodeAztec locates bull's eye candidates, fits the square of their
+// rings to find the perspective, then reads the mode message and the
+// data layers around them.
+func decodeAztec(ctx context.Context, m *bitMatrix) (Result, error) {
+	candidates := findPatterns(ctx, m, []float64{1, 1, 1, 1, 1})
+	if len(candidates) == 0 {
+		return Result{}, ErrNotFound
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].count > candidates[j].count })
+	if len(candidates) > maxAztecCandidates {
+		candidates = candidates[:maxAztecCandidates]
+	}
+
+	err := ErrNotFound
+	for _, c := range candidates {
+		if ctx.Err() != nil {
+			return Result{}, ErrNotFound
+		}
+		c.center = aztecCenter(m, c)
+		if !aztecRings(m, c) {
+			continue
+		}
+		transforms, ok := aztecGeometry(m, c.center)
+		if !ok {
+			continue
+		}
+		for _, t := range transforms {
+			res, e := decodeAztecAt(aztecGrid{m: m, t: t})
+			if e == nil {
+				return res, nil
+			}
+			if e != ErrNotFound {
+				err = e
+			}
+		}
+	}
+	return Result{}, err
+}
+
+// aztecCenter snaps a candidate to the centroid of the nearest small
+// dark blob, the central module, which the row and column scans may
+// miss when the symbol is rotated.
+func aztecCenter(m *bitMatrix, c finder) point {
+	radius := int(math.Ceil(c.module * 1.5))
+	limit := int(math.Ceil(c.module*c.module*2)) + 4
+	cx, cy := int(math.Floor(c.center.x)), int(math.Floor(c.center.y))
+	seen := map[[2]int]bool{}
+	best, bestDist := c.center, math.Inf(1)
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			start := [2]int{cx + dx, cy + dy}
+			if seen[start] || !m.get(start[0], start[1]) {
+				continue
+			}
+			// Flood fill the blob, giving up once it is larger than a
+			// module: it is then part of a ring.
+			blob, queue := []([2]int){}, [][2]int{start}
+			seen[start] = true
+			for len(queue) > 0 && len(blob) <= limit {
+				p := queue[0]
+				queue = queue[1:]
+				blob = append(blob, p)
+				for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+					q := [2]int{p[0] + d[0], p[1] + d[1]}
+					if !seen[q] && m.get(q[0], q[1]) {
+						seen[q] = true
+						queue = append(queue, q)
+					}
+				}
+			}
+			if len(blob) > limit {
+				continue
+			}
+			sum := point{}
+			for _, p := range blob {
+				sum = sum.add(point{float64(p[0]) + 0.5, float64(p[1]) + 0.5})
+			}
+			centroid := sum.scale(1 / float64(len(blob)))
+			if d := centroid.dist(c.center); d < bestDist {
+				best, bestDist = centroid, d
+			}
+		}
+	}
+	return best
+}
+
+// aztecRings quickly rejects candidates, such as reference grid
+// crossings, whose rings are not where a bull's eye has them in every
+// direction. Runs measured along rows are up to 1.4 modules long when
+// the symbol is rotated.
+func aztecRings(m *bitMatrix, c finder) bool {
+	for deg := 0; deg < 360; deg += 45 {
+		a := float64(deg) * math.Pi / 180
+		if d := ringEdge(m, c.center, point{math.Cos(a), math.Sin(a)}); d < 1.8*c.module || d > 5*c.module {
+			return false
+		}
+	}
+	return true
+}
+
+// ringEdge returns the distance from the centre to the middle of the
+// second light ring in direction dir, -1 if there is none. The centre
+// may be slightly off the central module, into the first light ring.
+// Taking the middle of the ring cancels out any bias of the
+// binarization.
+func ringEdge(m *bitMatrix, center, dir point) float64 {
+	dark, lights, limit := true, 0, float64(m.width+m.height)
+	inner := 0.0
+	for r := 0.0; r < limit; r += 0.25 {
+		if m.at(center.add(dir.scale(r))) == dark {
+			continue
+		}
+		dark = !dark
+		switch {
+		case !dark:
+			lights++
+			inner = r
+		case lights == 2:
+			return (inner + r) / 2
+		}
+	}
+	return -1
+}
+
+// aztecGeometry casts rays from the centre to the middle of the ring
+// three modules around it and returns the transforms mapping module
+// offsets to the image: first the rotation and scale that best fit the
+// whole square, then the perspective given by fitting each of its
+// sides.
+func aztecGeometry(m *bitMatrix, center point) ([]perspective, bool) {
+	var edges [360]point
+	var dists [360]float64
+	for pass := 0; pass < 4; pass++ {
+		for deg := range edges {
+			a := float64(deg) * math.Pi / 180
+			dir := point{math.Cos(a), math.Sin(a)}
+			r := ringEdge(m, center, dir)
+			if r < 0 {
+				return nil, false
+			}
+			edges[deg], dists[deg] = center.add(dir.scale(r)), r
+		}
+
+		// Opposite edges are symmetric around the centre. Rays through
+		// the central module when the centre is off it mistake the
+		// first ring for the second, hence the median.
+		xs, ys := make([]float64, 180), make([]float64, 180)
+		for deg := range xs {
+			xs[deg] = (edges[deg].x + edges[deg+180].x) / 2
+			ys[deg] = (edges[deg].y + edges[deg+180].y) / 2
+		}
+		sort.Float64s(xs)
+		sort.Float64s(ys)
+		next := point{xs[90], ys[90]}
+		if next.near(center, 0.25) {
+			break
+		}
+		center = next
+	}
+
+	// Distance times the cosine to the nearest side is constant at the
+	// rotation of the square.
+	bestAngle, bestCost, bestHalf := 0.0, math.Inf(1), 0.0
+	values := make([]float64, len(dists))
+	fit := func(angle float64) {
+		for deg, d := range dists {
+			a := (float64(deg) - angle) * math.Pi / 180
+			values[deg] = d * math.Max(math.Abs(math.Cos(a)), math.Abs(math.Sin(a)))
+		}
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+		half := sorted[len(sorted)/2]
+		cost := 0.0
+		for _, v := range values {
+			cost += math.Abs(v - half)
+		}
+		if cost < bestCost {
+			bestAngle, bestCost, bestHalf = angle, cost, half
+		}
+	}
+	for angle := 0.0; angle < 90; angle++ {
+		fit(angle)
+	}
+	coarse := bestAngle
+	for angle := coarse - 1; angle <= coarse+1; angle += 0.1 {
+		fit(angle)
+	}
+	module := bestHalf / 3
+	if module < 1 || bestCost/float64(len(dists)) > module/2 {
+		return nil, false
+	}
+	a := bestAngle * math.Pi / 180
+	cos, sin := math.Cos(a)*module, math.Sin(a)*module
+	transforms := []perspective{{cos, -sin, center.x, sin, cos, center.y, 0, 0, 1}}
+
+	// Fit the sides, right, bottom, left and top, leaving out the
+	// corners, and intersect them.
+	var sides [4]line
+	for side := range sides {
+		var points []point
+		for deg := -35; deg <= 35; deg++ {
+			points = append(points, edges[((int(math.Round(bestAngle))+side*90+deg)%360+360)%360])
+		}
+		sides[side] = fitLine(points)
+	}
+	var corners [4]point
+	for i := range corners {
+		// Top left, top right, bottom right, bottom left.
+		p, ok := sides[(i+2)%4].intersect(sides[(i+3)%4])
+		if !ok {
+			return transforms, true
+		}
+		corners[i] = p
+	}
+	src := [4]point{{-3, -3}, {3, -3}, {3, 3}, {-3, 3}}
+	return append(transforms, newPerspective(src, corners)), true
+}
+
+// line is a point and a unit direction.
+type line struct {
+	p, d point
+}
+
+// fitLine returns the least squares line through points.
+func fitLine(points []point) line {
+	c := point{}
+	for _, p := range points {
+		c = c.add(p)
+	}
+	c = c.scale(1 / float64(len(points)))
+	var xx, xy, yy float64
+	for _, p := range points {
+		d := p.sub(c)
+		xx, xy, yy = xx+d.x*d.x, xy+d.x*d.y, yy+d.y*d.y
+	}
+	a := math.Atan2(2*xy, xx-yy) / 2
+	return line{c, point{math.Cos(a), math.Sin(a)}}
+}
+
+// consensusLine returns the least squares line through the points
+// lying within tolerance of the line through two of them that most
+// points lie near, ignoring the others.
+func consensusLine(points []point, tolerance float64) line {
+	best, bestCount := fitLine(points), 0
+	step := max(1, len(points)/40)
+	for i := 0; i < len(points); i += step {
+		for j := i + step; j < len(points); j += step {
+			d := points[j].sub(points[i])
+			length := d.dist(point{})
+			if length == 0 {
+				continue
+			}
+			l := line{points[i], d.scale(1 / length)}
+			count := 0
+			for _, p := range points {
+				if math.Abs(p.sub(l.p).cross(l.d)) <= tolerance {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = l, count
+			}
+		}
+	}
+	var inliers []point
+	for _, p := range points {
+		if math.Abs(p.sub(best.p).cross(best.d)) <= tolerance {
+			inliers = append(inliers, p)
+		}
+	}
+	if len(inliers) < 2 {
+		return best
+	}
+	return fitLine(inliers)
+}
+
+func (l line) intersect(o line) (point, bool) {
+	den := l.d.cross(o.d)
+	if math.Abs(den) < 1e-9 {
+		return point{}, false
+	}
+	return l.p.add(l.d.scale(o.p.sub(l.p).cross(o.d) / den)), true
+}
+
+// decodeAztecAt finds the orientation and mode message, then reads the
+// data, nudging the geometry when it does not read. Full range symbols
+// are first aligned on their reference grid.
+func decodeAztecAt(g aztecGrid) (Result, error) {
+	compact, layers, dataWords, ok := false, 0, 0, false
+	for _, compact = range []bool{true, false} {
+		for g.rotation = 0; g.rotation < 4; g.rotation++ {
+			if !aztecMarks(g, compact) {
+				continue
+			}
+			if layers, dataWords, ok = readAztecMode(g, compact); ok {
+				break
+			}
+		}
+		if ok {
+			break
+		}
+	}
+	if !ok {
+		return Result{}, ErrNotFound
+	}
+
+	size := 11 + layers*4
+	if !compact {
+		size = 14 + layers*4
+		size += 1 + 2*((size/2-1)/15)
+		best, bestScore := g, -1
+		for da := -1.0; da <= 1; da += 0.1 {
+			for ds := 0.96; ds <= 1.04; ds += 0.004 {
+				adjusted := g.adjust(da, ds)
+				if score := aztecGridScore(adjusted, size); score > bestScore {
+					best, bestScore = adjusted, score
+				}
+			}
+		}
+		g = best
+	}
+	grids := []aztecGrid{g}
+	if outline, ok := aztecOutline(g, size); ok {
+		grids = append(grids, outline)
+	}
+
+	err := ErrCorrupted
+	for _, da := range []float64{0, 0.3, -0.3, 0.6, -0.6, 1, -1, 1.5, -1.5} {
+		for _, ds := range []float64{1, 1.01, 0.99, 1.02, 0.98, 1.03, 0.97, 1.04, 0.96} {
+			for _, g := range grids {
+				res, e := readAztecData(g.adjust(da, ds), compact, layers, dataWords)
+				if e == nil {
+					return res, nil
+				}
+				err = e
+			}
+		}
+	}
+	return Result{}, err
+}
+
+// aztecMarks reports whether the orientation marks at the corners of
+// the mode message are where they should be, allowing two errors.
+func aztecMarks(g aztecGrid, compact bool) bool {
+	k := 7
+	if compact {
+		k = 5
+	}
+	marks := 0
+	for _, p := range [][3]int{
+		{-k, -k, 1}, {-k + 1, -k, 1}, {-k, -k + 1, 1},
+		{k, -k, 1}, {k, -k + 1, 1}, {k - 1, -k, 0},
+		{k, k - 1, 1}, {k, k, 0}, {k - 1, k, 0},
+		{-k, k, 0}, {-k + 1, k, 0}, {-k, k - 1, 0},
+	} {
+		if g.get(p[0], p[1]) == (p[2] == 1) {
+			marks++
+		}
+	}
+	return marks >= 10
+}
+
+// aztecGridScore counts the modules of the reference grid of a full
+// range symbol that read as expected: dark on even offsets from the
+// centre along lines every 16 modules.
+func aztecGridScore(g aztecGrid, size int) int {
+	score := 0
+	for l := -size / 2 / 16 * 16; l <= size/2; l += 16 {
+		for t := -size / 2; t <= size/2; t++ {
+			if max(abs(l), abs(t)) <= 7 {
+				continue
+			}
+			if g.get(l, t) == (t%2 == 0) {
+				score++
+			}
+			if g.get(t, l) == (t%2 == 0) {
+				score++
+			}
+		}
+	}
+	return score
+}
+
+// readAztecMode reads and corrects the mode message around the bull's
+// eye, the inverse of drawAztecMode.
+func readAztecMode(g aztecGrid, compact bool) (int, int, bool) {
+	bits := &bitReader{}
+	if compact {
+		bits.bits = make([]bool, 28)
+		for i := 0; i < 7; i++ {
+			offset := i - 3
+			bits.bits[i] = g.get(offset, -5)
+			bits.bits[i+7] = g.get(5, offset)
+			bits.bits[20-i] = g.get(offset, 5)
+			bits.bits[27-i] = g.get(-5, offset)
+		}
+	} else {
+		bits.bits = make([]bool, 40)
+		for i := 0; i < 10; i++ {
+			offset := i - 5 + i/5
+			bits.bits[i] = g.get(offset, -7)
+			bits.bits[i+10] = g.get(7, offset)
+			bits.bits[29-i] = g.get(offset, 7)
+			bits.bits[39-i] = g.get(-7, offset)
+		}
+	}
+
+	words := make([]int, len(bits.bits)/4)
+	for i := range words {
+		words[i] = bits.read(4)
+	}
+	dataWords := 2
+	if !compact {
+		dataWords = 4
+	}
+	if _, err := gfAztec4.decode(words, len(words)-dataWords); err != nil {
+		return 0, 0, false
+	}
+	mode := &bitReader{}
+	for _, w := range words[:dataWords] {
+		mode.append(w, 4)
+	}
+	if compact {
+		return mode.read(2) + 1, mode.read(6) + 1, true
+	}
+	return mode.read(5) + 1, mode.read(11) + 1, true
+}
+
+// readAztecData samples the data layers, the inverse of the placement
+// in encodeAztec, corrects them and decodes the high level encoding.
+func readAztecData(g aztecGrid, compact bool, layers, dataWords int) (Result, error) {
+	base := 14 + layers*4
+	if compact {
+		base = 11 + layers*4
+	}
+	align := make([]int, base)
+	size := base
+	if compact {
+		for i := range align {
+			align[i] = i
+		}
+	} else {
+		size = base + 1 + 2*((base/2-1)/15)
+		origCenter, center := base/2, size/2
+		for i := 0; i < origCenter; i++ {
+			offset := i + i/15
+			align[origCenter-i-1] = center - offset - 1
+			align[origCenter+i] = center + offset + 1
+		}
+	}
+	center := size / 2
+	get := func(x, y int) bool { return g.get(x-center, y-center) }
+
+	total := aztecTotalBits(layers, compact)
+	raw := make([]bool, total)
+	for i, rowOffset := 0, 0; i < layers; i++ {
+		rowSize := (layers-i)*4 + 12
+		if compact {
+			rowSize = (layers-i)*4 + 9
+		}
+		for j := 0; j < rowSize; j++ {
+			columnOffset := j * 2
+			for k := 0; k < 2; k++ {
+				raw[rowOffset+columnOffset+k] = get(align[i*2+k], align[i*2+j])
+				raw[rowOffset+rowSize*2+columnOffset+k] = get(align[i*2+j], align[base-1-i*2-k])
+				raw[rowOffset+rowSize*4+columnOffset+k] = get(align[base-1-i*2-k], align[base-1-i*2-j])
+				raw[rowOffset+rowSize*6+columnOffset+k] = get(align[base-1-i*2-j], align[i*2+k])
+			}
+		}
+		rowOffset += rowSize * 8
+	}
+
+	wordSize := aztecWordSize(layers)
+	words := make([]int, total/wordSize)
+	if dataWords >= len(words) {
+		return Result{}, ErrCorrupted
+	}
+	r := &bitReader{bits: raw, pos: total % wordSize}
+	for i := range words {
+		words[i] = r.read(wordSize)
+	}
+	corrected, err := aztecField[wordSize].decode(words, len(words)-dataWords)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Remove the bit stuffed after all zero or all one words.
+	mask := 1<<wordSize - 1
+	data := &bitReader{}
+	for _, w := range words[:dataWords] {
+		switch w {
+		case 0, mask:
+			return Result{}, ErrCorrupted
+		case 1, mask - 1:
+			data.append(w>>1, wordSize-1)
+		default:
+			data.append(w, wordSize)
+		}
+	}
+
+	content, err := aztecContent(data)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Content: content, Corrected: corrected}, nil
+}
+
+// aztecContent decodes the high level encoding of the data bits.
+func aztecContent(r *bitReader) (string, error) {
+	var sb strings.Builder
+	latch, shift := aztecUpper, aztecUpper
+	for r.remaining() > 0 {
+		if shift == aztecBinary {
+			if r.remaining() < 5 {
+				break
+			}
+			n := r.read(5)
+			if n == 0 {
+				if r.remaining() < 11 {
+					break
+				}
+				n = r.read(11) + 31
+			}
+			// Padding may look like a truncated binary shift.
+			for ; n > 0 && r.remaining() >= 8; n-- {
+				sb.WriteByte(byte(r.read(8)))
+			}
+			shift = latch
+			continue
+		}
+
+		size := 5
+		if shift == aztecDigit {
+			size = 4
+		}
+		if r.remaining() < size {
+			break
+		}
+		s := aztecTables[shift][r.read(size)]
+		switch {
+		case s == "\x00F":
+			n := r.read(3)
+			switch {
+			case n == 0:
+				sb.WriteByte(0x1d)
+			case n == 7 || r.remaining() < n*4:
+				return "", ErrCorrupted
+			default:
+				// ECI designator, content is kept as raw bytes.
+				r.read(n * 4)
+			}
+			shift = latch
+		case s[0] == 0:
+			latch = shift
+			shift = aztecModes[s[1]]
+			if s[2] == 'L' {
+				latch = shift
+			}
+		default:
+			sb.WriteString(s)
+			shift = latch
+		}
+	}
+	return sb.String(), nil
+}
+
+// aztecOutline refits the grid on the outer edges of a symbol of the
+// given size, the bull's eye being too small to pin down a strong
+// perspective on its own. Each side is the line through the outermost
+// dark modules, light modules on the edge falling short of it.
+func aztecOutline(g aztecGrid, size int) (aztecGrid, bool) {
+	c := float64(size / 2)
+	edge := c + 0.5
+	sides := [4]point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	for pass := 0; pass < 2; pass++ {
+		origin := g.t.apply(point{})
+		var lines [4]line
+		for i, u := range sides {
+			v := point{-u.y, u.x}
+			outward := g.t.apply(u.scale(edge)).sub(origin)
+			module := outward.dist(point{}) / edge
+			if module < 1 {
+				return g, false
+			}
+			outward = outward.scale(1 / (module * edge))
+
+			var points []point
+			for a := -c; a <= c; a += 0.5 {
+				last, found := point{}, false
+				for r := c - 5; r <= c+3; r += 0.1 {
+					// A module sized patch, so that speckles of the
+					// background do not pass for the edge.
+					p := g.t.apply(u.scale(r).add(v.scale(a)))
+					dark := 0
+					for dy := -1.0; dy <= 1; dy++ {
+						for dx := -1.0; dx <= 1; dx++ {
+							if g.m.at(p.add(point{dx, dy}.scale(module / 3))) {
+								dark++
+							}
+						}
+					}
+					if dark >= 5 {
+						last, found = g.t.apply(u.scale(r+0.05).add(v.scale(a))), true
+					}
+				}
+				if found {
+					points = append(points, last)
+				}
+			}
+
+			l, ok := fitEdge(points, outward, module)
+			if !ok || len(points) < size/2 {
+				return g, false
+			}
+			lines[i] = l
+		}
+
+		var corners [4]point
+		for i := range corners {
+			p, ok := lines[i].intersect(lines[(i+3)%4])
+			if !ok {
+				return g, false
+			}
+			corners[i] = p
+		}
+		src := [4]point{{-edge, -edge}, {edge, -edge}, {edge, edge}, {-edge, edge}}
+		g.t = newPerspective(src, corners)
+	}
+	return g, true
+}
+
+// fitEdge returns the line through the outermost of points, which lie
+// on an edge facing outward or, where the edge module is light, a whole
+// number of modules inside it. Of the lines through each pair it keeps
+// the one explaining most points, penalising points beyond it, and
+// refits it on the points lying on it.
+func fitEdge(points []point, outward point, module float64) (line, bool) {
+	offset := func(l line, p point) float64 {
+		n := point{-l.d.y, l.d.x}
+		if n.x*outward.x+n.y*outward.y < 0 {
+			n = n.scale(-1)
+		}
+		d := p.sub(l.p)
+		return d.x*n.x + d.y*n.y
+	}
+	best, bestScore := line{}, 0
+	step := max(1, len(points)/40)
+	for i := 0; i < len(points); i += step {
+		for j := i + step; j < len(points); j += step {
+			p, q := points[i], points[j]
+			d := q.sub(p)
+			length := d.dist(point{})
+			if length < module*4 {
+				continue
+			}
+			l := line{p, d.scale(1 / length)}
+			score := 0
+			for _, r := range points {
+				off := offset(l, r)
+				if off > module/3 {
+					score -= 5
+					continue
+				}
+				if k := math.Round(-off / module); k <= 2 && math.Abs(off+k*module) <= module/3 {
+					score++
+				}
+			}
+			if score > bestScore {
+				best, bestScore = l, score
+			}
+		}
+	}
+	if bestScore < 3 {
+		return line{}, false
+	}
+	var inliers []point
+	for _, p := range points {
+		if math.Abs(offset(best, p)) <= module/3 {
+			inliers = append(inliers, p)
+		}
+	}
+	return fitLine(inliers), true
+}
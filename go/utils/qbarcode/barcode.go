@@ -0,0 +1,194 @@
+package qbarcode
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Symbology is a barcode type allowed for boarding passes by IATA
+// Resolution 792.
+type Symbology string
+
+const (
+	PDF417 Symbology = "pdf417"
+	Aztec  Symbology = "aztec"
+	QR     Symbology = "qr"
+)
+
+var (
+	ErrUnsupportedSymbology = errors.New("unsupported symbology")
+	ErrDataTooLong          = errors.New("data too long for symbology")
+)
+
+// Barcode is an encoded symbol as a grid of modules, true being dark.
+// PDF417 rows are repeated to their rendered height.
+type Barcode struct {
+	Symbology Symbology `json:"symbology"`
+	Content   string    `json:"content"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+
+	modules    [][]bool
+	moduleSize int
+	quietZone  int
+}
+
+type option struct {
+	level      int
+	moduleSize int
+	quietZone  int
+	columns    int
+}
+
+type Option func(*option)
+
+// WithErrorCorrection sets the error correction level: 0-3 for QR (L, M,
+// Q, H), 0-8 for PDF417 and the percentage of check words (5-95) for
+// Aztec. Defaults are M, 2 and 23 respectively.
+func WithErrorCorrection(level int) Option {
+	return func(o *option) {
+		o.level = level
+	}
+}
+
+// WithModuleSize sets the size in pixels of one module when rendering.
+func WithModuleSize(px int) Option {
+	return func(o *option) {
+		o.moduleSize = px
+	}
+}
+
+// WithQuietZone sets the blank margin around the symbol, in modules.
+func WithQuietZone(modules int) Option {
+	return func(o *option) {
+		o.quietZone = modules
+	}
+}
+
+// WithColumns sets the number of PDF417 data columns (1-30). By default
+// it is picked to keep the symbol about three times wider than tall.
+func WithColumns(columns int) Option {
+	return func(o *option) {
+		o.columns = columns
+	}
+}
+
+// Encode encodes content, usually a BCBP string, in the given
+// symbology.
+func Encode(content string, symbology Symbology, opts ...Option) (*Barcode, error) {
+	opt := &option{level: -1, moduleSize: 4, quietZone: -1}
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+	if opt.moduleSize < 1 {
+		return nil, errors.New("invalid module size")
+	}
+
+	var modules [][]bool
+	var err error
+	quietZone := 4
+	switch symbology {
+	case QR:
+		modules, err = encodeQR([]byte(content), opt.level)
+	case Aztec:
+		modules, err = encodeAztec([]byte(content), opt.level)
+		quietZone = 1
+	case PDF417:
+		modules, err = encodePDF417([]byte(content), opt.level, opt.columns)
+		quietZone = 2
+	default:
+		return nil, ErrUnsupportedSymbology
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opt.quietZone >= 0 {
+		quietZone = opt.quietZone
+	}
+
+	return &Barcode{
+		Symbology:  symbology,
+		Content:    content,
+		Width:      len(modules[0]),
+		Height:     len(modules),
+		modules:    modules,
+		moduleSize: opt.moduleSize,
+		quietZone:  quietZone,
+	}, nil
+}
+
+// At reports whether the module at x, y is dark.
+func (b *Barcode) At(x, y int) bool {
+	if y < 0 || y >= b.Height || x < 0 || x >= b.Width {
+		return false
+	}
+	return b.modules[y][x]
+}
+
+// Image renders the symbol with its quiet zone.
+func (b *Barcode) Image() image.Image {
+	size := b.moduleSize
+	margin := b.quietZone * size
+	img := image.NewGray(image.Rect(0, 0, b.Width*size+margin*2, b.Height*size+margin*2))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y, row := range b.modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < size; dy++ {
+				for dx := 0; dx < size; dx++ {
+					img.SetGray(margin+x*size+dx, margin+y*size+dy, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
+
+// PNG writes the rendered symbol as a PNG image.
+func (b *Barcode) PNG(w io.Writer) error {
+	return png.Encode(w, b.Image())
+}
+
+// SVG writes the symbol as an SVG document, one rectangle per run of
+// dark modules.
+func (b *Barcode) SVG(w io.Writer) error {
+	size := b.moduleSize
+	width := (b.Width + b.quietZone*2) * size
+	height := (b.Height + b.quietZone*2) * size
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, width, height, width, height)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, width, height)
+	for y, row := range b.modules {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&sb, "M%d %dh%dv%dh-%dz", (start+b.quietZone)*size, (y+b.quietZone)*size, (x-start)*size, size, (x-start)*size)
+		}
+	}
+	sb.WriteString(`"/></svg>`)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func newGrid(width, height int) [][]bool {
+	grid := make([][]bool, height)
+	for i := range grid {
+		grid[i] = make([]bool, width)
+	}
+	return grid
+}
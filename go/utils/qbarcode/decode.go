@@ -0,0 +1,305 @@
+package qbarcode
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+var (
+	ErrNotFound  = errors.New("no barcode found")
+	ErrCorrupted = errors.New("barcode too damaged to decode")
+)
+
+// Result is a decoded symbol.
+type Result struct {
+	Symbology Symbology `json:"symbology"`
+	Content   string    `json:"content"`
+	// Corrected is the number of codewords repaired by error correction.
+	Corrected int `json:"corrected"`
+}
+
+// Decode looks for a QR, Aztec or PDF417 symbol in img, or only the
+// given symbologies, and returns its raw content, usually a BCBP string
+// to hand to qbcbp.ParseBCBP.
+//
+// Symbols may be scaled, rotated and seen at a moderate angle.
+// ErrNotFound is returned when no symbol could be located,
+// ErrCorrupted when one was found but could not be read.
+func Decode(img image.Image, symbologies ...Symbology) (Result, error) {
+	return DecodeContext(context.Background(), img, symbologies...)
+}
+
+// DecodeContext is Decode giving up with the error of ctx once it is
+// done, e.g. to bound the time spent on a camera frame: searching a
+// large frame without any symbol takes several hundred milliseconds.
+func DecodeContext(ctx context.Context, img image.Image, symbologies ...Symbology) (Result, error) {
+	if len(symbologies) == 0 {
+		symbologies = []Symbology{Aztec, QR, PDF417}
+	}
+	for _, s := range symbologies {
+		switch s {
+		case QR, Aztec, PDF417:
+		default:
+			return Result{}, ErrUnsupportedSymbology
+		}
+	}
+	m := binarize(img)
+
+	err := ErrNotFound
+	for _, s := range symbologies {
+		if ctx.Err() != nil {
+			return Result{}, ctx.Err()
+		}
+		var res Result
+		var e error
+		switch s {
+		case QR:
+			res, e = decodeQR(ctx, m)
+		case Aztec:
+			res, e = decodeAztec(ctx, m)
+		case PDF417:
+			res, e = decodePDF417(ctx, m)
+		}
+		if ctx.Err() != nil {
+			return Result{}, ctx.Err()
+		}
+		if e == nil {
+			res.Symbology = s
+			return res, nil
+		}
+		if e != ErrNotFound {
+			err = e
+		}
+	}
+	return Result{}, err
+}
+
+// bitMatrix is a binarized image, true being dark.
+type bitMatrix struct {
+	width, height int
+	bits          []bool
+}
+
+func newBitMatrix(width, height int) *bitMatrix {
+	return &bitMatrix{width: width, height: height, bits: make([]bool, width*height)}
+}
+
+func (m *bitMatrix) get(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return false
+	}
+	return m.bits[y*m.width+x]
+}
+
+func (m *bitMatrix) set(x, y int, dark bool) {
+	m.bits[y*m.width+x] = dark
+}
+
+// at samples the pixel containing point p.
+func (m *bitMatrix) at(p point) bool {
+	return m.get(int(math.Floor(p.x)), int(math.Floor(p.y)))
+}
+
+// rotate returns the matrix turned 90 degrees clockwise.
+func (m *bitMatrix) rotate() *bitMatrix {
+	r := newBitMatrix(m.height, m.width)
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			r.set(m.height-1-y, x, m.get(x, y))
+		}
+	}
+	return r
+}
+
+// runs returns the lengths of the alternating runs of row y, starting
+// with a light one which may be empty.
+func (m *bitMatrix) runs(y int) []int {
+	runs := []int{0}
+	dark := false
+	for x := 0; x < m.width; x++ {
+		if m.get(x, y) != dark {
+			dark = !dark
+			runs = append(runs, 0)
+		}
+		runs[len(runs)-1]++
+	}
+	return runs
+}
+
+const (
+	binarizeBlock    = 8
+	binarizeMinRange = 24
+)
+
+// binarize thresholds img against the local average of 5x5 blocks of 8
+// pixels, which copes with the uneven lighting of photographs. Flat
+// blocks borrow the threshold of their neighbours.
+func binarize(img image.Image) *bitMatrix {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	lum := make([]uint8, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			lum[y*w+x] = color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y
+		}
+	}
+
+	m := newBitMatrix(w, h)
+	bw, bh := (w+binarizeBlock-1)/binarizeBlock, (h+binarizeBlock-1)/binarizeBlock
+	if bw < 5 || bh < 5 {
+		threshold := globalThreshold(lum)
+		for i, l := range lum {
+			m.bits[i] = l < threshold
+		}
+		return m
+	}
+
+	black := make([]int, bw*bh)
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			sum, n, lo, hi := 0, 0, 255, 0
+			for y := by * binarizeBlock; y < min((by+1)*binarizeBlock, h); y++ {
+				for x := bx * binarizeBlock; x < min((bx+1)*binarizeBlock, w); x++ {
+					l := int(lum[y*w+x])
+					sum += l
+					n++
+					lo, hi = min(lo, l), max(hi, l)
+				}
+			}
+			avg := sum / n
+			if hi-lo <= binarizeMinRange {
+				avg = lo / 2
+				if by > 0 && bx > 0 {
+					neighbours := (black[(by-1)*bw+bx] + 2*black[by*bw+bx-1] + black[(by-1)*bw+bx-1]) / 4
+					if lo < neighbours {
+						avg = neighbours
+					}
+				}
+			}
+			black[by*bw+bx] = avg
+		}
+	}
+
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			cx, cy := min(max(bx, 2), bw-3), min(max(by, 2), bh-3)
+			sum := 0
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					sum += black[(cy+dy)*bw+cx+dx]
+				}
+			}
+			threshold := sum / 25
+			for y := by * binarizeBlock; y < min((by+1)*binarizeBlock, h); y++ {
+				for x := bx * binarizeBlock; x < min((bx+1)*binarizeBlock, w); x++ {
+					m.bits[y*w+x] = int(lum[y*w+x]) <= threshold
+				}
+			}
+		}
+	}
+	return m
+}
+
+// globalThreshold picks the threshold between the two main peaks of the
+// histogram (Otsu's method).
+func globalThreshold(lum []uint8) uint8 {
+	var hist [256]int
+	for _, l := range lum {
+		hist[l]++
+	}
+	total, sum := len(lum), 0
+	for i, n := range hist {
+		sum += i * n
+	}
+	best, threshold := -1.0, 128
+	weight, partial := 0, 0
+	for i, n := range hist {
+		weight += n
+		partial += i * n
+		if weight == 0 || weight == total {
+			continue
+		}
+		m1 := float64(partial) / float64(weight)
+		m2 := float64(sum-partial) / float64(total-weight)
+		v := float64(weight) * float64(total-weight) * (m1 - m2) * (m1 - m2)
+		if v > best {
+			best, threshold = v, i+1
+		}
+	}
+	return uint8(min(threshold, 255))
+}
+
+type point struct {
+	x, y float64
+}
+
+func (p point) add(q point) point            { return point{p.x + q.x, p.y + q.y} }
+func (p point) sub(q point) point            { return point{p.x - q.x, p.y - q.y} }
+func (p point) scale(k float64) point        { return point{p.x * k, p.y * k} }
+func (p point) dist(q point) float64         { return math.Hypot(p.x-q.x, p.y-q.y) }
+func (p point) cross(q point) float64        { return p.x*q.y - p.y*q.x }
+func (p point) near(q point, d float64) bool { return p.dist(q) <= d }
+
+// perspective maps the unit square onto a quadrilateral.
+type perspective struct {
+	a11, a12, a13, a21, a22, a23, a31, a32, a33 float64
+}
+
+// newPerspective maps the source quadrilateral onto the destination
+// one, corners in the same order.
+func newPerspective(src, dst [4]point) perspective {
+	return squareToQuad(dst).times(squareToQuad(src).adjoint())
+}
+
+func squareToQuad(q [4]point) perspective {
+	x0, y0, x1, y1, x2, y2, x3, y3 := q[0].x, q[0].y, q[1].x, q[1].y, q[2].x, q[2].y, q[3].x, q[3].y
+	dx3, dy3 := x0-x1+x2-x3, y0-y1+y2-y3
+	if dx3 == 0 && dy3 == 0 {
+		return perspective{x1 - x0, x2 - x1, x0, y1 - y0, y2 - y1, y0, 0, 0, 1}
+	}
+	dx1, dx2, dy1, dy2 := x1-x2, x3-x2, y1-y2, y3-y2
+	den := dx1*dy2 - dx2*dy1
+	a13 := (dx3*dy2 - dx2*dy3) / den
+	a23 := (dx1*dy3 - dx3*dy1) / den
+	return perspective{
+		x1 - x0 + a13*x1, x3 - x0 + a23*x3, x0,
+		y1 - y0 + a13*y1, y3 - y0 + a23*y3, y0,
+		a13, a23, 1,
+	}
+}
+
+func (p perspective) adjoint() perspective {
+	return perspective{
+		p.a22*p.a33 - p.a23*p.a32, p.a13*p.a32 - p.a12*p.a33, p.a12*p.a23 - p.a13*p.a22,
+		p.a23*p.a31 - p.a21*p.a33, p.a11*p.a33 - p.a13*p.a31, p.a13*p.a21 - p.a11*p.a23,
+		p.a21*p.a32 - p.a22*p.a31, p.a12*p.a31 - p.a11*p.a32, p.a11*p.a22 - p.a12*p.a21,
+	}
+}
+
+func (p perspective) times(o perspective) perspective {
+	return perspective{
+		p.a11*o.a11 + p.a12*o.a21 + p.a13*o.a31, p.a11*o.a12 + p.a12*o.a22 + p.a13*o.a32, p.a11*o.a13 + p.a12*o.a23 + p.a13*o.a33,
+		p.a21*o.a11 + p.a22*o.a21 + p.a23*o.a31, p.a21*o.a12 + p.a22*o.a22 + p.a23*o.a32, p.a21*o.a13 + p.a22*o.a23 + p.a23*o.a33,
+		p.a31*o.a11 + p.a32*o.a21 + p.a33*o.a31, p.a31*o.a12 + p.a32*o.a22 + p.a33*o.a32, p.a31*o.a13 + p.a32*o.a23 + p.a33*o.a33,
+	}
+}
+
+func (p perspective) apply(q point) point {
+	den := p.a31*q.x + p.a32*q.y + p.a33
+	return point{(p.a11*q.x + p.a12*q.y + p.a13) / den, (p.a21*q.x + p.a22*q.y + p.a23) / den}
+}
+
+// sampleGrid reads a size x size grid of modules through t, which maps
+// module coordinates to image coordinates.
+func sampleGrid(m *bitMatrix, t perspective, size int) [][]bool {
+	grid := newGrid(size, size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			grid[y][x] = m.at(t.apply(point{float64(x) + 0.5, float64(y) + 0.5}))
+		}
+	}
+	return grid
+}
@@ -0,0 +1,183 @@
+package qbarcode
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPayload is the boarding pass encoded in every testdata image.
+const testPayload = "M1ERIKSSON/ANNA       EABC123 CGKDPSGA 0404 291Y012A0001 100"
+
+func loadImage(t testing.TB, name string) image.Image {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// noiseFrame is a full HD camera frame of random pixels.
+func noiseFrame() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 1920, 1080))
+	rng := rand.New(rand.NewSource(1))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rng.Intn(256))
+	}
+	return img
+}
+
+// The testdata images are renderings of testPayload: plain, rotated by
+// 23 degrees, seen at an angle on a grey background, and with six
+// modules flipped.
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		file      string
+		symbology Symbology
+		corrected bool
+	}{
+		{"qr_plain.png", QR, false},
+		{"qr_rotated.png", QR, false},
+		{"qr_skewed.png", QR, false},
+		{"qr_damaged.png", QR, true},
+		{"aztec_plain.png", Aztec, false},
+		{"aztec_rotated.png", Aztec, false},
+		{"aztec_skewed.png", Aztec, false},
+		{"aztec_damaged.png", Aztec, true},
+		{"pdf417_plain.png", PDF417, false},
+		{"pdf417_rotated.png", PDF417, false},
+		{"pdf417_skewed.png", PDF417, false},
+		{"pdf417_damaged.png", PDF417, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			img := loadImage(t, tt.file)
+			for _, only := range [][]Symbology{nil, {tt.symbology}} {
+				got, err := Decode(img, only...)
+				if err != nil {
+					t.Fatalf("Decode(%v) error = %v", only, err)
+				}
+				if got.Symbology != tt.symbology || got.Content != testPayload {
+					t.Errorf("Decode(%v) = %s %q, want %s %q", only, got.Symbology, got.Content, tt.symbology, testPayload)
+				}
+				if tt.corrected && got.Corrected == 0 {
+					t.Errorf("Decode(%v) corrected no codewords", only)
+				}
+			}
+		})
+	}
+}
+
+// The independent_*.png images are made with another encoder, see
+// testdata/boombuler, so the decoder is not only checked against its
+// own reading of the standards.
+func TestDecodeIndependentEncoder(t *testing.T) {
+	const (
+		// The two leg example of the IATA Resolution 792 implementation guide.
+		twoLegs = "M2DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J003A0027 167>5321WW1325BAC 0014123456002" +
+			"001412346700100141234789012A0141234567890 1AC AC 1234567890123    4PCYLX58Z" +
+			"DEF456 FRAGVALH 3664 327C012C0002 12E2A0141234567890 1AC AC 1234567890123    3PCNWQ" +
+			"^164GIWVC5EH7JNT684FVNJ91W2QA4DVN5J8K4F0L0GEQ3DF5TGBN8709HKT5D3DW3GBHFCVHMY7J5T6HFR41W2QA4DVN5J8K4F0L0GE"
+		oneLeg = "M1DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J001A0025 100"
+	)
+	tests := []struct {
+		file      string
+		symbology Symbology
+		want      string
+	}{
+		{"independent_qr.png", QR, twoLegs},
+		{"independent_qr_rotated.png", QR, twoLegs},
+		{"independent_aztec.png", Aztec, twoLegs},
+		{"independent_aztec_rotated.png", Aztec, twoLegs},
+		{"independent_pdf417.png", PDF417, oneLeg},
+		{"independent_pdf417_rotated.png", PDF417, oneLeg},
+		{"independent_pdf417_two_legs.png", PDF417, twoLegs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := Decode(loadImage(t, tt.file))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got.Symbology != tt.symbology || got.Content != tt.want {
+				t.Errorf("Decode() = %s %q, want %s %q", got.Symbology, got.Content, tt.symbology, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeNotFound(t *testing.T) {
+	// Noise may pass for a damaged symbol, but must never decode.
+	if _, err := Decode(loadImage(t, "noise.png")); !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrCorrupted) {
+		t.Errorf("Decode(noise) error = %v, want %v or %v", err, ErrNotFound, ErrCorrupted)
+	}
+	if _, err := Decode(loadImage(t, "qr_plain.png"), Aztec, PDF417); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Decode(QR as Aztec, PDF417) error = %v, want %v", err, ErrNotFound)
+	}
+	if _, err := Decode(loadImage(t, "qr_plain.png"), "code128"); !errors.Is(err, ErrUnsupportedSymbology) {
+		t.Errorf("Decode() error = %v, want %v", err, ErrUnsupportedSymbology)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, s := range []Symbology{QR, Aztec, PDF417} {
+		b, err := Encode(testPayload, s, WithModuleSize(2))
+		if err != nil {
+			t.Fatalf("Encode(%s) error = %v", s, err)
+		}
+		got, err := Decode(b.Image(), s)
+		if err != nil || got.Content != testPayload {
+			t.Errorf("Decode(Encode(%s)) = %q, %v, want %q", s, got.Content, err, testPayload)
+		}
+	}
+}
+
+// BenchmarkDecodeNoise measures the worst case of a camera frame
+// without any symbol, where every finder candidate is tried.
+func BenchmarkDecodeNoise(b *testing.B) {
+	img := noiseFrame()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(img); err == nil {
+			b.Fatal("Decode() of noise succeeded")
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, file := range []string{"qr_skewed.png", "aztec_skewed.png", "pdf417_skewed.png"} {
+		img := loadImage(b, file)
+		b.Run(file, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := Decode(img); err != nil {
+					b.Fatalf("Decode() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeContextDeadline(t *testing.T) {
+	img := noiseFrame()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := DecodeContext(ctx, img); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DecodeContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("DecodeContext() took %v after a 20ms deadline", elapsed)
+	}
+}
@@ -0,0 +1,273 @@
+package qbarcode
+
+import "errors"
+
+const (
+	pdf417Start     = 0x1fea8
+	pdf417Stop      = 0x3fa29
+	pdf417RowHeight = 3
+	pdf417Pad       = 900
+
+	pdf417LatchByte      = 901
+	pdf417LatchByteSix   = 924
+	pdf417MaxCodewords   = 928
+	pdf417PreferredRatio = 3.0
+)
+
+// Text compaction sub-mode values, -1 when a character is not part of
+// the sub-mode.
+var (
+	pdf417Mixed = [128]int8{}
+	pdf417Punct = [128]int8{}
+)
+
+const (
+	pdf417MixedChars = "0123456789&\r\t,:#-.$/+%*=^"
+	pdf417PunctChars = ";<>@[\\]_`~!\r\t,:\n-.$/\"|*()?{}'"
+)
+
+func init() {
+	for i := range pdf417Mixed {
+		pdf417Mixed[i], pdf417Punct[i] = -1, -1
+	}
+	for i, c := range pdf417MixedChars {
+		pdf417Mixed[c] = int8(i)
+	}
+	pdf417Mixed[' '] = 26
+	for i, c := range pdf417PunctChars {
+		pdf417Punct[c] = int8(i)
+	}
+}
+
+// encodePDF417 encodes data with text compaction when it is printable
+// ASCII and byte compaction otherwise. level is the error correction
+// level (0-8), -1 for the recommended minimum.
+func encodePDF417(data []byte, level, columns int) ([][]bool, error) {
+	words := pdf417Text(data)
+	if words == nil {
+		words = pdf417Bytes(data)
+	}
+
+	if level < 0 {
+		switch n := len(words); {
+		case n <= 40:
+			level = 2
+		case n <= 160:
+			level = 3
+		case n <= 320:
+			level = 4
+		default:
+			level = 5
+		}
+	}
+	if level > 8 {
+		return nil, errors.New("invalid PDF417 error correction level")
+	}
+	ecCount := 2 << level
+
+	n := len(words) + 1 + ecCount
+	if n > pdf417MaxCodewords {
+		return nil, ErrDataTooLong
+	}
+	rows := 0
+	if columns > 0 {
+		if columns > 30 {
+			return nil, errors.New("invalid PDF417 column count")
+		}
+		rows = max((n+columns-1)/columns, 3)
+	} else {
+		columns, rows = pdf417Dimensions(n)
+	}
+	if rows == 0 || rows > 90 || rows*columns > pdf417MaxCodewords {
+		return nil, ErrDataTooLong
+	}
+
+	for len(words)+1+ecCount < rows*columns {
+		words = append(words, pdf417Pad)
+	}
+	words = append([]int{len(words) + 1}, words...)
+	words = append(words, gfPDF417.encode(words, ecCount)...)
+
+	width := (columns+4)*17 + 1
+	grid := make([][]bool, 0, rows*pdf417RowHeight)
+	for r := 0; r < rows; r++ {
+		cluster := r % 3
+		base := 30 * (r / 3)
+		var left, right int
+		switch cluster {
+		case 0:
+			left, right = base+(rows-1)/3, base+columns-1
+		case 1:
+			left, right = base+level*3+(rows-1)%3, base+(rows-1)/3
+		default:
+			left, right = base+columns-1, base+level*3+(rows-1)%3
+		}
+
+		row := make([]bool, 0, width)
+		row = appendPattern(row, pdf417Start, 17)
+		row = appendPattern(row, pdf417Patterns[cluster][left], 17)
+		for _, w := range words[r*columns : (r+1)*columns] {
+			row = appendPattern(row, pdf417Patterns[cluster][w], 17)
+		}
+		row = appendPattern(row, pdf417Patterns[cluster][right], 17)
+		row = appendPattern(row, pdf417Stop, 18)
+		for i := 0; i < pdf417RowHeight; i++ {
+			grid = append(grid, row)
+		}
+	}
+	return grid, nil
+}
+
+// pdf417Dimensions picks the column count that brings the symbol
+// closest to the preferred aspect ratio.
+func pdf417Dimensions(n int) (columns, rows int) {
+	bestRatio := -1.0
+	for c := 1; c <= 30; c++ {
+		r := max((n+c-1)/c, 3)
+		if r > 90 || r*c > pdf417MaxCodewords {
+			continue
+		}
+		ratio := float64(17*c+69) / float64(r*pdf417RowHeight)
+		diff := ratio - pdf417PreferredRatio
+		if diff < 0 {
+			diff = -diff
+		}
+		if bestRatio < 0 || diff < bestRatio {
+			bestRatio, columns, rows = diff, c, r
+		}
+	}
+	return columns, rows
+}
+
+func appendPattern(row []bool, pattern, n int) []bool {
+	for i := n - 1; i >= 0; i-- {
+		row = append(row, (pattern>>i)&1 != 0)
+	}
+	return row
+}
+
+const (
+	pdf417Alpha = iota
+	pdf417Lower
+	pdf417MixedMode
+	pdf417PunctMode
+)
+
+// pdf417Text applies text compaction, starting in the alpha sub-mode
+// the symbol begins with. It returns nil if data is not text.
+func pdf417Text(data []byte) []int {
+	isUpper := func(c byte) bool { return c == ' ' || (c >= 'A' && c <= 'Z') }
+	isLower := func(c byte) bool { return c == ' ' || (c >= 'a' && c <= 'z') }
+	isMixed := func(c byte) bool { return c < 128 && pdf417Mixed[c] >= 0 }
+	isPunct := func(c byte) bool { return c < 128 && pdf417Punct[c] >= 0 }
+
+	values := []int{}
+	mode := pdf417Alpha
+	for i := 0; i < len(data); {
+		c := data[i]
+		if !isUpper(c) && !isLower(c) && !isMixed(c) && !isPunct(c) {
+			return nil
+		}
+		switch mode {
+		case pdf417Alpha:
+			switch {
+			case isUpper(c):
+				values = append(values, alphaValue(c, 'A'))
+				i++
+			case isLower(c):
+				mode = pdf417Lower
+				values = append(values, 27)
+			case isMixed(c):
+				mode = pdf417MixedMode
+				values = append(values, 28)
+			default:
+				values = append(values, 29, int(pdf417Punct[c]))
+				i++
+			}
+		case pdf417Lower:
+			switch {
+			case isLower(c):
+				values = append(values, alphaValue(c, 'a'))
+				i++
+			case isUpper(c):
+				values = append(values, 27, int(c-'A'))
+				i++
+			case isMixed(c):
+				mode = pdf417MixedMode
+				values = append(values, 28)
+			default:
+				values = append(values, 29, int(pdf417Punct[c]))
+				i++
+			}
+		case pdf417MixedMode:
+			switch {
+			case isMixed(c):
+				values = append(values, int(pdf417Mixed[c]))
+				i++
+			case isUpper(c):
+				mode = pdf417Alpha
+				values = append(values, 28)
+			case isLower(c):
+				mode = pdf417Lower
+				values = append(values, 27)
+			case i+1 < len(data) && isPunct(data[i+1]):
+				mode = pdf417PunctMode
+				values = append(values, 25)
+			default:
+				values = append(values, 29, int(pdf417Punct[c]))
+				i++
+			}
+		default:
+			if isPunct(c) {
+				values = append(values, int(pdf417Punct[c]))
+				i++
+			} else {
+				mode = pdf417Alpha
+				values = append(values, 29)
+			}
+		}
+	}
+
+	if len(values)%2 != 0 {
+		values = append(values, 29)
+	}
+	words := make([]int, 0, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		words = append(words, values[i]*30+values[i+1])
+	}
+	return words
+}
+
+func alphaValue(c, first byte) int {
+	if c == ' ' {
+		return 26
+	}
+	return int(c - first)
+}
+
+// pdf417Bytes applies byte compaction: six bytes in five base 900
+// codewords, the remainder one byte per codeword.
+func pdf417Bytes(data []byte) []int {
+	latch := pdf417LatchByte
+	if len(data)%6 == 0 {
+		latch = pdf417LatchByteSix
+	}
+	words := []int{latch}
+	for len(data) >= 6 {
+		v := 0
+		for _, b := range data[:6] {
+			v = v<<8 | int(b)
+		}
+		group := make([]int, 5)
+		for i := 4; i >= 0; i-- {
+			group[i] = v % 900
+			v /= 900
+		}
+		words = append(words, group...)
+		data = data[6:]
+	}
+	for _, b := range data {
+		words = append(words, int(b))
+	}
+	return words
+}
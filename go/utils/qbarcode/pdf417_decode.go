@@ -0,0 +1,643 @@
+package qbarcode
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+const (
+	pdf417LatchText      = 900
+	pdf417LatchNumeric   = 902
+	pdf417ShiftByte      = 913
+	pdf417ReaderInit     = 921
+	pdf417MacroTerminate = 922
+	pdf417MacroOptional  = 923
+	pdf417ECIUser        = 925
+	pdf417ECIGeneral     = 926
+	pdf417ECICharset     = 927
+	pdf417MacroControl   = 928
+)
+
+var (
+	pdf417StartWidths = []float64{8, 1, 1, 1, 1, 1, 1, 3}
+	// pdf417StopWidths leaves out the final bar of the stop pattern.
+	pdf417StopWidths = []float64{7, 1, 1, 3, 1, 1, 1, 2}
+)
+
+// pdf417Values maps the pattern of each cluster back to its codeword.
+var pdf417Values [3]map[int]int
+
+func init() {
+	for c := range pdf417Patterns {
+		pdf417Values[c] = make(map[int]int, len(pdf417Patterns[c]))
+		for v, p := range pdf417Patterns[c] {
+			pdf417Values[c][p] = v
+		}
+	}
+}
+
+// pdf417Word is a codeword read on a scanline, col 0 being the left row
+// indicator.
+type pdf417Word struct {
+	col, cluster, value int
+}
+
+// decodePDF417 finds the start and stop patterns scanning rows and
+// columns both ways and fits the lines their bars lie on, then reads
+// scanlines from one to the other. Rows are told apart by their row
+// indicators and each codeword is voted on over the scanlines crossing
+// it.
+func decodePDF417(ctx context.Context, m *bitMatrix) (Result, error) {
+	err := ErrNotFound
+	for _, scan := range []struct {
+		origin, along, across point
+		lines, length         int
+	}{
+		{point{0, 0.5}, point{1, 0}, point{0, 1}, m.height, m.width},
+		{point{float64(m.width), 0.5}, point{-1, 0}, point{0, 1}, m.height, m.width},
+		{point{0.5, 0}, point{0, 1}, point{1, 0}, m.width, m.height},
+		{point{0.5, float64(m.height)}, point{0, -1}, point{1, 0}, m.width, m.height},
+	} {
+		var start, stop edgeChains
+		for i := 0; i < scan.lines; i++ {
+			if i%64 == 0 && ctx.Err() != nil {
+				return Result{}, ErrNotFound
+			}
+			p := scan.origin.add(scan.across.scale(float64(i)))
+			runs := lineRuns(m, p, scan.along, 1, scan.length)
+			pos := 0
+			for j, r := range runs {
+				if j%2 == 1 && j+8 <= len(runs) {
+					hit := p.add(scan.along.scale(float64(pos)))
+					if w, ok := matchWidths(runs[j:j+8], pdf417StartWidths); ok {
+						start.add(i, pos, hit, w)
+					} else if w, ok := matchWidths(runs[j:j+8], pdf417StopWidths); ok {
+						stop.add(i, pos, hit, w)
+					}
+				}
+				pos += r
+			}
+		}
+
+		startEdge, ok := start.edge(m, scan.along, pdf417StartWidths[0])
+		if !ok {
+			continue
+		}
+		stopEdge, ok := stop.edge(m, scan.along, pdf417StopWidths[0])
+		if !ok {
+			// Without the stop pattern the rows are taken to run square
+			// to the start pattern.
+			d := startEdge.b.sub(startEdge.a)
+			across := point{-d.y, d.x}.scale(float64(scan.length) / d.dist(point{}))
+			if across.x*scan.along.x+across.y*scan.along.y < 0 {
+				across = across.scale(-1)
+			}
+			stopEdge = pdf417Edge{startEdge.a.add(across), startEdge.b.add(across), startEdge.module}
+		}
+		res, e := readPDF417(m, startEdge, stopEdge)
+		if e == nil {
+			return res, nil
+		}
+		if e != ErrNotFound {
+			err = e
+		}
+	}
+	return Result{}, err
+}
+
+// pdf417Edge is the leading edge of the start or stop pattern, from a
+// to b.
+type pdf417Edge struct {
+	a, b   point
+	module float64
+}
+
+// edgeChains links the hits of a pattern on consecutive scanlines that
+// are close to each other.
+type edgeChains []*edgeChain
+
+type edgeChain struct {
+	hits      []point
+	module    float64
+	line, pos int
+}
+
+func (cs *edgeChains) add(line, pos int, hit point, module float64) {
+	var c *edgeChain
+	for _, o := range *cs {
+		if float64(line-o.line) <= module*6 && math.Abs(float64(o.pos-pos)) <= module*2 {
+			c = o
+			break
+		}
+	}
+	if c == nil {
+		c = &edgeChain{}
+		*cs = append(*cs, c)
+	}
+	c.hits = append(c.hits, hit)
+	c.module += module
+	c.line, c.pos = line, pos
+}
+
+// edge fits the line through the longest chain, then follows it on
+// both sides as far as the wide leading bar of the pattern, bar modules
+// wide, goes: a slanted scanline only sees the whole pattern away from
+// the ends of the symbol.
+func (cs edgeChains) edge(m *bitMatrix, along point, bar float64) (pdf417Edge, bool) {
+	var longest *edgeChain
+	for _, c := range cs {
+		if longest == nil || len(c.hits) > len(longest.hits) {
+			longest = c
+		}
+	}
+	if longest == nil || len(longest.hits) < 3 {
+		return pdf417Edge{}, false
+	}
+	module := longest.module / float64(len(longest.hits))
+	l := consensusLine(longest.hits, module)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, h := range longest.hits {
+		if math.Abs(h.sub(l.p).cross(l.d)) > module {
+			continue
+		}
+		d := h.sub(l.p)
+		t := d.x*l.d.x + d.y*l.d.y
+		lo, hi = math.Min(lo, t), math.Max(hi, t)
+	}
+
+	into := point{-l.d.y, l.d.x}
+	cos := into.x*along.x + into.y*along.y
+	if cos < 0 {
+		into, cos = into.scale(-1), -cos
+	}
+	module *= cos
+	mid := into.scale(module * bar / 2)
+	follow := func(t, dir float64) float64 {
+		// The bar ends where under three quarters of the last two
+		// modules are dark.
+		var window []bool
+		width, dark, end := int(module*4)+1, 0, t
+		for i := 0; ; i++ {
+			pos := t + dir*0.5*float64(i)
+			d := m.at(l.p.add(l.d.scale(pos)).add(mid))
+			window = append(window, d)
+			if d {
+				dark++
+			}
+			if len(window) > width {
+				if window[0] {
+					dark--
+				}
+				window = window[1:]
+			}
+			if dark*4 < len(window)*3 {
+				return end
+			}
+			if d {
+				end = pos
+			}
+		}
+	}
+	lo, hi = follow(lo, -1), follow(hi, 1)
+	return pdf417Edge{l.p.add(l.d.scale(lo)), l.p.add(l.d.scale(hi)), module}, true
+}
+
+// lineRuns samples n steps of length step from p along d and returns
+// the lengths of the alternating runs in steps, starting with a light
+// one which may be empty.
+func lineRuns(m *bitMatrix, p, d point, step float64, n int) []int {
+	runs := []int{0}
+	dark := false
+	for i := 0; i < n; i++ {
+		if m.at(p.add(d.scale((float64(i)+0.5)*step))) != dark {
+			dark = !dark
+			runs = append(runs, 0)
+		}
+		runs[len(runs)-1]++
+	}
+	return runs
+}
+
+// matchWidths reports whether runs have the relative widths of a
+// pattern, returning the module width.
+func matchWidths(runs []int, widths []float64) (float64, bool) {
+	total, modules := 0, 0.0
+	for i, w := range widths {
+		total += runs[i]
+		modules += w
+	}
+	module := float64(total) / modules
+	for i, w := range widths {
+		if math.Abs(float64(runs[i])-w*module) > module*0.8+w*module*0.25 {
+			return 0, false
+		}
+	}
+	return module, true
+}
+
+// readPDF417 reads scanlines from the start edge to the matching point
+// of the stop edge, and assembles the codewords they agree on.
+func readPDF417(m *bitMatrix, start, stop pdf417Edge) (Result, error) {
+	if start.a.dist(stop.a)+start.b.dist(stop.b) > start.a.dist(stop.b)+start.b.dist(stop.a) {
+		stop.a, stop.b = stop.b, stop.a
+	}
+	steps := int(math.Max(start.a.dist(start.b), stop.a.dist(stop.b))) + 1
+
+	votes := map[[2]int]map[int]int{}
+	vote := func(row, col, value int) {
+		k := [2]int{row, col}
+		if votes[k] == nil {
+			votes[k] = map[int]int{}
+		}
+		votes[k][value]++
+	}
+	var lines [][]pdf417Word
+	for k := 0; k <= steps; k++ {
+		f := float64(k) / float64(steps)
+		from := start.a.add(start.b.sub(start.a).scale(f))
+		to := stop.a.add(stop.b.sub(stop.a).scale(f))
+		length := from.dist(to)
+		if length < start.module*17 {
+			continue
+		}
+		d := to.sub(from).scale(1 / length)
+		p := from.sub(d.scale(start.module * 2))
+		runs := lineRuns(m, p, d, 0.5, int((length+start.module*24)*2))
+		words, stopped := readPDF417Line(runs)
+		if len(words) == 0 {
+			continue
+		}
+		lines = append(lines, words)
+
+		// The row indicators give the size and error correction level
+		// of the symbol, the right one in a different order.
+		if w := words[0]; w.col == 0 {
+			vote(-1, w.cluster, w.value%30)
+		}
+		if w := words[len(words)-1]; stopped && w.col > 1 {
+			vote(-1, (w.cluster+2)%3, w.value%30)
+		}
+	}
+	// candidates returns the values voted for at least half as often
+	// as the leading one, most voted first.
+	candidates := func(row, col int) []int {
+		v := votes[[2]int{row, col}]
+		values, top := make([]int, 0, len(v)), 0
+		for value, n := range v {
+			values = append(values, value)
+			top = max(top, n)
+		}
+		sort.Slice(values, func(i, j int) bool {
+			if v[values[i]] != v[values[j]] {
+				return v[values[i]] > v[values[j]]
+			}
+			return values[i] < values[j]
+		})
+		for i, value := range values {
+			if 2*v[value] < top {
+				return values[:i]
+			}
+		}
+		return values
+	}
+	levelRows, colsLeft := candidates(-1, 1), candidates(-1, 2)
+	if len(levelRows) == 0 || len(colsLeft) == 0 {
+		return Result{}, ErrNotFound
+	}
+	// The left and right indicators may disagree on the row count, as
+	// with encoders that get one of them wrong, so every count with
+	// enough votes is tried and the error correction picks the one that
+	// reads.
+	err := ErrNotFound
+	for _, rowsHigh := range candidates(-1, 0) {
+		rows, cols, level := rowsHigh*3+levelRows[0]%3+1, colsLeft[0]+1, levelRows[0]/3
+		if rows < 3 || rows > 90 || cols > 30 {
+			continue
+		}
+		var ret Result
+		if ret, err = readPDF417Grid(lines, rows, cols, level); err == nil {
+			return ret, nil
+		}
+	}
+	return Result{}, err
+}
+
+// readPDF417Grid votes on the codewords of a symbol of the given size
+// over the scanlines and decodes them.
+func readPDF417Grid(lines [][]pdf417Word, rows, cols, level int) (Result, error) {
+	votes := map[[2]int]map[int]int{}
+	for _, words := range lines {
+		row := -1
+		for _, w := range words {
+			if w.col == 0 || w.col == cols+1 {
+				row = w.value/30*3 + w.cluster
+				break
+			}
+		}
+		if row < 0 || row >= rows {
+			continue
+		}
+		for _, w := range words {
+			if w.col >= 1 && w.col <= cols && w.cluster == row%3 {
+				k := [2]int{row, w.col}
+				if votes[k] == nil {
+					votes[k] = map[int]int{}
+				}
+				votes[k][w.value]++
+			}
+		}
+	}
+
+	words := make([]int, rows*cols)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			value, count := 0, 0
+			for v, n := range votes[[2]int{r, c + 1}] {
+				if n > count || (n == count && v < value) {
+					value, count = v, n
+				}
+			}
+			words[r*cols+c] = value
+		}
+	}
+	ecCount := 2 << level
+	if ecCount >= len(words) {
+		return Result{}, ErrNotFound
+	}
+	corrected, err := gfPDF417.decode(words, ecCount)
+	if err != nil {
+		return Result{}, err
+	}
+	n := words[0]
+	if n < 1 || n > len(words)-ecCount {
+		return Result{}, ErrCorrupted
+	}
+	content, err := pdf417Content(words[1:n])
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Content: content, Corrected: corrected}, nil
+}
+
+// readPDF417Line reads the codewords of a scanline from the first start
+// pattern on, reporting whether it reached the stop pattern. A codeword
+// that does not read is skipped and the column of the next one worked
+// out from its position.
+func readPDF417Line(runs []int) ([]pdf417Word, bool) {
+	starts := make([]int, len(runs)+1)
+	for k, r := range runs {
+		starts[k+1] = starts[k] + r
+	}
+	i, module := 1, 0.0
+	for ; i+8 <= len(runs); i += 2 {
+		if w, ok := matchWidths(runs[i:i+8], pdf417StartWidths); ok {
+			module = w
+			break
+		}
+	}
+	if module == 0 {
+		return nil, false
+	}
+	i += 8
+
+	var words []pdf417Word
+	col, end := -1, starts[i]
+	for i+8 <= len(runs) {
+		width := starts[i+8] - starts[i]
+		if modules, ok := pdf417Modules(runs[i:i+8], width); ok && pdf417IsStop(modules) {
+			return words, true
+		}
+		cluster, value, ok := pdf417Codeword(runs[i:i+8], width)
+		if !ok || math.Abs(float64(width)-module*17) > module*17*0.25 {
+			i += 2
+			continue
+		}
+		col += 1 + int(math.Round(float64(starts[i]-end)/(module*17)))
+		words = append(words, pdf417Word{col, cluster, value})
+		module, end = float64(width)/17, starts[i+8]
+		i += 8
+	}
+	return words, false
+}
+
+// pdf417Modules rounds eight runs spanning width to the 17 modules of
+// a codeword, adjusting the runs furthest from a whole module until
+// they add up.
+func pdf417Modules(runs []int, width int) ([8]int, bool) {
+	var modules [8]int
+	total := 0
+	for j, r := range runs {
+		modules[j] = max(int(math.Round(float64(r)*17/float64(width))), 1)
+		total += modules[j]
+	}
+	for total != 17 {
+		worst, diff := 0, 0.0
+		for j, r := range runs {
+			d := float64(r)*17/float64(width) - float64(modules[j])
+			if total > 17 {
+				d = -d
+			}
+			if d > diff && (total < 17 || modules[j] > 1) {
+				worst, diff = j, d
+			}
+		}
+		if diff == 0 {
+			return modules, false
+		}
+		if total > 17 {
+			modules[worst]--
+			total--
+		} else {
+			modules[worst]++
+			total++
+		}
+	}
+	return modules, true
+}
+
+func pdf417IsStop(modules [8]int) bool {
+	for j, w := range pdf417StopWidths {
+		if modules[j] != int(w) {
+			return false
+		}
+	}
+	return true
+}
+
+// pdf417Codeword reads the codeword of eight runs spanning width.
+func pdf417Codeword(runs []int, width int) (cluster, value int, ok bool) {
+	modules, ok := pdf417Modules(runs, width)
+	if !ok {
+		return 0, 0, false
+	}
+	pattern := 0
+	for j, n := range modules {
+		if n > 6 {
+			return 0, 0, false
+		}
+		for ; n > 0; n-- {
+			pattern <<= 1
+			if j%2 == 0 {
+				pattern |= 1
+			}
+		}
+	}
+	cluster = ((modules[0] - modules[2] + modules[4] - modules[6] + 9) % 9) / 3
+	value, ok = pdf417Values[cluster][pattern]
+	return cluster, value, ok
+}
+
+// pdf417Content parses the data codewords, the length descriptor
+// excluded.
+func pdf417Content(words []int) (string, error) {
+	var sb strings.Builder
+	text := pdf417TextDecoder{sb: &sb}
+	for i := 0; i < len(words); {
+		switch w := words[i]; w {
+		case pdf417LatchText:
+			text.mode, text.shift = pdf417Alpha, -1
+			i++
+		case pdf417LatchByte, pdf417LatchByteSix:
+			j := i + 1
+			for j < len(words) && words[j] < pdf417LatchText {
+				j++
+			}
+			if err := pdf417ByteContent(&sb, words[i+1:j], w == pdf417LatchByteSix); err != nil {
+				return "", err
+			}
+			text.mode, text.shift = pdf417Alpha, -1
+			i = j
+		case pdf417ShiftByte:
+			if i+1 >= len(words) || words[i+1] > 255 {
+				return "", ErrCorrupted
+			}
+			sb.WriteByte(byte(words[i+1]))
+			i += 2
+		case pdf417LatchNumeric:
+			j := i + 1
+			for j < len(words) && words[j] < pdf417LatchText {
+				j++
+			}
+			for k := i + 1; k < j; k += 15 {
+				pdf417Numeric(&sb, words[k:min(k+15, j)])
+			}
+			text.mode, text.shift = pdf417Alpha, -1
+			i = j
+		case pdf417ECIUser, pdf417ECICharset:
+			i += 2
+		case pdf417ECIGeneral:
+			i += 3
+		case pdf417ReaderInit:
+			i++
+		case pdf417MacroControl, pdf417MacroTerminate, pdf417MacroOptional:
+			return sb.String(), nil
+		default:
+			if w > pdf417LatchText {
+				return "", ErrCorrupted
+			}
+			text.write(w / 30)
+			text.write(w % 30)
+			i++
+		}
+	}
+	return sb.String(), nil
+}
+
+// pdf417TextDecoder follows the sub-mode latches and shifts of text
+// compaction. shift is the sub-mode to return to after a shifted
+// character, -1 when none is pending.
+type pdf417TextDecoder struct {
+	sb          *strings.Builder
+	mode, shift int
+}
+
+func (d *pdf417TextDecoder) write(v int) {
+	mode := d.mode
+	if d.shift >= 0 {
+		d.mode, d.shift = d.shift, -1
+	}
+	switch mode {
+	case pdf417Alpha, pdf417Lower:
+		switch {
+		case v < 26 && mode == pdf417Alpha:
+			d.sb.WriteByte(byte('A' + v))
+		case v < 26:
+			d.sb.WriteByte(byte('a' + v))
+		case v == 26:
+			d.sb.WriteByte(' ')
+		case v == 27 && mode == pdf417Alpha:
+			d.mode = pdf417Lower
+		case v == 27:
+			d.mode, d.shift = pdf417Alpha, pdf417Lower
+		case v == 28:
+			d.mode = pdf417MixedMode
+		default:
+			d.mode, d.shift = pdf417PunctMode, d.mode
+		}
+	case pdf417MixedMode:
+		switch {
+		case v < 25:
+			d.sb.WriteByte(pdf417MixedChars[v])
+		case v == 25:
+			d.mode = pdf417PunctMode
+		case v == 26:
+			d.sb.WriteByte(' ')
+		case v == 27:
+			d.mode = pdf417Lower
+		case v == 28:
+			d.mode = pdf417Alpha
+		default:
+			d.mode, d.shift = pdf417PunctMode, d.mode
+		}
+	default:
+		if v < 29 {
+			d.sb.WriteByte(pdf417PunctChars[v])
+		} else if d.shift < 0 && mode == d.mode {
+			d.mode = pdf417Alpha
+		}
+	}
+}
+
+// pdf417ByteContent writes byte compacted codewords. Groups of five
+// hold six bytes; with the 901 latch the last group, even a full one,
+// holds a byte per codeword.
+func pdf417ByteContent(sb *strings.Builder, words []int, six bool) error {
+	for len(words) >= 5 && (six || len(words) > 5) {
+		v := 0
+		for _, w := range words[:5] {
+			v = v*900 + w
+		}
+		if v >= 1<<48 {
+			return ErrCorrupted
+		}
+		for j := 5; j >= 0; j-- {
+			sb.WriteByte(byte(v >> (8 * j)))
+		}
+		words = words[5:]
+	}
+	for _, w := range words {
+		if w > 255 {
+			return ErrCorrupted
+		}
+		sb.WriteByte(byte(w))
+	}
+	return nil
+}
+
+// pdf417Numeric writes a group of up to 15 numeric compacted codewords,
+// a base 900 number whose decimal digits follow a leading 1.
+func pdf417Numeric(sb *strings.Builder, words []int) {
+	v := new(big.Int)
+	base := big.NewInt(900)
+	for _, w := range words {
+		v.Mul(v, base)
+		v.Add(v, big.NewInt(int64(w)))
+	}
+	s := v.String()
+	if len(s) > 1 {
+		sb.WriteString(s[1:])
+	}
+}
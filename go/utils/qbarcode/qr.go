@@ -0,0 +1,446 @@
+package qbarcode
+
+import "errors"
+
+// QR error correction levels.
+const (
+	QRLevelL = iota
+	QRLevelM
+	QRLevelQ
+	QRLevelH
+)
+
+// qrFormatBits are the error correction level bits of the format
+// information.
+var qrFormatBits = [4]int{1, 0, 3, 2}
+
+// qrBlock describes the error correction blocks of a version and level:
+// check words per block, then count and data words of the two groups.
+type qrBlock struct {
+	ec            int
+	count1, data1 int
+	count2, data2 int
+}
+
+// qrBlocks lists versions 1 to 20 (up to 858 bytes at level L), plenty
+// for any boarding pass. Indexed by version-1 and level.
+var qrBlocks = [][4]qrBlock{
+	{{7, 1, 19, 0, 0}, {10, 1, 16, 0, 0}, {13, 1, 13, 0, 0}, {17, 1, 9, 0, 0}},
+	{{10, 1, 34, 0, 0}, {16, 1, 28, 0, 0}, {22, 1, 22, 0, 0}, {28, 1, 16, 0, 0}},
+	{{15, 1, 55, 0, 0}, {26, 1, 44, 0, 0}, {18, 2, 17, 0, 0}, {22, 2, 13, 0, 0}},
+	{{20, 1, 80, 0, 0}, {18, 2, 32, 0, 0}, {26, 2, 24, 0, 0}, {16, 4, 9, 0, 0}},
+	{{26, 1, 108, 0, 0}, {24, 2, 43, 0, 0}, {18, 2, 15, 2, 16}, {22, 2, 11, 2, 12}},
+	{{18, 2, 68, 0, 0}, {16, 4, 27, 0, 0}, {24, 4, 19, 0, 0}, {28, 4, 15, 0, 0}},
+	{{20, 2, 78, 0, 0}, {18, 4, 31, 0, 0}, {18, 2, 14, 4, 15}, {26, 4, 13, 1, 14}},
+	{{24, 2, 97, 0, 0}, {22, 2, 38, 2, 39}, {22, 4, 18, 2, 19}, {26, 4, 14, 2, 15}},
+	{{30, 2, 116, 0, 0}, {22, 3, 36, 2, 37}, {20, 4, 16, 4, 17}, {24, 4, 12, 4, 13}},
+	{{18, 2, 68, 2, 69}, {26, 4, 43, 1, 44}, {24, 6, 19, 2, 20}, {28, 6, 15, 2, 16}},
+	{{20, 4, 81, 0, 0}, {30, 1, 50, 4, 51}, {28, 4, 22, 4, 23}, {24, 3, 12, 8, 13}},
+	{{24, 2, 92, 2, 93}, {22, 6, 36, 2, 37}, {26, 4, 20, 6, 21}, {28, 7, 14, 4, 15}},
+	{{26, 4, 107, 0, 0}, {22, 8, 37, 1, 38}, {24, 8, 20, 4, 21}, {22, 12, 11, 4, 12}},
+	{{30, 3, 115, 1, 116}, {24, 4, 40, 5, 41}, {20, 11, 16, 5, 17}, {24, 11, 12, 5, 13}},
+	{{22, 5, 87, 1, 88}, {24, 5, 41, 5, 42}, {30, 5, 24, 7, 25}, {24, 11, 12, 7, 13}},
+	{{24, 5, 98, 1, 99}, {28, 7, 45, 3, 46}, {24, 15, 19, 2, 20}, {30, 3, 15, 13, 16}},
+	{{28, 1, 107, 5, 108}, {28, 10, 46, 1, 47}, {28, 1, 22, 15, 23}, {28, 2, 14, 17, 15}},
+	{{30, 5, 120, 1, 121}, {26, 9, 43, 4, 44}, {28, 17, 22, 1, 23}, {28, 2, 14, 19, 15}},
+	{{28, 3, 113, 4, 114}, {26, 3, 44, 11, 45}, {26, 17, 21, 4, 22}, {26, 9, 13, 16, 14}},
+	{{28, 3, 107, 5, 108}, {26, 3, 41, 13, 42}, {30, 15, 24, 5, 25}, {28, 15, 15, 10, 16}},
+}
+
+func (b qrBlock) dataWords() int {
+	return b.count1*b.data1 + b.count2*b.data2
+}
+
+// qrSize is the number of modules per side of a version.
+func qrSize(version int) int {
+	return version*4 + 17
+}
+
+// qrAlignment returns the row and column centres of the alignment
+// patterns of a version.
+func qrAlignment(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	count := version/7 + 2
+	step := (version*4 + count*2 + 1) / (count*2 - 2) * 2
+	ret := make([]int, count)
+	ret[0] = 6
+	for i, pos := count-1, qrSize(version)-7; i >= 1; i, pos = i-1, pos-step {
+		ret[i] = pos
+	}
+	return ret
+}
+
+// encodeQR encodes data in byte mode using the smallest version that
+// fits at the given level.
+func encodeQR(data []byte, level int) ([][]bool, error) {
+	if level < 0 {
+		level = QRLevelM
+	}
+	if level > QRLevelH {
+		return nil, errors.New("invalid QR error correction level")
+	}
+
+	version := 0
+	for v := 1; v <= len(qrBlocks); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+len(data)*8 <= qrBlocks[v-1][level].dataWords()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrDataTooLong
+	}
+
+	q := newQRMatrix(version)
+	q.drawFunctionPatterns()
+	q.drawCodewords(qrCodewords(data, version, level))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(level, mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(level, best)
+	return q.modules, nil
+}
+
+// qrCodewords builds the data bit stream and returns the interleaved
+// data and check words.
+func qrCodewords(data []byte, version, level int) []int {
+	block := qrBlocks[version-1][level]
+	capacity := block.dataWords() * 8
+
+	bits := &bitBuffer{}
+	bits.append(4, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	terminator := capacity - bits.len()
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-bits.len()%8)%8)
+	for pad := 0xec; bits.len() < capacity; pad ^= 0xec ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	words := bits.words(8)
+
+	var dataBlocks, ecBlocks [][]int
+	for i := 0; i < block.count1+block.count2; i++ {
+		n := block.data1
+		if i >= block.count1 {
+			n = block.data2
+		}
+		dataBlocks = append(dataBlocks, words[:n])
+		ecBlocks = append(ecBlocks, gfQR.encode(words[:n], block.ec))
+		words = words[n:]
+	}
+
+	var ret []int
+	for i := 0; i < block.data2 || i < block.data1; i++ {
+		for _, b := range dataBlocks {
+			if i < len(b) {
+				ret = append(ret, b[i])
+			}
+		}
+	}
+	for i := 0; i < block.ec; i++ {
+		for _, b := range ecBlocks {
+			ret = append(ret, b[i])
+		}
+	}
+	return ret
+}
+
+type qrMatrix struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newQRMatrix(version int) *qrMatrix {
+	size := qrSize(version)
+	return &qrMatrix{version: version, size: size, modules: newGrid(size, size), function: newGrid(size, size)}
+}
+
+func (q *qrMatrix) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *qrMatrix) drawFunctionPatterns() {
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	q.drawFinder(3, 3)
+	q.drawFinder(q.size-4, 3)
+	q.drawFinder(3, q.size-4)
+
+	align := qrAlignment(q.version)
+	for i, x := range align {
+		for j, y := range align {
+			if (i == 0 && j == 0) || (i == 0 && j == len(align)-1) || (i == len(align)-1 && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas, written once the mask is known.
+	q.drawFormat(0, 0)
+	if q.version >= 7 {
+		bits := qrVersionInfo(q.version)
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 != 0
+			a, b := q.size-11+i%3, i/3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+}
+
+// drawFinder draws a finder pattern and its separator around centre x, y.
+func (q *qrMatrix) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= q.size || yy < 0 || yy >= q.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			q.set(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+// qrFormatInfo returns the masked 15 bit BCH code of the level and mask.
+func qrFormatInfo(level, mask int) int {
+	data := qrFormatBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// qrVersionInfo returns the 18 bit BCH code of a version, 7 and up.
+func qrVersionInfo(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	return version<<12 | rem
+}
+
+func (q *qrMatrix) drawFormat(level, mask int) {
+	bits := qrFormatInfo(level, mask)
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+// drawCodewords places the codewords in the two module wide zigzag
+// columns, from the bottom right corner.
+func (q *qrMatrix) drawCodewords(words []int) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if q.function[y][x] || i >= len(words)*8 {
+					continue
+				}
+				q.modules[y][x] = (words[i>>3]>>(7-i&7))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+func qrMask(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyMask flips the data modules selected by mask; applying it twice
+// restores the matrix.
+func (q *qrMatrix) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !q.function[y][x] && qrMask(mask, x, y) {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the matrix with the four rules of ISO/IEC 18004
+// section 7.8.3; the mask with the lowest score is used.
+func (q *qrMatrix) penalty() int {
+	score := 0
+	dark := 0
+	finder := []bool{true, false, true, true, true, false, true}
+	for i := 0; i < q.size; i++ {
+		row := make([]bool, q.size)
+		col := make([]bool, q.size)
+		for j := 0; j < q.size; j++ {
+			row[j] = q.modules[i][j]
+			col[j] = q.modules[j][i]
+			if row[j] {
+				dark++
+			}
+		}
+		for _, line := range [][]bool{row, col} {
+			run := 1
+			for j := 1; j <= len(line); j++ {
+				if j < len(line) && line[j] == line[j-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += run - 2
+				}
+				run = 1
+			}
+			for j := 0; j+7 <= len(line); j++ {
+				if !matchAt(line, j, finder) {
+					continue
+				}
+				if lightRun(line, j-4, j) || lightRun(line, j+7, j+11) {
+					score += 40
+				}
+			}
+		}
+	}
+	for y := 0; y < q.size-1; y++ {
+		for x := 0; x < q.size-1; x++ {
+			c := q.modules[y][x]
+			if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+				score += 3
+			}
+		}
+	}
+	total := q.size * q.size
+	score += abs(dark*20-total*10) / total * 10
+	return score
+}
+
+func matchAt(line []bool, at int, pattern []bool) bool {
+	for i, p := range pattern {
+		if line[at+i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+// lightRun reports whether line[from:to] is light, counting modules
+// outside the symbol as light.
+func lightRun(line []bool, from, to int) bool {
+	for i := from; i < to; i++ {
+		if i >= 0 && i < len(line) && line[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// bitBuffer collects bits most significant first.
+type bitBuffer struct {
+	bits []bool
+}
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		b.bits = append(b.bits, (value>>i)&1 != 0)
+	}
+}
+
+func (b *bitBuffer) len() int {
+	return len(b.bits)
+}
+
+// words groups the bits in words of n bits, padding the last one with
+// zeros.
+func (b *bitBuffer) words(n int) []int {
+	ret := make([]int, 0, (len(b.bits)+n-1)/n)
+	for i := 0; i < len(b.bits); i += n {
+		w := 0
+		for j := 0; j < n; j++ {
+			w <<= 1
+			if i+j < len(b.bits) && b.bits[i+j] {
+				w |= 1
+			}
+		}
+		ret = append(ret, w)
+	}
+	return ret
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
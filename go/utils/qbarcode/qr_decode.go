@@ -0,0 +1,598 @@
+package qbarcode
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// finder is a candidate finder pattern, or bull's eye for Aztec.
+type finder struct {
+	center point
+	module float64
+	count  int
+}
+
+// decodeQR locates the three finder patterns, samples the symbol
+// through the perspective they and the bottom right alignment pattern
+// describe, then reads it.
+func decodeQR(ctx context.Context, m *bitMatrix) (Result, error) {
+	finders := findPatterns(ctx, m, []float64{1, 1, 3, 1, 1})
+	if len(finders) < 3 {
+		return Result{}, ErrNotFound
+	}
+	sort.SliceStable(finders, func(i, j int) bool { return finders[i].count > finders[j].count })
+	if len(finders) > 8 {
+		finders = finders[:8]
+	}
+
+	type triple struct {
+		tl, tr, bl finder
+		score      float64
+	}
+	var triples []triple
+	for i := 0; i < len(finders); i++ {
+		for j := i + 1; j < len(finders); j++ {
+			for k := j + 1; k < len(finders); k++ {
+				t, ok := orderFinders(finders[i], finders[j], finders[k])
+				if !ok {
+					continue
+				}
+				a, b := t[0].center.dist(t[1].center), t[0].center.dist(t[2].center)
+				hyp := t[1].center.dist(t[2].center)
+				score := math.Abs(a-b)/(a+b) + math.Abs(hyp-math.Hypot(a, b))/hyp
+				triples = append(triples, triple{t[0], t[1], t[2], score})
+			}
+		}
+	}
+	sort.Slice(triples, func(i, j int) bool { return triples[i].score < triples[j].score })
+
+	err := ErrNotFound
+	for _, t := range triples {
+		if ctx.Err() != nil {
+			return Result{}, ErrNotFound
+		}
+		if t.score > 0.5 {
+			break
+		}
+		res, e := decodeQRAt(m, t.tl, t.tr, t.bl)
+		if e == nil {
+			return res, nil
+		}
+		err = e
+	}
+	return Result{}, err
+}
+
+// orderFinders returns the patterns as top left, top right and bottom
+// left, the top left one being at the right angle.
+func orderFinders(a, b, c finder) ([3]finder, bool) {
+	if a.module > b.module*2 || b.module > a.module*2 || a.module > c.module*2 || c.module > a.module*2 {
+		return [3]finder{}, false
+	}
+	ab, ac, bc := a.center.dist(b.center), a.center.dist(c.center), b.center.dist(c.center)
+	tl, tr, bl := a, b, c
+	switch {
+	case ab >= ac && ab >= bc:
+		tl, tr, bl = c, a, b
+	case ac >= ab && ac >= bc:
+		tl, tr, bl = b, a, c
+	}
+	if tr.center.sub(tl.center).cross(bl.center.sub(tl.center)) < 0 {
+		tr, bl = bl, tr
+	}
+	return [3]finder{tl, tr, bl}, true
+}
+
+func decodeQRAt(m *bitMatrix, tl, tr, bl finder) (Result, error) {
+	// Runs are measured along the image axes, longer than the module
+	// when the symbol is rotated.
+	d := tr.center.sub(tl.center)
+	rotation := math.Max(math.Abs(d.x), math.Abs(d.y)) / math.Hypot(d.x, d.y)
+	across := tl.center.dist(tr.center) / ((tl.module + tr.module) / 2)
+	down := tl.center.dist(bl.center) / ((tl.module + bl.module) / 2)
+	modules := (across+down)/2/rotation + 7
+	estimate := int(math.Round((modules - 17) / 4))
+
+	err := ErrNotFound
+	for _, version := range []int{estimate, estimate + 1, estimate - 1, estimate + 2, estimate - 2} {
+		if version < 1 || version > len(qrBlocks) {
+			continue
+		}
+		size := qrSize(version)
+		src := [4]point{{3.5, 3.5}, {float64(size) - 3.5, 3.5}, {float64(size) - 3.5, float64(size) - 3.5}, {3.5, float64(size) - 3.5}}
+		dst := [4]point{tl.center, tr.center, tr.center.add(bl.center).sub(tl.center), bl.center}
+		if version > 1 {
+			if p, ok := findAlignment(m, tl, tr, bl, size); ok {
+				src[2] = point{float64(size) - 6.5, float64(size) - 6.5}
+				dst[2] = p
+			}
+		}
+		t := newPerspective(src, dst)
+		res, e := readQR(sampleGrid(m, t, size))
+		if e == nil {
+			return res, nil
+		}
+		err = e
+	}
+	return Result{}, err
+}
+
+// findAlignment looks for the bottom right alignment pattern by
+// matching its 5x5 modules around the position the finder patterns
+// predict, widening the search when perspective moves it further.
+func findAlignment(m *bitMatrix, tl, tr, bl finder, size int) (point, bool) {
+	ux := tr.center.sub(tl.center).scale(1 / float64(size-7))
+	uy := bl.center.sub(tl.center).scale(1 / float64(size-7))
+	estimate := tl.center.add(ux.scale(float64(size) - 10)).add(uy.scale(float64(size) - 10))
+	module := math.Max(math.Hypot(ux.x, ux.y), math.Hypot(uy.x, uy.y))
+
+	// Modules shrink or grow towards the corner as they do towards the
+	// other two finder patterns.
+	k := tr.module * bl.module / (tl.module * tl.module)
+	ux, uy = ux.scale(k), uy.scale(k)
+
+	for _, allowance := range []float64{4, 8, 16} {
+		radius := int(math.Ceil(allowance * module))
+		best, sum, n := 0, point{}, 0
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				c := point{estimate.x + float64(dx), estimate.y + float64(dy)}
+				score := 0
+				for j := -2; j <= 2; j++ {
+					for i := -2; i <= 2; i++ {
+						if m.at(c.add(ux.scale(float64(i))).add(uy.scale(float64(j)))) == (max(abs(i), abs(j)) != 1) {
+							score++
+						}
+					}
+				}
+				switch {
+				case score > best:
+					best, sum, n = score, c, 1
+				case score == best:
+					sum, n = sum.add(c), n+1
+				}
+			}
+		}
+		if best >= 23 {
+			return sum.scale(1 / float64(n)), true
+		}
+	}
+	return point{}, false
+}
+
+// findPatterns scans rows for runs in the given proportions, starting
+// and ending with dark, and keeps those confirmed across the column and
+// row through their centre.
+func findPatterns(ctx context.Context, m *bitMatrix, ratios []float64) []finder {
+	var found []finder
+	// active holds the indices of the patterns close enough to the row
+	// scanned to be seen again, so noisy images with thousands of
+	// candidates do not compare each with all the others.
+	var active []int
+	for y := 0; y < m.height; y++ {
+		if y%64 == 0 && ctx.Err() != nil {
+			return nil
+		}
+		n := 0
+		for _, i := range active {
+			if found[i].center.y >= float64(y)-found[i].module*8 {
+				active[n] = i
+				n++
+			}
+		}
+		active = active[:n]
+
+		runs := m.runs(y)
+		x := runs[0]
+		for i := 1; i+len(ratios) <= len(runs); i += 2 {
+			if _, ok := matchRatios(runs[i:i+len(ratios)], ratios); ok {
+				mid := len(ratios) / 2
+				start := x
+				for _, r := range runs[i : i+mid] {
+					start += r
+				}
+				cx := float64(start) + float64(runs[i+mid])/2
+				if f, ok := crossCheck(m, point{cx, float64(y) + 0.5}, ratios); ok && !mergeFinder(found, active, f) {
+					found = append(found, f)
+					active = append(active, len(found)-1)
+				}
+			}
+			x += runs[i]
+			if i+1 < len(runs) {
+				x += runs[i+1]
+			}
+		}
+	}
+	return found
+}
+
+// matchRatios reports whether runs are in the given proportions, each
+// within half a module, and returns the module size.
+func matchRatios(runs []int, ratios []float64) (float64, bool) {
+	total, units := 0, 0.0
+	for i, r := range runs {
+		total += r
+		units += ratios[i]
+	}
+	if float64(total) < units {
+		return 0, false
+	}
+	module := float64(total) / units
+	for i, r := range runs {
+		if math.Abs(float64(r)-ratios[i]*module) > math.Max(ratios[i]*module/2, 1) {
+			return 0, false
+		}
+	}
+	return module, true
+}
+
+// crossCheck confirms a pattern vertically, horizontally and vertically
+// again through its centre, returning the refined centre.
+func crossCheck(m *bitMatrix, p point, ratios []float64) (finder, bool) {
+	cy, vm, ok := lineCheck(m, p, point{0, 1}, ratios)
+	if !ok {
+		return finder{}, false
+	}
+	cx, hm, ok := lineCheck(m, point{p.x, cy}, point{1, 0}, ratios)
+	if !ok {
+		return finder{}, false
+	}
+	// Again through the refined column, which matters when the pattern
+	// is rotated.
+	if cy, vm, ok = lineCheck(m, point{cx, cy}, point{0, 1}, ratios); !ok {
+		return finder{}, false
+	}
+	if vm > hm*1.5 || hm > vm*1.5 {
+		return finder{}, false
+	}
+	return finder{center: point{cx, cy}, module: (vm + hm) / 2, count: 1}, true
+}
+
+// lineCheck reads the runs along direction d (one of the axes) around
+// the dark pixel at p and returns the centre coordinate of the middle
+// run along that axis and the module size.
+func lineCheck(m *bitMatrix, p, d point, ratios []float64) (float64, float64, bool) {
+	x, y := int(math.Floor(p.x)), int(math.Floor(p.y))
+	dx, dy := int(d.x), int(d.y)
+	if !m.get(x, y) {
+		return 0, 0, false
+	}
+	mid := len(ratios) / 2
+	limit := m.width + m.height
+	runs := make([]int, len(ratios))
+
+	// Walk back from the centre through the first half of the runs.
+	state, k := mid, 0
+	for ; k < limit; k++ {
+		if m.get(x-k*dx, y-k*dy) != (state%2 == 0) {
+			if state == 0 {
+				break
+			}
+			state--
+		}
+		if x-k*dx < 0 || y-k*dy < 0 {
+			return 0, 0, false
+		}
+		runs[state]++
+	}
+	start := x*dx + y*dy - runs[mid] + 1
+
+	state = mid
+	for k = 1; k < limit; k++ {
+		if m.get(x+k*dx, y+k*dy) != (state%2 == 0) {
+			if state == len(ratios)-1 {
+				break
+			}
+			state++
+		}
+		if x+k*dx >= m.width || y+k*dy >= m.height {
+			return 0, 0, false
+		}
+		runs[state]++
+	}
+	if state != len(ratios)-1 {
+		return 0, 0, false
+	}
+	module, ok := matchRatios(runs, ratios)
+	if !ok {
+		return 0, 0, false
+	}
+	return float64(start) + float64(runs[mid])/2, module, true
+}
+
+// mergeFinder averages f with the pattern of found, among the active
+// ones, seen at the same place, and reports whether there was one.
+func mergeFinder(found []finder, active []int, f finder) bool {
+	for _, i := range active {
+		g := found[i]
+		if math.Abs(g.center.y-f.center.y) > g.module*2 {
+			continue
+		}
+		if g.center.near(f.center, g.module*2) && f.module < g.module*1.5 && g.module < f.module*1.5 {
+			n := float64(g.count)
+			found[i] = finder{
+				center: g.center.scale(n).add(f.center).scale(1 / (n + 1)),
+				module: (g.module*n + f.module) / (n + 1),
+				count:  g.count + 1,
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// readQR decodes a sampled symbol.
+func readQR(grid [][]bool) (Result, error) {
+	size := len(grid)
+	version := (size - 17) / 4
+	if version >= 7 {
+		read1, read2 := 0, 0
+		for i := 0; i < 18; i++ {
+			a, b := size-11+i%3, i/3
+			if grid[b][a] {
+				read1 |= 1 << i
+			}
+			if grid[a][b] {
+				read2 |= 1 << i
+			}
+		}
+		v, best := 0, 4
+		for candidate := 7; candidate <= 40; candidate++ {
+			info := qrVersionInfo(candidate)
+			for _, read := range []int{read1, read2} {
+				if d := bits.OnesCount(uint(info ^ read)); d < best {
+					v, best = candidate, d
+				}
+			}
+		}
+		if v != version {
+			return Result{}, ErrCorrupted
+		}
+	}
+
+	read1, read2 := 0, 0
+	for i := 0; i < 15; i++ {
+		var a, b bool
+		switch {
+		case i <= 5:
+			a = grid[i][8]
+		case i <= 7:
+			a = grid[i+1][8]
+		case i == 8:
+			a = grid[8][7]
+		default:
+			a = grid[8][14-i]
+		}
+		if i < 8 {
+			b = grid[8][size-1-i]
+		} else {
+			b = grid[size-15+i][8]
+		}
+		if a {
+			read1 |= 1 << i
+		}
+		if b {
+			read2 |= 1 << i
+		}
+	}
+	level, mask, best := 0, 0, 4
+	for l := QRLevelL; l <= QRLevelH; l++ {
+		for k := 0; k < 8; k++ {
+			info := qrFormatInfo(l, k)
+			for _, read := range []int{read1, read2} {
+				if d := bits.OnesCount(uint(info ^ read)); d < best {
+					level, mask, best = l, k, d
+				}
+			}
+		}
+	}
+	if best > 3 {
+		return Result{}, ErrCorrupted
+	}
+
+	q := newQRMatrix(version)
+	q.drawFunctionPatterns()
+	block := qrBlocks[version-1][level]
+	blocks := block.count1 + block.count2
+	words := make([]int, block.dataWords()+blocks*block.ec)
+	i := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if q.function[y][x] || i >= len(words)*8 {
+					continue
+				}
+				if grid[y][x] != qrMask(mask, x, y) {
+					words[i>>3] |= 1 << (7 - i&7)
+				}
+				i++
+			}
+		}
+	}
+
+	codewords := make([][]int, blocks)
+	for b := range codewords {
+		n := block.data1
+		if b >= block.count1 {
+			n = block.data2
+		}
+		codewords[b] = make([]int, 0, n+block.ec)
+	}
+	k := 0
+	for i := 0; i < max(block.data1, block.data2); i++ {
+		for b := range codewords {
+			if b < block.count1 && i >= block.data1 {
+				continue
+			}
+			codewords[b] = append(codewords[b], words[k])
+			k++
+		}
+	}
+	for i := 0; i < block.ec; i++ {
+		for b := range codewords {
+			codewords[b] = append(codewords[b], words[k])
+			k++
+		}
+	}
+
+	var data []int
+	corrected := 0
+	for _, cw := range codewords {
+		n, err := gfQR.decode(cw, block.ec)
+		if err != nil {
+			return Result{}, err
+		}
+		corrected += n
+		data = append(data, cw[:len(cw)-block.ec]...)
+	}
+
+	content, err := qrContent(data, version)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Content: content, Corrected: corrected}, nil
+}
+
+// qrContent parses the segments of the data words.
+func qrContent(data []int, version int) (string, error) {
+	r := &bitReader{}
+	for _, w := range data {
+		r.append(w, 8)
+	}
+	sizeClass := 0
+	switch {
+	case version >= 27:
+		sizeClass = 2
+	case version >= 10:
+		sizeClass = 1
+	}
+
+	var sb strings.Builder
+	for r.remaining() >= 4 {
+		switch mode := r.read(4); mode {
+		case 0:
+			return sb.String(), nil
+		case 1:
+			n := r.read([]int{10, 12, 14}[sizeClass])
+			for ; n >= 3; n -= 3 {
+				writeDigits(&sb, r.read(10), 3)
+			}
+			switch n {
+			case 2:
+				writeDigits(&sb, r.read(7), 2)
+			case 1:
+				writeDigits(&sb, r.read(4), 1)
+			}
+		case 2:
+			n := r.read([]int{9, 11, 13}[sizeClass])
+			for ; n >= 2; n -= 2 {
+				v := r.read(11)
+				if v >= 45*45 {
+					return "", ErrCorrupted
+				}
+				sb.WriteByte(qrAlphanumeric[v/45])
+				sb.WriteByte(qrAlphanumeric[v%45])
+			}
+			if n == 1 {
+				v := r.read(6)
+				if v >= 45 {
+					return "", ErrCorrupted
+				}
+				sb.WriteByte(qrAlphanumeric[v])
+			}
+		case 4:
+			n := r.read([]int{8, 16, 16}[sizeClass])
+			for ; n > 0; n-- {
+				sb.WriteByte(byte(r.read(8)))
+			}
+		case 8:
+			// Kanji, written back as Shift JIS.
+			n := r.read([]int{8, 10, 12}[sizeClass])
+			for ; n > 0; n-- {
+				v := r.read(13)
+				c := v/0xc0<<8 | v%0xc0
+				if c < 0x1f00 {
+					c += 0x8140
+				} else {
+					c += 0xc140
+				}
+				sb.WriteByte(byte(c >> 8))
+				sb.WriteByte(byte(c))
+			}
+		case 7:
+			// ECI designator, content is kept as raw bytes.
+			switch v := r.read(8); {
+			case v&0x80 == 0:
+			case v&0xc0 == 0x80:
+				r.read(8)
+			default:
+				r.read(16)
+			}
+		case 3:
+			r.read(16)
+		case 5:
+		case 9:
+			r.read(8)
+		default:
+			return "", ErrCorrupted
+		}
+		if r.overflow {
+			return "", ErrCorrupted
+		}
+	}
+	return sb.String(), nil
+}
+
+func writeDigits(sb *strings.Builder, v, n int) {
+	digits := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		digits[i] = byte('0' + v%10)
+		v /= 10
+	}
+	sb.Write(digits)
+}
+
+// bitReader reads big endian values from a bit stream.
+type bitReader struct {
+	bits     []bool
+	pos      int
+	overflow bool
+}
+
+func (r *bitReader) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		r.bits = append(r.bits, (value>>i)&1 != 0)
+	}
+}
+
+func (r *bitReader) remaining() int {
+	return len(r.bits) - r.pos
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		v <<= 1
+		if r.pos >= len(r.bits) {
+			r.overflow = true
+			continue
+		}
+		if r.bits[r.pos] {
+			v |= 1
+		}
+		r.pos++
+	}
+	return v
+}
@@ -0,0 +1,327 @@
+package qbarcode
+
+// galoisField is GF(2^m) built from a primitive polynomial. base is the
+// exponent of the first root of the generator polynomial: QR codes use
+// 0, Aztec codes 1.
+type galoisField struct {
+	size int
+	base int
+	exp  []int
+	log  []int
+}
+
+func newGaloisField(poly, size, base int) *galoisField {
+	f := &galoisField{size: size, base: base, exp: make([]int, size*2), log: make([]int, size)}
+	x := 1
+	for i := 0; i < size*2; i++ {
+		f.exp[i] = x
+		if i < size-1 {
+			f.log[x] = i
+		}
+		x <<= 1
+		if x >= size {
+			x ^= poly
+		}
+	}
+	return f
+}
+
+var (
+	gfQR       = newGaloisField(0x11d, 256, 0)
+	gfAztec4   = newGaloisField(0x13, 16, 1)
+	gfAztec6   = newGaloisField(0x43, 64, 1)
+	gfAztec8   = newGaloisField(0x12d, 256, 1)
+	gfAztec10  = newGaloisField(0x409, 1024, 1)
+	gfAztec12  = newGaloisField(0x1069, 4096, 1)
+	aztecField = map[int]*galoisField{4: gfAztec4, 6: gfAztec6, 8: gfAztec8, 10: gfAztec10, 12: gfAztec12}
+)
+
+func (f *galoisField) mul(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return f.exp[f.log[a]+f.log[b]]
+}
+
+func (f *galoisField) div(a, b int) int {
+	if a == 0 {
+		return 0
+	}
+	return f.exp[(f.log[a]+f.size-1-f.log[b])%(f.size-1)]
+}
+
+// pow returns a^e for a non-zero a, e may be negative.
+func (f *galoisField) pow(a, e int) int {
+	n := f.size - 1
+	return f.exp[((f.log[a]*e)%n+n)%n]
+}
+
+// evaluate evaluates poly, highest degree first, at x.
+func (f *galoisField) evaluate(poly []int, x int) int {
+	sum := 0
+	for _, c := range poly {
+		sum = f.mul(sum, x) ^ c
+	}
+	return sum
+}
+
+// generator returns the coefficients of prod(x - a^(base+i)) for
+// i < n, highest degree first.
+func (f *galoisField) generator(n int) []int {
+	g := []int{1}
+	for i := 0; i < n; i++ {
+		next := make([]int, len(g)+1)
+		root := f.exp[(f.base+i)%(f.size-1)]
+		for j, c := range g {
+			next[j] ^= c
+			next[j+1] ^= f.mul(c, root)
+		}
+		g = next
+	}
+	return g
+}
+
+// encode returns the n check words of data.
+func (f *galoisField) encode(data []int, n int) []int {
+	g := f.generator(n)
+	rem := make([]int, n)
+	for _, d := range data {
+		factor := d ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for i := 0; i < n; i++ {
+			rem[i] ^= f.mul(g[i+1], factor)
+		}
+	}
+	return rem
+}
+
+// primeField is GF(929) used by PDF417, with generator 3.
+type primeField struct {
+	mod int
+	exp []int
+	log []int
+}
+
+func newPrimeField(mod, generator int) *primeField {
+	f := &primeField{mod: mod, exp: make([]int, mod), log: make([]int, mod)}
+	x := 1
+	for i := 0; i < mod; i++ {
+		f.exp[i] = x
+		x = x * generator % mod
+	}
+	for i := 0; i < mod-1; i++ {
+		f.log[f.exp[i]] = i
+	}
+	return f
+}
+
+var gfPDF417 = newPrimeField(929, 3)
+
+func (f *primeField) add(a, b int) int { return (a + b) % f.mod }
+func (f *primeField) sub(a, b int) int { return (f.mod + a - b) % f.mod }
+
+func (f *primeField) mul(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return f.exp[(f.log[a]+f.log[b])%(f.mod-1)]
+}
+
+// generator returns the coefficients of prod(x - 3^i) for i = 1..n,
+// highest degree first.
+func (f *primeField) generator(n int) []int {
+	g := []int{1}
+	for i := 1; i <= n; i++ {
+		next := make([]int, len(g)+1)
+		root := f.exp[i%(f.mod-1)]
+		for j, c := range g {
+			next[j] = f.add(next[j], c)
+			next[j+1] = f.sub(next[j+1], f.mul(c, root))
+		}
+		g = next
+	}
+	return g
+}
+
+// encode returns the n check words of data such that the whole
+// codeword is divisible by the generator polynomial.
+func (f *primeField) encode(data []int, n int) []int {
+	g := f.generator(n)
+	rem := make([]int, n)
+	for _, d := range data {
+		factor := f.add(d, rem[0])
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for i := 0; i < n; i++ {
+			rem[i] = f.sub(rem[i], f.mul(g[i+1], factor))
+		}
+	}
+	for i := range rem {
+		rem[i] = f.sub(0, rem[i])
+	}
+	return rem
+}
+
+// decode corrects codeword, data followed by n check words, in place
+// and returns the number of corrected words.
+func (f *galoisField) decode(codeword []int, n int) (int, error) {
+	syndromes := make([]int, n)
+	clean := true
+	for i := range syndromes {
+		syndromes[i] = f.evaluate(codeword, f.exp[(f.base+i)%(f.size-1)])
+		clean = clean && syndromes[i] == 0
+	}
+	if clean {
+		return 0, nil
+	}
+
+	sigma := berlekampMassey(syndromes, f.mul, f.div, func(a, b int) int { return a ^ b })
+	errs := len(sigma) - 1
+	if errs == 0 || errs > n/2 {
+		return 0, ErrCorrupted
+	}
+	omega := make([]int, n)
+	for i := range omega {
+		for j := 0; j <= i && j < len(sigma); j++ {
+			omega[i] ^= f.mul(sigma[j], syndromes[i-j])
+		}
+	}
+
+	// Chien search, p being the power of x of the erroneous word.
+	found := 0
+	for p := 0; p < len(codeword); p++ {
+		xinv := f.pow(f.exp[p%(f.size-1)], -1)
+		if f.evaluate(reversed(sigma), xinv) != 0 {
+			continue
+		}
+		// Forney, the odd terms of sigma giving its formal derivative.
+		den := 0
+		for j := 1; j < len(sigma); j += 2 {
+			den ^= f.mul(sigma[j], f.pow(xinv, j-1))
+		}
+		if den == 0 {
+			return 0, ErrCorrupted
+		}
+		e := f.div(f.evaluate(reversed(omega), xinv), den)
+		e = f.mul(e, f.pow(f.exp[p%(f.size-1)], 1-f.base))
+		codeword[len(codeword)-1-p] ^= e
+		found++
+	}
+	if found != errs {
+		return 0, ErrCorrupted
+	}
+	return errs, nil
+}
+
+func (f *primeField) inv(a int) int {
+	return f.exp[(f.mod-1-f.log[a])%(f.mod-1)]
+}
+
+func (f *primeField) div(a, b int) int {
+	return f.mul(a, f.inv(b))
+}
+
+func (f *primeField) evaluate(poly []int, x int) int {
+	sum := 0
+	for _, c := range poly {
+		sum = f.add(f.mul(sum, x), c)
+	}
+	return sum
+}
+
+// decode corrects codeword, data followed by n check words, in place
+// and returns the number of corrected words.
+func (f *primeField) decode(codeword []int, n int) (int, error) {
+	syndromes := make([]int, n)
+	clean := true
+	for i := range syndromes {
+		syndromes[i] = f.evaluate(codeword, f.exp[i+1])
+		clean = clean && syndromes[i] == 0
+	}
+	if clean {
+		return 0, nil
+	}
+
+	sigma := berlekampMassey(syndromes, f.mul, f.div, f.sub)
+	errs := len(sigma) - 1
+	if errs == 0 || errs > n/2 {
+		return 0, ErrCorrupted
+	}
+	omega := make([]int, n)
+	for i := range omega {
+		for j := 0; j <= i && j < len(sigma); j++ {
+			omega[i] = f.add(omega[i], f.mul(sigma[j], syndromes[i-j]))
+		}
+	}
+
+	found := 0
+	for p := 0; p < len(codeword); p++ {
+		xinv := f.inv(f.exp[p%(f.mod-1)])
+		if f.evaluate(reversed(sigma), xinv) != 0 {
+			continue
+		}
+		den, x := 0, 1
+		for j := 1; j < len(sigma); j++ {
+			den = f.add(den, f.mul(f.mul(sigma[j], j%f.mod), x))
+			x = f.mul(x, xinv)
+		}
+		if den == 0 {
+			return 0, ErrCorrupted
+		}
+		e := f.sub(0, f.div(f.evaluate(reversed(omega), xinv), den))
+		i := len(codeword) - 1 - p
+		codeword[i] = f.sub(codeword[i], e)
+		found++
+	}
+	if found != errs {
+		return 0, ErrCorrupted
+	}
+	return errs, nil
+}
+
+// berlekampMassey returns the error locator polynomial of the
+// syndromes, lowest degree first, for either field.
+func berlekampMassey(syndromes []int, mul, div, sub func(a, b int) int) []int {
+	sigma, prev := []int{1}, []int{1}
+	l, m, b := 0, 1, 1
+	for i := range syndromes {
+		d := syndromes[i]
+		for j := 1; j <= l && j < len(sigma); j++ {
+			d = sub(d, sub(0, mul(sigma[j], syndromes[i-j])))
+		}
+		if d == 0 {
+			m++
+			continue
+		}
+		coef := div(d, b)
+		next := append([]int(nil), sigma...)
+		for len(next) < len(prev)+m {
+			next = append(next, 0)
+		}
+		for j, p := range prev {
+			next[j+m] = sub(next[j+m], mul(coef, p))
+		}
+		if 2*l <= i {
+			prev, l, b, m = sigma, i+1-l, d, 1
+		} else {
+			m++
+		}
+		sigma = next
+	}
+	for len(sigma) > 1 && sigma[len(sigma)-1] == 0 {
+		sigma = sigma[:len(sigma)-1]
+	}
+	if len(sigma)-1 != l {
+		return []int{1}
+	}
+	return sigma
+}
+
+func reversed(poly []int) []int {
+	out := make([]int, len(poly))
+	for i, c := range poly {
+		out[len(poly)-1-i] = c
+	}
+	return out
+}
@@ -0,0 +1,5 @@
+module github.com/mhaqqiw/sdk/go/utils/qbarcode/testdata/boombuler
+
+go 1.24
+
+require github.com/boombuler/barcode v1.1.0
@@ -0,0 +1,116 @@
+// Command boombuler renders the independent_*.png test images with
+// github.com/boombuler/barcode, an encoder unrelated to this package:
+// its Aztec encoder is ported from ZXing and its PDF417 encoder from
+// ruudk/golang-pdf417. That PDF417 encoder writes the left row indicator
+// of cluster 0 as (rows-3)/3 instead of (rows-1)/3, so for the 26 row
+// symbol of the two leg pass the left and right indicators disagree on
+// the row count. Run it from this directory with
+//
+//	go run .
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/aztec"
+	"github.com/boombuler/barcode/pdf417"
+	"github.com/boombuler/barcode/qr"
+)
+
+// The payloads are the two leg example of the IATA Resolution 792
+// implementation guide and a single leg pass without conditional items.
+const (
+	twoLegs = "M2DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J003A0027 167>5321WW1325BAC 0014123456002" +
+		"001412346700100141234789012A0141234567890 1AC AC 1234567890123    4PCYLX58Z" +
+		"DEF456 FRAGVALH 3664 327C012C0002 12E2A0141234567890 1AC AC 1234567890123    3PCNWQ" +
+		"^164GIWVC5EH7JNT684FVNJ91W2QA4DVN5J8K4F0L0GEQ3DF5TGBN8709HKT5D3DW3GBHFCVHMY7J5T6HFR41W2QA4DVN5J8K4F0L0GE"
+	oneLeg = "M1DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J001A0025 100"
+)
+
+func main() {
+	qrCode, err := qr.Encode(twoLegs, qr.M, qr.Auto)
+	check(err)
+	aztecCode, err := aztec.Encode([]byte(twoLegs), 33, 0)
+	check(err)
+	pdfCode, err := pdf417.Encode(oneLeg, 4)
+	check(err)
+	pdfLongCode, err := pdf417.Encode(twoLegs, 2)
+	check(err)
+
+	write("independent_qr.png", render(qrCode, 4, 4, 4))
+	write("independent_qr_rotated.png", rotate(render(qrCode, 4, 4, 4), 17))
+	write("independent_aztec.png", render(aztecCode, 5, 5, 4))
+	write("independent_aztec_rotated.png", rotate(render(aztecCode, 5, 5, 4), -31))
+	write("independent_pdf417.png", render(pdfCode, 2, 2, 10))
+	write("independent_pdf417_rotated.png", rotate(render(pdfCode, 2, 2, 10), 8))
+	write("independent_pdf417_two_legs.png", render(pdfLongCode, 2, 2, 10))
+}
+
+// render draws every module of bc as a sx by sy block inside a quiet
+// zone of quiet modules.
+func render(bc barcode.Barcode, sx, sy, quiet int) *image.Gray {
+	b := bc.Bounds()
+	img := image.NewGray(image.Rect(0, 0, (b.Dx()+2*quiet)*sx, (b.Dy()+2*quiet)*sy))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.GrayModel.Convert(bc.At(x, y)).(color.Gray).Y > 127 {
+				continue
+			}
+			r := image.Rect((x-b.Min.X+quiet)*sx, (y-b.Min.Y+quiet)*sy, (x-b.Min.X+quiet+1)*sx, (y-b.Min.Y+quiet+1)*sy)
+			draw.Draw(img, r, image.Black, image.Point{}, draw.Src)
+		}
+	}
+	return img
+}
+
+// rotate turns src by deg degrees around its centre onto a white canvas
+// large enough to hold it, with bilinear sampling.
+func rotate(src *image.Gray, deg float64) *image.Gray {
+	rad := deg * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	w, h := float64(src.Bounds().Dx()), float64(src.Bounds().Dy())
+	nw := int(math.Ceil(math.Abs(w*cos) + math.Abs(h*sin)))
+	nh := int(math.Ceil(math.Abs(w*sin) + math.Abs(h*cos)))
+	dst := image.NewGray(image.Rect(0, 0, nw, nh))
+	at := func(x, y int) float64 {
+		if x < 0 || y < 0 || x >= src.Bounds().Dx() || y >= src.Bounds().Dy() {
+			return 255
+		}
+		return float64(src.GrayAt(x, y).Y)
+	}
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			dx, dy := float64(x)-float64(nw)/2, float64(y)-float64(nh)/2
+			sx := dx*cos + dy*sin + w/2
+			sy := -dx*sin + dy*cos + h/2
+			x0, y0 := int(math.Floor(sx)), int(math.Floor(sy))
+			fx, fy := sx-float64(x0), sy-float64(y0)
+			v := at(x0, y0)*(1-fx)*(1-fy) + at(x0+1, y0)*fx*(1-fy) +
+				at(x0, y0+1)*(1-fx)*fy + at(x0+1, y0+1)*fx*fy
+			dst.SetGray(x, y, color.Gray{Y: uint8(math.Round(v))})
+		}
+	}
+	return dst
+}
+
+func write(name string, img image.Image) {
+	f, err := os.Create(filepath.Join("..", name))
+	check(err)
+	defer f.Close()
+	check(png.Encode(f, img))
+}
+
+func check(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -8,8 +8,13 @@ import (
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/mhaqqiw/sdk/go/utils/qiata"
 )
 
+// errTrailingData is returned when data follows the security section.
+var errTrailingData = errors.New("invalid BCBP (Code: 12)")
+
 type BCBP struct {
 	FormatCode   string `json:"format_code"`
 	TotalLeg     string `json:"total_leg"`
@@ -27,9 +32,72 @@ type BCBP struct {
 	Seat         string `json:"seat"`
 	Sequence     string `json:"sequence"`
 	Status       string `json:"status"`
+
+	Origin      *qiata.Airport `json:"origin,omitempty"`
+	Destination *qiata.Airport `json:"destination,omitempty"`
+	Carrier     *qiata.Airline `json:"carrier,omitempty"`
+
+	Conditional BCBPConditional `json:"conditional"`
+	Legs        []BCBPLeg       `json:"legs"`
+	Security    BCBPSecurity    `json:"security"`
+
+	// signed is the part of the pass covered by the security data.
+	signed string
 }
 
-func generateData(length int, charset string) string {
+// BCBPConditional holds the unique conditional items, present once in
+// the variable size field of the first leg.
+type BCBPConditional struct {
+	Version              string `json:"version"`
+	PassengerDescription string `json:"passenger_description"`
+	CheckinSource        string `json:"checkin_source"`
+	IssuanceSource       string `json:"issuance_source"`
+	IssueDate            string `json:"issue_date"`
+	DocumentType         string `json:"document_type"`
+	IssuerAirline        string `json:"issuer_airline"`
+	BaggageTag           string `json:"baggage_tag"`
+	BaggageTag2          string `json:"baggage_tag_2"`
+	BaggageTag3          string `json:"baggage_tag_3"`
+}
+
+// BCBPLeg holds the mandatory and repeated conditional items of one
+// flight segment.
+type BCBPLeg struct {
+	PnrCode              string `json:"pnr_code"`
+	From                 string `json:"from"`
+	To                   string `json:"to"`
+	Airline              string `json:"airline"`
+	FlightNumber         string `json:"flight_number"`
+	Date                 string `json:"date"`
+	Year                 int    `json:"year"`
+	DateAmbiguous        bool   `json:"date_ambiguous"`
+	DateOutsideWindow    bool   `json:"date_outside_window"`
+	Class                string `json:"class"`
+	Seat                 string `json:"seat"`
+	Sequence             string `json:"sequence"`
+	Status               string `json:"status"`
+	AirlineNumericCode   string `json:"airline_numeric_code"`
+	DocumentSerialNumber string `json:"document_serial_number"`
+	SelecteeIndicator    string `json:"selectee_indicator"`
+	DocumentVerification string `json:"document_verification"`
+	MarketingCarrier     string `json:"marketing_carrier"`
+	FrequentFlyerAirline string `json:"frequent_flyer_airline"`
+	FrequentFlyerNumber  string `json:"frequent_flyer_number"`
+	IDADIndicator        string `json:"id_ad_indicator"`
+	FreeBaggageAllowance string `json:"free_baggage_allowance"`
+	FastTrack            string `json:"fast_track"`
+	AirlineData          string `json:"airline_data"`
+
+	// Origin, Destination and Carrier are only set when parsing with
+	// WithRegistry.
+	Origin      *qiata.Airport `json:"origin,omitempty"`
+	Destination *qiata.Airport `json:"destination,omitempty"`
+	Carrier     *qiata.Airline `json:"carrier,omitempty"`
+
+	julianDay string
+}
+
+func generateData(rng *rand.Rand, length int, charset string) string {
 	var letters string
 	switch charset {
 	case "[A-Z]":
@@ -40,7 +108,6 @@ func generateData(length int, charset string) string {
 		letters = "FCY"
 	}
 
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
 	result := make([]byte, length)
 	for i := range result {
 		result[i] = letters[rng.Intn(len(letters))]
@@ -56,38 +123,37 @@ func dateToJulian(date string) string {
 	return fmt.Sprintf("%03d", parsedTime.YearDay())
 }
 
-func julianDayToGregorian(julianDay string) (string, error) {
-	day, err := strconv.Atoi(julianDay)
-	if err != nil {
-		return "", errors.New("invalid Julian day")
-	}
-	year := time.Now().Year()
-	return time.Date(year, 1, day, 0, 0, 0, 0, time.UTC).Format("2006-01-02"), nil
-}
-
+// GenerateBCBP generates a single leg boarding pass with a random PNR,
+// flight, class, seat and sequence. Use a Generator with WithSeed for
+// reproducible output.
 func GenerateBCBP(lastName, firstName, dateOfFlight, fromAirport, toAirport string) (string, error) {
 	if lastName == "" || firstName == "" || dateOfFlight == "" {
 		return "", errors.New("missing required parameters")
 	}
-
-	pnrCode := generateData(6, "[A-Z]")
-	airlineCode := generateData(2, "[A-Z]")
-	flightNumber := generateData(4, "[0-9]")
-	julianDate := dateToJulian(dateOfFlight)
-	seatNumber := generateData(3, "[0-9]") + generateData(1, "[A-Z]")
-	checkInSequence := generateData(4, "[0-9]")
-	classPassenger := generateData(1, "[FCY]")
-
-	nameField := fmt.Sprintf("%-20s", strings.ToUpper(lastName)+"/"+strings.ToUpper(firstName))
-	flightInfo := fmt.Sprintf("%-8s", airlineCode+flightNumber)
-	seat := fmt.Sprintf("%-4s", seatNumber)
-
-	bcRawString := fmt.Sprintf("M1%sE%-7s%s%s%-8s%s%s%-4s%-4s1AA", nameField, pnrCode, fromAirport, toAirport, flightInfo, julianDate, classPassenger, seat, checkInSequence)
-	return bcRawString, nil
+	return defaultGenerator.GenerateLegs(lastName, firstName, BCBPLeg{
+		From: fromAirport,
+		To:   toAirport,
+		Date: dateOfFlight,
+	})
 }
 
-func ParseBCBP(data string) (BCBP, error) {
+// ParseBCBP parses an IATA Resolution 792 boarding pass: the mandatory
+// items of every leg, the unique and repeated conditional items and the
+// airline private data and the security data. Nothing but spaces may
+// follow the security data. The top level leg fields mirror Legs[0]. Flight
+// dates are resolved with ResolveFlightDate against the reference time
+// and window given in opts. With WithRegistry the airports and airline
+// of every leg are checked against the registry.
+func ParseBCBP(data string, opts ...ParseOption) (BCBP, error) {
 	var result BCBP
+	opt := &parseOption{
+		reference: time.Now(),
+		window:    DefaultDateWindow,
+	}
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+
 	if len(data) < 58 {
 		return result, errors.New("invalid BCBP (Code: 1)")
 	}
@@ -103,45 +169,226 @@ func ParseBCBP(data string) (BCBP, error) {
 		firstName = nameParts[1]
 	}
 
-	date, err := julianDayToGregorian(strings.TrimSpace(data[44:47]))
-	if err != nil {
-		return result, errors.New("invalid BCBP (Code: 3)")
+	totalLeg, err := strconv.Atoi(data[1:2])
+	if err != nil || totalLeg < 1 {
+		return result, errors.New("invalid BCBP (Code: 6)")
+	}
+
+	result = BCBP{
+		FormatCode: strings.TrimSpace(data[0:1]),
+		TotalLeg:   strings.TrimSpace(data[1:2]),
+		FirstName:  strings.TrimSpace(firstName),
+		LastName:   strings.TrimSpace(nameParts[0]),
+		Name:       strings.TrimSpace(data[2:22]),
+		Indicator:  strings.TrimSpace(data[22:23]),
+	}
+
+	r := &bcbpReader{data: data, pos: 23}
+	for i := 0; i < totalLeg; i++ {
+		leg, err := parseBCBPLeg(r, i == 0, &result.Conditional)
+		if err != nil {
+			return result, err
+		}
+		result.Legs = append(result.Legs, leg)
+	}
+
+	result.signed = data[:r.pos]
+	if strings.HasPrefix(data[r.pos:], "^") {
+		r.next(1)
+		result.Security.Type = r.next(1)
+		size, err := parseHexSize(r.next(2))
+		if err != nil {
+			return result, errors.New("invalid BCBP (Code: 10)")
+		}
+		result.Security.Data = r.next(size)
+		if len(result.Security.Data) < size {
+			return result, errors.New("invalid BCBP (Code: 10)")
+		}
+	}
+
+	for i := range result.Legs {
+		leg := &result.Legs[i]
+		date, err := ResolveFlightDate(leg.julianDay, result.Conditional.IssueDate, opt.reference, opt.window)
+		if err != nil {
+			return result, errors.New("invalid BCBP (Code: 9)")
+		}
+		leg.Date = date.Date.Format("2006-01-02")
+		leg.Year = date.Year
+		leg.DateAmbiguous = date.Ambiguous
+		leg.DateOutsideWindow = date.OutsideWindow
+		if opt.registry != nil {
+			if err := enrichLeg(leg, opt.registry); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	first := result.Legs[0]
+	result.PnrCode = first.PnrCode
+	result.From = first.From
+	result.To = first.To
+	result.Airline = first.Airline
+	result.FlightNumber = first.FlightNumber
+	result.Date = first.Date
+	result.Class = first.Class
+	result.Seat = first.Seat
+	result.Sequence = first.Sequence
+	result.Status = first.Status
+	result.Origin = first.Origin
+	result.Destination = first.Destination
+	result.Carrier = first.Carrier
+	if strings.TrimRight(data[r.pos:], " ") != "" {
+		return result, errTrailingData
+	}
+	return result, nil
+}
+
+// parseBCBPLeg reads the 37 mandatory repeated characters of a leg and
+// its variable size field. The unique conditional items are only
+// present in the first leg.
+func parseBCBPLeg(r *bcbpReader, first bool, unique *BCBPConditional) (BCBPLeg, error) {
+	var leg BCBPLeg
+	mandatory := r.next(37)
+	if len(mandatory) < 35 {
+		return leg, errors.New("invalid BCBP (Code: 1)")
 	}
+	mandatory = fmt.Sprintf("%-37s", mandatory)
 
-	airline := strings.TrimSpace(data[36:39])
+	julianDay := strings.TrimSpace(mandatory[21:24])
+	if day, err := strconv.Atoi(julianDay); err != nil || day < 1 || day > 366 {
+		return leg, errors.New("invalid BCBP (Code: 3)")
+	}
+
+	airline := strings.TrimSpace(mandatory[13:16])
 	if len(airline) < 2 {
-		return result, errors.New("invalid BCBP (Code: 4)")
+		return leg, errors.New("invalid BCBP (Code: 4)")
 	}
 	for _, char := range airline {
 		if !(unicode.IsLetter(char) != unicode.IsNumber(char) != unicode.IsLower(char)) {
-			return result, errors.New("invalid BCBP (Code: 4)")
+			return leg, errors.New("invalid BCBP (Code: 4)")
 		}
 	}
 
-	flightNumber, err := sanitizeFlightNumber(data[39:43])
+	flightNumber, err := sanitizeFlightNumber(mandatory[16:21])
 	if err != nil {
-		return result, errors.New("invalid BCBP (Code: 5)")
+		return leg, errors.New("invalid BCBP (Code: 5)")
 	}
 
-	result = BCBP{
-		FormatCode:   strings.TrimSpace(data[0:1]),
-		TotalLeg:     strings.TrimSpace(data[1:2]),
-		FirstName:    strings.TrimSpace(firstName),
-		LastName:     strings.TrimSpace(nameParts[0]),
-		Name:         strings.TrimSpace(data[2:22]),
-		Indicator:    strings.TrimSpace(data[22:23]),
-		PnrCode:      strings.TrimSpace(data[23:30]),
-		From:         strings.TrimSpace(data[30:33]),
-		To:           strings.TrimSpace(data[33:36]),
-		Airline:      airline,
-		FlightNumber: flightNumber,
-		Date:         date,
-		Class:        strings.TrimSpace(data[47:48]),
-		Seat:         strings.TrimSpace(data[48:52]),
-		Sequence:     strings.TrimSpace(data[52:56]),
-		Status:       strings.TrimSpace(data[57:58]),
+	leg.PnrCode = strings.TrimSpace(mandatory[0:7])
+	leg.From = strings.TrimSpace(mandatory[7:10])
+	leg.To = strings.TrimSpace(mandatory[10:13])
+	leg.Airline = airline
+	leg.FlightNumber = flightNumber
+	leg.julianDay = julianDay
+	leg.Class = strings.TrimSpace(mandatory[24:25])
+	leg.Seat = strings.TrimSpace(mandatory[25:29])
+	leg.Sequence = strings.TrimSpace(mandatory[29:34])
+	leg.Status = strings.TrimSpace(mandatory[34:35])
+
+	size, err := parseHexSize(mandatory[35:37])
+	if err != nil {
+		return leg, errors.New("invalid BCBP (Code: 7)")
 	}
-	return result, nil
+	if size == 0 {
+		return leg, nil
+	}
+	variable := r.next(size)
+	if len(variable) < size {
+		return leg, errors.New("invalid BCBP (Code: 8)")
+	}
+
+	v := &bcbpReader{data: variable}
+	if first && strings.HasPrefix(variable, ">") {
+		v.next(1)
+		unique.Version = v.next(1)
+		if unique.Version < "1" || unique.Version > "9" {
+			return leg, errors.New("invalid BCBP (Code: 11)")
+		}
+		uniqueSize, err := parseHexSize(v.next(2))
+		if err != nil {
+			return leg, errors.New("invalid BCBP (Code: 7)")
+		}
+		uniqueData := v.next(uniqueSize)
+		if len(uniqueData) < uniqueSize {
+			return leg, errors.New("invalid BCBP (Code: 8)")
+		}
+		u := &bcbpReader{data: uniqueData}
+		unique.PassengerDescription = u.field(1)
+		unique.CheckinSource = u.field(1)
+		unique.IssuanceSource = u.field(1)
+		unique.IssueDate = u.field(4)
+		unique.DocumentType = u.field(1)
+		unique.IssuerAirline = u.field(3)
+		unique.BaggageTag = u.field(13)
+		unique.BaggageTag2 = u.field(13)
+		unique.BaggageTag3 = u.field(13)
+	}
+
+	if v.remaining() >= 2 {
+		repeatedSize, err := parseHexSize(v.next(2))
+		if err != nil {
+			return leg, errors.New("invalid BCBP (Code: 7)")
+		}
+		repeatedData := v.next(repeatedSize)
+		if len(repeatedData) < repeatedSize {
+			return leg, errors.New("invalid BCBP (Code: 8)")
+		}
+		c := &bcbpReader{data: repeatedData}
+		leg.AirlineNumericCode = c.field(3)
+		leg.DocumentSerialNumber = c.field(10)
+		leg.SelecteeIndicator = c.field(1)
+		leg.DocumentVerification = c.field(1)
+		leg.MarketingCarrier = c.field(3)
+		leg.FrequentFlyerAirline = c.field(3)
+		leg.FrequentFlyerNumber = c.field(16)
+		leg.IDADIndicator = c.field(1)
+		leg.FreeBaggageAllowance = c.field(3)
+		leg.FastTrack = c.field(1)
+	}
+	leg.AirlineData = v.next(v.remaining())
+	return leg, nil
+}
+
+// bcbpReader walks a BCBP section. Conditional items may be cut short
+// by their field size, so reads past the end return what is left.
+type bcbpReader struct {
+	data string
+	pos  int
+}
+
+func (r *bcbpReader) next(n int) string {
+	if r.pos >= len(r.data) || n <= 0 {
+		return ""
+	}
+	end := r.pos + n
+	if end > len(r.data) {
+		end = len(r.data)
+	}
+	ret := r.data[r.pos:end]
+	r.pos = end
+	return ret
+}
+
+func (r *bcbpReader) field(n int) string {
+	return strings.TrimSpace(r.next(n))
+}
+
+func (r *bcbpReader) remaining() int {
+	if r.pos >= len(r.data) {
+		return 0
+	}
+	return len(r.data) - r.pos
+}
+
+func parseHexSize(s string) (int, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	size, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		return 0, err
+	}
+	return int(size), nil
 }
 
 func sanitizeFlightNumber(flightNumber string) (string, error) {
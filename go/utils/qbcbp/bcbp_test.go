@@ -0,0 +1,186 @@
+package qbcbp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// iataSample is the two leg example of the IATA Resolution 792
+// implementation guide, version 5 with unique, repeated and airline
+// private conditional items and a security section.
+const iataSample = "M2DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J003A0027 167>5321WW1325BAC 0014123456002" +
+	"001412346700100141234789012A0141234567890 1AC AC 1234567890123    4PCYLX58Z" +
+	"DEF456 FRAGVALH 3664 327C012C0002 12E2A0141234567890 1AC AC 1234567890123    3PCNWQ" +
+	"^164GIWVC5EH7JNT684FVNJ91W2QA4DVN5J8K4F0L0GEQ3DF5TGBN8709HKT5D3DW3GBHFCVHMY7J5T6HFR41W2QA4DVN5J8K4F0L0GE"
+
+// mandatoryOnly is a single leg pass without conditional items.
+const mandatoryOnly = "M1DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J001A0025 100"
+
+var sampleIssue = time.Date(2021, 11, 21, 12, 0, 0, 0, time.UTC)
+
+func TestParseBCBPIATASample(t *testing.T) {
+	got, err := ParseBCBP(iataSample, WithReferenceTime(sampleIssue))
+	if err != nil {
+		t.Fatalf("ParseBCBP() error = %v", err)
+	}
+
+	if got.FormatCode != "M" || got.TotalLeg != "2" || got.FirstName != "LUC" || got.LastName != "DESMARAIS" ||
+		got.Name != "DESMARAIS/LUC" || got.Indicator != "E" {
+		t.Errorf("ParseBCBP() header = %q %q %q %q %q %q", got.FormatCode, got.TotalLeg, got.Name,
+			got.FirstName, got.LastName, got.Indicator)
+	}
+	wantConditional := BCBPConditional{
+		Version:              "5",
+		PassengerDescription: "1",
+		CheckinSource:        "W",
+		IssuanceSource:       "W",
+		IssueDate:            "1325",
+		DocumentType:         "B",
+		IssuerAirline:        "AC",
+		BaggageTag:           "0014123456002",
+		BaggageTag2:          "0014123467001",
+		BaggageTag3:          "0014123478901",
+	}
+	if got.Conditional != wantConditional {
+		t.Errorf("ParseBCBP() Conditional = %+v, want %+v", got.Conditional, wantConditional)
+	}
+	wantLegs := []BCBPLeg{
+		{
+			PnrCode: "ABC123", From: "YUL", To: "FRA", Airline: "AC", FlightNumber: "834",
+			Date: "2021-11-22", Year: 2021, Class: "J", Seat: "003A", Sequence: "0027", Status: "1",
+			AirlineNumericCode: "014", DocumentSerialNumber: "1234567890", DocumentVerification: "1",
+			MarketingCarrier: "AC", FrequentFlyerAirline: "AC", FrequentFlyerNumber: "1234567890123",
+			FreeBaggageAllowance: "4PC", FastTrack: "Y", AirlineData: "LX58Z", julianDay: "326",
+		},
+		{
+			PnrCode: "DEF456", From: "FRA", To: "GVA", Airline: "LH", FlightNumber: "3664",
+			Date: "2021-11-23", Year: 2021, Class: "C", Seat: "012C", Sequence: "0002", Status: "1",
+			AirlineNumericCode: "014", DocumentSerialNumber: "1234567890", DocumentVerification: "1",
+			MarketingCarrier: "AC", FrequentFlyerAirline: "AC", FrequentFlyerNumber: "1234567890123",
+			FreeBaggageAllowance: "3PC", FastTrack: "N", AirlineData: "WQ", julianDay: "327",
+		},
+	}
+	if !reflect.DeepEqual(got.Legs, wantLegs) {
+		t.Errorf("ParseBCBP() Legs = %+v, want %+v", got.Legs, wantLegs)
+	}
+	if got.PnrCode != "ABC123" || got.FlightNumber != "834" || got.Date != "2021-11-22" || got.Seat != "003A" {
+		t.Errorf("ParseBCBP() does not mirror the first leg: %+v", got)
+	}
+	if got.Security.Type != "1" || len(got.Security.Data) != 0x64 {
+		t.Errorf("ParseBCBP() Security = %+v, want type 1 with 100 characters", got.Security)
+	}
+	if want := iataSample[:strings.Index(iataSample, "^")]; got.signed != want {
+		t.Errorf("ParseBCBP() signed = %q, want %q", got.signed, want)
+	}
+}
+
+func TestParseBCBPMandatoryOnly(t *testing.T) {
+	got, err := ParseBCBP(mandatoryOnly, WithReferenceTime(sampleIssue))
+	if err != nil {
+		t.Fatalf("ParseBCBP() error = %v", err)
+	}
+	want := []BCBPLeg{{
+		PnrCode: "ABC123", From: "YUL", To: "FRA", Airline: "AC", FlightNumber: "834",
+		Date: "2021-11-22", Year: 2021, Class: "J", Seat: "001A", Sequence: "0025", Status: "1",
+		julianDay: "326",
+	}}
+	if !reflect.DeepEqual(got.Legs, want) {
+		t.Errorf("ParseBCBP() Legs = %+v, want %+v", got.Legs, want)
+	}
+	if got.Conditional != (BCBPConditional{}) || got.Security != (BCBPSecurity{}) {
+		t.Errorf("ParseBCBP() = %+v, want no conditional or security data", got)
+	}
+}
+
+func TestParseBCBPErrors(t *testing.T) {
+	signed := iataSample[:strings.Index(iataSample, "^")]
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"too short", mandatoryOnly[:57], "invalid BCBP (Code: 1)"},
+		{"leg count", "M0" + mandatoryOnly[2:], "invalid BCBP (Code: 6)"},
+		{"missing leg", "M2" + mandatoryOnly[2:], "invalid BCBP (Code: 1)"},
+		{"field size overruns the pass", mandatoryOnly[:58] + "FF>5", "invalid BCBP (Code: 8)"},
+		{"field size not hexadecimal", mandatoryOnly[:58] + "G1>", "invalid BCBP (Code: 7)"},
+		{"unique size overruns the field", strings.Replace(iataSample, ">532", ">5FF", 1), "invalid BCBP (Code: 8)"},
+		{"repeated size overruns the field", strings.Replace(iataSample, "2A0141234567890 1AC AC 1234567890123    3PC",
+			"FF0141234567890 1AC AC 1234567890123    3PC", 1), "invalid BCBP (Code: 8)"},
+		{"version not a number", strings.Replace(iataSample, ">532", ">X32", 1), "invalid BCBP (Code: 11)"},
+		{"version zero", strings.Replace(iataSample, ">532", ">032", 1), "invalid BCBP (Code: 11)"},
+		{"security size overruns the pass", signed + "^1FF" + "GIWVC5EH7J", "invalid BCBP (Code: 10)"},
+		{"security size not hexadecimal", signed + "^1ZZ" + "GIWVC5EH7J", "invalid BCBP (Code: 10)"},
+		{"data after the security section", iataSample + "XYZ", "invalid BCBP (Code: 12)"},
+		{"data after the last leg", signed + "XYZ", "invalid BCBP (Code: 12)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBCBP(tt.data, WithReferenceTime(sampleIssue))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ParseBCBP() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseBCBP(iataSample+"   ", WithReferenceTime(sampleIssue)); err != nil {
+		t.Errorf("ParseBCBP() with trailing spaces error = %v", err)
+	}
+}
+
+func TestParseBCBPLeg(t *testing.T) {
+	const mandatory = "ABC123 YULFRAAC 0834 326J001A0025 1"
+	tests := []struct {
+		name    string
+		data    string
+		first   bool
+		want    BCBPLeg
+		unique  BCBPConditional
+		wantErr string
+	}{
+		{"mandatory items only", mandatory + "00", true,
+			BCBPLeg{PnrCode: "ABC123", From: "YUL", To: "FRA", Airline: "AC", FlightNumber: "834", Class: "J",
+				Seat: "001A", Sequence: "0025", Status: "1", julianDay: "326"}, BCBPConditional{}, ""},
+		{"status and size missing", mandatory[:35], true,
+			BCBPLeg{PnrCode: "ABC123", From: "YUL", To: "FRA", Airline: "AC", FlightNumber: "834", Class: "J",
+				Seat: "001A", Sequence: "0025", Status: "1", julianDay: "326"}, BCBPConditional{}, ""},
+		{"cut short unique items", mandatory + "07>60301W", true,
+			BCBPLeg{PnrCode: "ABC123", From: "YUL", To: "FRA", Airline: "AC", FlightNumber: "834", Class: "J",
+				Seat: "001A", Sequence: "0025", Status: "1", julianDay: "326"},
+			BCBPConditional{Version: "6", PassengerDescription: "0", CheckinSource: "1", IssuanceSource: "W"}, ""},
+		{"repeated items and airline data", mandatory + "0703014AB", false,
+			BCBPLeg{PnrCode: "ABC123", From: "YUL", To: "FRA", Airline: "AC", FlightNumber: "834", Class: "J",
+				Seat: "001A", Sequence: "0025", Status: "1", julianDay: "326",
+				AirlineNumericCode: "014", AirlineData: "AB"}, BCBPConditional{}, ""},
+		{"unique items only in the first leg", mandatory + "07>60301W", false, BCBPLeg{}, BCBPConditional{}, "invalid BCBP (Code: 7)"},
+		{"short mandatory items", mandatory[:34], true, BCBPLeg{}, BCBPConditional{}, "invalid BCBP (Code: 1)"},
+		{"day of year zero", strings.Replace(mandatory, "326", "000", 1), true, BCBPLeg{}, BCBPConditional{}, "invalid BCBP (Code: 3)"},
+		{"day of year 367", strings.Replace(mandatory, "326", "367", 1), true, BCBPLeg{}, BCBPConditional{}, "invalid BCBP (Code: 3)"},
+		{"airline too short", strings.Replace(mandatory, "AC ", "A  ", 1), true, BCBPLeg{}, BCBPConditional{}, "invalid BCBP (Code: 4)"},
+		{"flight number", strings.Replace(mandatory, "0834 ", "X834 ", 1), true, BCBPLeg{}, BCBPConditional{}, "invalid BCBP (Code: 5)"},
+		{"field size", mandatory + "1G", true, BCBPLeg{}, BCBPConditional{}, "invalid BCBP (Code: 7)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var unique BCBPConditional
+			got, err := parseBCBPLeg(&bcbpReader{data: tt.data}, tt.first, &unique)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("parseBCBPLeg() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBCBPLeg() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBCBPLeg() = %+v, want %+v", got, tt.want)
+			}
+			if unique != tt.unique {
+				t.Errorf("parseBCBPLeg() unique = %+v, want %+v", unique, tt.unique)
+			}
+		})
+	}
+}
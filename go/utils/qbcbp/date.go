@@ -0,0 +1,144 @@
+package qbcbp
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/mhaqqiw/sdk/go/utils/qiata"
+)
+
+// DateWindow bounds the flight date around the reference time, in days.
+type DateWindow struct {
+	Before int `json:"before"`
+	After  int `json:"after"`
+}
+
+// DefaultDateWindow accepts passes for flights up to two days ago and
+// almost a year ahead.
+var DefaultDateWindow = DateWindow{Before: 2, After: 360}
+
+// FlightDate is a day-of-year flight date resolved to a calendar date.
+// Ambiguous is set when more than one year fits the window; the one
+// closest to the reference time is returned then. OutsideWindow is set
+// when no year fits; the closest date is returned then, for the caller
+// to decide on, e.g. to refuse boarding rather than reject the pass.
+type FlightDate struct {
+	Date          time.Time `json:"date"`
+	Year          int       `json:"year"`
+	Ambiguous     bool      `json:"ambiguous"`
+	OutsideWindow bool      `json:"outside_window"`
+}
+
+type parseOption struct {
+	reference time.Time
+	window    DateWindow
+	registry  *qiata.Registry
+}
+
+type ParseOption func(*parseOption)
+
+// WithReferenceTime sets the time flight dates are resolved against,
+// usually the scan time. Defaults to time.Now().
+func WithReferenceTime(t time.Time) ParseOption {
+	return func(o *parseOption) {
+		o.reference = t
+	}
+}
+
+// WithDateWindow sets how many days before and after the reference time
+// a flight date may lie.
+func WithDateWindow(before, after int) ParseOption {
+	return func(o *parseOption) {
+		o.window = DateWindow{Before: before, After: after}
+	}
+}
+
+// ResolveFlightDate picks the year of a three digit day-of-year flight
+// date. When the boarding pass carries its date of issue ("yddd", last
+// digit of the year and day of year) the flight is the first matching
+// day on or after the issue date. Otherwise every year around ref is
+// tried and the date closest to ref within the window is taken, or the
+// closest one overall flagged OutsideWindow.
+func ResolveFlightDate(julianDay, issueDate string, ref time.Time, window DateWindow) (FlightDate, error) {
+	var ret FlightDate
+	day, err := strconv.Atoi(julianDay)
+	if err != nil || day < 1 || day > 366 {
+		return ret, errors.New("invalid Julian day")
+	}
+
+	if issued, err := resolveIssueDate(issueDate, ref); err == nil {
+		for _, year := range []int{issued.Year(), issued.Year() + 1} {
+			if date, ok := dayOfYear(year, day); ok && !date.Before(issued) {
+				ret.Date, ret.Year = date, year
+				return ret, nil
+			}
+		}
+	}
+
+	ref = time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, time.UTC)
+	earliest := ref.AddDate(0, 0, -window.Before)
+	latest := ref.AddDate(0, 0, window.After)
+	found := 0
+	var nearest time.Time
+	for _, year := range []int{ref.Year() - 1, ref.Year(), ref.Year() + 1} {
+		date, ok := dayOfYear(year, day)
+		if !ok {
+			continue
+		}
+		if nearest.IsZero() || absDuration(date, ref) < absDuration(nearest, ref) {
+			nearest = date
+		}
+		if date.Before(earliest) || date.After(latest) {
+			continue
+		}
+		found++
+		if found == 1 || absDuration(date, ref) < absDuration(ret.Date, ref) {
+			ret.Date, ret.Year = date, year
+		}
+	}
+	if found == 0 {
+		ret.Date, ret.Year = nearest, nearest.Year()
+		ret.OutsideWindow = true
+	}
+	ret.Ambiguous = found > 1
+	return ret, nil
+}
+
+// resolveIssueDate reads the "yddd" date of issue, taking the most
+// recent year ending in y that is not after ref.
+func resolveIssueDate(issueDate string, ref time.Time) (time.Time, error) {
+	if len(issueDate) != 4 {
+		return time.Time{}, errors.New("invalid issue date")
+	}
+	digit, err := strconv.Atoi(issueDate[:1])
+	if err != nil {
+		return time.Time{}, errors.New("invalid issue date")
+	}
+	day, err := strconv.Atoi(issueDate[1:])
+	if err != nil {
+		return time.Time{}, errors.New("invalid issue date")
+	}
+
+	year := ref.Year() - (ref.Year()%10-digit+10)%10
+	date, ok := dayOfYear(year, day)
+	if !ok || date.After(ref) {
+		year -= 10
+		if date, ok = dayOfYear(year, day); !ok {
+			return time.Time{}, errors.New("invalid issue date")
+		}
+	}
+	return date, nil
+}
+
+func dayOfYear(year, day int) (time.Time, bool) {
+	date := time.Date(year, 1, day, 0, 0, 0, 0, time.UTC)
+	return date, day >= 1 && date.Year() == year
+}
+
+func absDuration(a, b time.Time) time.Duration {
+	if a.After(b) {
+		return a.Sub(b)
+	}
+	return b.Sub(a)
+}
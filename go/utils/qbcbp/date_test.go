@@ -0,0 +1,89 @@
+package qbcbp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveFlightDate(t *testing.T) {
+	ref := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		julian    string
+		issue     string
+		window    DateWindow
+		want      string
+		ambiguous bool
+		outside   bool
+	}{
+		{"today", "291", "", DefaultDateWindow, "2026-10-18", false, false},
+		{"yesterday", "290", "", DefaultDateWindow, "2026-10-17", false, false},
+		{"next year", "010", "", DefaultDateWindow, "2027-01-10", false, false},
+		{"before the window", "288", "", DefaultDateWindow, "2026-10-15", false, true},
+		{"after the window", "330", "", DateWindow{Before: 2, After: 30}, "2026-11-26", false, true},
+		{"months ago", "200", "", DateWindow{Before: 2, After: 30}, "2026-07-19", false, true},
+		{"both years fit", "291", "", DateWindow{Before: 400, After: 400}, "2026-10-18", true, false},
+		{"from the issue date", "288", "6280", DefaultDateWindow, "2026-10-15", false, false},
+		{"issued last year", "005", "5360", DefaultDateWindow, "2026-01-05", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveFlightDate(tt.julian, tt.issue, ref, tt.window)
+			if err != nil {
+				t.Fatalf("ResolveFlightDate() error = %v", err)
+			}
+			if d := got.Date.Format("2006-01-02"); d != tt.want || got.Year != got.Date.Year() {
+				t.Errorf("Date = %s, Year = %d, want %s", d, got.Year, tt.want)
+			}
+			if got.Ambiguous != tt.ambiguous || got.OutsideWindow != tt.outside {
+				t.Errorf("Ambiguous, OutsideWindow = %v, %v, want %v, %v", got.Ambiguous, got.OutsideWindow, tt.ambiguous, tt.outside)
+			}
+		})
+	}
+}
+
+func TestResolveFlightDateInvalid(t *testing.T) {
+	for _, julian := range []string{"000", "367", "1A2"} {
+		if _, err := ResolveFlightDate(julian, "", time.Now(), DefaultDateWindow); err == nil {
+			t.Errorf("ResolveFlightDate(%q) error = nil, want an error", julian)
+		}
+	}
+}
+
+// encodeTestPass encodes a one leg boarding pass for GA404 from CGK to
+// DPS on date, given as "2006-01-02".
+func encodeTestPass(t *testing.T, date string) string {
+	t.Helper()
+	pass, err := EncodeBCBP(BCBP{
+		LastName:  "ERIKSSON",
+		FirstName: "ANNA",
+		Legs: []BCBPLeg{{
+			PnrCode:      "ABC123",
+			From:         "CGK",
+			To:           "DPS",
+			Airline:      "GA",
+			FlightNumber: "404",
+			Date:         date,
+			Class:        "Y",
+			Seat:         "12A",
+			Sequence:     "1",
+			Status:       "1",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("EncodeBCBP() error = %v", err)
+	}
+	return pass
+}
+
+func TestParseBCBPOutsideWindow(t *testing.T) {
+	pass := encodeTestPass(t, "2026-10-15")
+	got, err := ParseBCBP(pass, WithReferenceTime(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatalf("ParseBCBP() error = %v", err)
+	}
+	leg := got.Legs[0]
+	if leg.Date != "2026-10-15" || !leg.DateOutsideWindow {
+		t.Errorf("leg date = %s, outside window = %v, want 2026-10-15, true", leg.Date, leg.DateOutsideWindow)
+	}
+}
@@ -0,0 +1,268 @@
+package qbcbp
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Generator fills in the fields left empty in a BCBP value with random
+// data and encodes it. Use WithSeed or WithSource for reproducible
+// output.
+type Generator struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+type generatorOption struct {
+	source rand.Source
+}
+
+type GeneratorOption func(*generatorOption)
+
+// WithSeed seeds the random source of the generator.
+func WithSeed(seed int64) GeneratorOption {
+	return func(o *generatorOption) {
+		o.source = rand.NewSource(seed)
+	}
+}
+
+// WithSource sets the random source of the generator.
+func WithSource(src rand.Source) GeneratorOption {
+	return func(o *generatorOption) {
+		o.source = src
+	}
+}
+
+func NewGenerator(opts ...GeneratorOption) *Generator {
+	opt := &generatorOption{}
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+	if opt.source == nil {
+		opt.source = rand.NewSource(time.Now().UnixNano())
+	}
+	return &Generator{rng: rand.New(opt.source)}
+}
+
+var defaultGenerator = NewGenerator()
+
+// Generate encodes data after filling in the PNR, airline, flight
+// number, class, seat, sequence and passenger status of every leg that
+// leaves them empty. The passenger name and flight dates are required.
+// A BCBP without Legs is encoded as a single leg built from its top
+// level fields.
+func (g *Generator) Generate(data BCBP) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	data.Legs = bcbpLegs(data)
+	for i := range data.Legs {
+		leg := &data.Legs[i]
+		if leg.PnrCode == "" {
+			leg.PnrCode = generateData(g.rng, 6, "[A-Z]")
+		}
+		if leg.Airline == "" {
+			leg.Airline = generateData(g.rng, 2, "[A-Z]")
+		}
+		if leg.FlightNumber == "" {
+			leg.FlightNumber = generateData(g.rng, 4, "[0-9]")
+		}
+		if leg.Class == "" {
+			leg.Class = generateData(g.rng, 1, "[FCY]")
+		}
+		if leg.Seat == "" {
+			leg.Seat = generateData(g.rng, 3, "[0-9]") + generateData(g.rng, 1, "[A-Z]")
+		}
+		if leg.Sequence == "" {
+			leg.Sequence = generateData(g.rng, 4, "[0-9]")
+		}
+		if leg.Status == "" {
+			leg.Status = "1"
+		}
+	}
+	return EncodeBCBP(data)
+}
+
+// GenerateLegs generates a boarding pass for the passenger over the
+// given legs.
+func (g *Generator) GenerateLegs(lastName, firstName string, legs ...BCBPLeg) (string, error) {
+	return g.Generate(BCBP{LastName: lastName, FirstName: firstName, Legs: legs})
+}
+
+// EncodeBCBP encodes data as an IATA Resolution 792 boarding pass string
+// without filling in anything. Conditional items are written up to the
+// last one set; the version number defaults to "6" when the unique
+// conditional items are present.
+func EncodeBCBP(data BCBP) (string, error) {
+	legs := bcbpLegs(data)
+	if len(legs) < 1 || len(legs) > 9 {
+		return "", errors.New("invalid number of legs")
+	}
+
+	name := data.Name
+	if name == "" {
+		if data.LastName == "" || data.FirstName == "" {
+			return "", errors.New("missing required parameters")
+		}
+		name = strings.ToUpper(data.LastName) + "/" + strings.ToUpper(data.FirstName)
+	}
+
+	w := &bcbpWriter{}
+	w.write(defaultString(data.FormatCode, "M"), 1, "format code")
+	w.write(fmt.Sprintf("%d", len(legs)), 1, "number of legs")
+	if len(name) > 20 {
+		name = name[:20]
+	}
+	w.write(name, 20, "name")
+	w.write(defaultString(data.Indicator, "E"), 1, "electronic ticket indicator")
+
+	for i, leg := range legs {
+		julian := dateToJulian(leg.Date)
+		if julian == "000" {
+			return "", fmt.Errorf("invalid date of flight on leg %d", i+1)
+		}
+		w.write(leg.PnrCode, 7, "pnr code")
+		w.write(leg.From, 3, "from")
+		w.write(leg.To, 3, "to")
+		w.write(leg.Airline, 3, "airline")
+		w.write(padNumeric(leg.FlightNumber, 4), 5, "flight number")
+		w.write(julian, 3, "date")
+		w.write(leg.Class, 1, "class")
+		w.write(padNumeric(leg.Seat, 3), 4, "seat")
+		w.write(padNumeric(leg.Sequence, 4), 5, "sequence")
+		w.write(leg.Status, 1, "status")
+
+		variable := &bcbpWriter{}
+		if i == 0 {
+			variable.unique(data.Conditional)
+		}
+		variable.repeated(leg)
+		if variable.err != nil {
+			return "", variable.err
+		}
+		w.size(variable.String(), "variable size field")
+	}
+	if data.Security.Data != "" {
+		w.WriteString("^")
+		w.write(defaultString(data.Security.Type, SecurityTypeSignature), 1, "security type")
+		w.size(data.Security.Data, "security data")
+	}
+	if w.err != nil {
+		return "", w.err
+	}
+	return w.String(), nil
+}
+
+func bcbpLegs(data BCBP) []BCBPLeg {
+	if len(data.Legs) > 0 {
+		return append([]BCBPLeg(nil), data.Legs...)
+	}
+	return []BCBPLeg{{
+		PnrCode:      data.PnrCode,
+		From:         data.From,
+		To:           data.To,
+		Airline:      data.Airline,
+		FlightNumber: data.FlightNumber,
+		Date:         data.Date,
+		Class:        data.Class,
+		Seat:         data.Seat,
+		Sequence:     data.Sequence,
+		Status:       data.Status,
+	}}
+}
+
+// bcbpWriter builds a BCBP section, keeping the first error.
+type bcbpWriter struct {
+	strings.Builder
+	err error
+}
+
+func (w *bcbpWriter) write(value string, length int, name string) {
+	if len(value) > length {
+		if w.err == nil {
+			w.err = fmt.Errorf("%s longer than %d characters", name, length)
+		}
+		return
+	}
+	fmt.Fprintf(w, "%-*s", length, value)
+}
+
+// size writes section prefixed with its two digit hex length.
+func (w *bcbpWriter) size(section, name string) {
+	if len(section) > 0xff {
+		if w.err == nil {
+			w.err = fmt.Errorf("%s exceeds 255 characters", name)
+		}
+		return
+	}
+	fmt.Fprintf(w, "%02X%s", len(section), section)
+}
+
+// items writes the values up to the last non-empty one.
+func (w *bcbpWriter) items(values []string, lengths []int, names []string) {
+	last := -1
+	for i, v := range values {
+		if v != "" {
+			last = i
+		}
+	}
+	for i := 0; i <= last; i++ {
+		w.write(values[i], lengths[i], names[i])
+	}
+}
+
+func (w *bcbpWriter) unique(c BCBPConditional) {
+	values := []string{c.PassengerDescription, c.CheckinSource, c.IssuanceSource, c.IssueDate, c.DocumentType, c.IssuerAirline, c.BaggageTag, c.BaggageTag2, c.BaggageTag3}
+	if c.Version == "" && strings.Join(values, "") == "" {
+		return
+	}
+	items := &bcbpWriter{}
+	items.items(values,
+		[]int{1, 1, 1, 4, 1, 3, 13, 13, 13},
+		[]string{"passenger description", "check-in source", "issuance source", "issue date", "document type", "issuer airline", "baggage tag", "baggage tag 2", "baggage tag 3"})
+	if items.err != nil {
+		w.err = items.err
+		return
+	}
+	w.WriteString(">")
+	w.write(defaultString(c.Version, "6"), 1, "version")
+	w.size(items.String(), "unique conditional items")
+}
+
+func (w *bcbpWriter) repeated(leg BCBPLeg) {
+	items := &bcbpWriter{}
+	items.items(
+		[]string{leg.AirlineNumericCode, leg.DocumentSerialNumber, leg.SelecteeIndicator, leg.DocumentVerification, leg.MarketingCarrier, leg.FrequentFlyerAirline, leg.FrequentFlyerNumber, leg.IDADIndicator, leg.FreeBaggageAllowance, leg.FastTrack},
+		[]int{3, 10, 1, 1, 3, 3, 16, 1, 3, 1},
+		[]string{"airline numeric code", "document serial number", "selectee indicator", "document verification", "marketing carrier", "frequent flyer airline", "frequent flyer number", "id/ad indicator", "free baggage allowance", "fast track"})
+	if items.err != nil {
+		w.err = items.err
+		return
+	}
+	if items.Len() == 0 && leg.AirlineData == "" {
+		return
+	}
+	w.size(items.String(), "repeated conditional items")
+	w.WriteString(leg.AirlineData)
+}
+
+// padNumeric left pads the leading digits of value with zeros to
+// length, keeping any trailing letter: "12A" becomes "012A".
+func padNumeric(value string, length int) string {
+	digits := len(value) - len(strings.TrimLeft(value, "0123456789"))
+	if digits == 0 || digits >= length {
+		return value
+	}
+	return strings.Repeat("0", length-digits) + value
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
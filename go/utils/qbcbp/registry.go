@@ -0,0 +1,53 @@
+package qbcbp
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mhaqqiw/sdk/go/utils/qiata"
+)
+
+// WithRegistry validates the airports and operating carrier of every leg
+// against r and fills Origin, Destination and Carrier. Parsing fails with
+// an error wrapping qiata.ErrUnknownAirport or qiata.ErrUnknownAirline
+// for codes r does not know.
+func WithRegistry(r *qiata.Registry) ParseOption {
+	return func(o *parseOption) {
+		o.registry = r
+	}
+}
+
+func enrichLeg(leg *BCBPLeg, r *qiata.Registry) error {
+	origin, err := r.Airport(leg.From)
+	if err != nil {
+		return err
+	}
+	destination, err := r.Airport(leg.To)
+	if err != nil {
+		return err
+	}
+	carrier, err := r.Airline(leg.Airline)
+	if err != nil {
+		return err
+	}
+	leg.Origin, leg.Destination, leg.Carrier = &origin, &destination, &carrier
+	return nil
+}
+
+// LocalDate returns the start of the flight date in the time zone of the
+// departure airport, the time zone the date is given in. The leg must
+// have been parsed with WithRegistry.
+func (l BCBPLeg) LocalDate() (time.Time, error) {
+	if l.Origin == nil {
+		return time.Time{}, errors.New("departure airport not resolved")
+	}
+	date, err := time.Parse("2006-01-02", l.Date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := l.Origin.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc), nil
+}
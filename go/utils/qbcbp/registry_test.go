@@ -0,0 +1,53 @@
+package qbcbp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mhaqqiw/sdk/go/utils/qiata"
+)
+
+func TestLegLocalDate(t *testing.T) {
+	r, err := qiata.NewRegistry()
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		from string
+		date string
+		want time.Time
+	}{
+		// Auckland is ahead of UTC, so its flight date starts the day
+		// before in UTC; Los Angeles is behind and starts the same day.
+		{"west of the date line", "AKL", "2026-10-18", time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC)},
+		{"east of the date line", "LAX", "2026-10-18", time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC)},
+		{"jakarta", "CGK", "2026-10-18", time.Date(2026, 10, 17, 17, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origin, err := r.Airport(tt.from)
+			if err != nil {
+				t.Fatalf("Airport() error = %v", err)
+			}
+			got, err := BCBPLeg{From: tt.from, Date: tt.date, Origin: &origin}.LocalDate()
+			if err != nil {
+				t.Fatalf("LocalDate() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("LocalDate() = %v, want %v", got.UTC(), tt.want)
+			}
+			if got.Format("2006-01-02") != tt.date {
+				t.Errorf("LocalDate() local date = %s, want %s", got.Format("2006-01-02"), tt.date)
+			}
+		})
+	}
+
+	if _, err := (BCBPLeg{From: "AKL", Date: "2026-10-18"}).LocalDate(); err == nil {
+		t.Error("LocalDate() without origin error = nil, want an error")
+	}
+	origin, _ := r.Airport("AKL")
+	if _, err := (BCBPLeg{From: "AKL", Origin: &origin}).LocalDate(); err == nil {
+		t.Error("LocalDate() without date error = nil, want an error")
+	}
+}
@@ -0,0 +1,193 @@
+package qbcbp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by ParseSeat, ParseSequence and ParseCabin, wrapped
+// with the offending value.
+var (
+	ErrInvalidSeat     = errors.New("invalid seat number")
+	ErrInvalidSequence = errors.New("invalid check-in sequence number")
+	ErrUnknownCabin    = errors.New("unknown compartment code")
+)
+
+// Seat values given instead of a row and letter.
+const (
+	SeatInfant  = "INF"
+	SeatGate    = "GATE"
+	SeatStandby = "STBY"
+)
+
+// Seat is a parsed seat number. Row and Letter are only set for an
+// assigned seat: an infant on an adult's lap, a passenger whose seat is
+// given at the gate and a standby passenger have none.
+type Seat struct {
+	Row     int    `json:"row"`
+	Letter  string `json:"letter"`
+	Infant  bool   `json:"infant"`
+	Gate    bool   `json:"gate"`
+	Standby bool   `json:"standby"`
+}
+
+// Assigned reports whether the passenger has a seat.
+func (s Seat) Assigned() bool {
+	return s.Row > 0
+}
+
+func (s Seat) String() string {
+	switch {
+	case s.Infant:
+		return SeatInfant
+	case s.Gate:
+		return SeatGate
+	case s.Standby:
+		return SeatStandby
+	case !s.Assigned():
+		return ""
+	}
+	return strconv.Itoa(s.Row) + s.Letter
+}
+
+// ParseSeat reads a seat number field: a row of up to three digits,
+// usually zero padded, and a seat letter such as "012A", or one of INF,
+// GATE and STBY.
+func ParseSeat(s string) (Seat, error) {
+	var seat Seat
+	value := strings.ToUpper(strings.TrimSpace(s))
+	switch value {
+	case SeatInfant:
+		seat.Infant = true
+		return seat, nil
+	case SeatGate:
+		seat.Gate = true
+		return seat, nil
+	case SeatStandby:
+		seat.Standby = true
+		return seat, nil
+	}
+
+	if len(value) < 2 || len(value) > 4 {
+		return seat, fmt.Errorf("%w: %q", ErrInvalidSeat, s)
+	}
+	letter := value[len(value)-1]
+	row, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || !isDigits(value[:len(value)-1]) || row < 1 || letter < 'A' || letter > 'Z' {
+		return seat, fmt.Errorf("%w: %q", ErrInvalidSeat, s)
+	}
+	seat.Row = row
+	seat.Letter = string(letter)
+	return seat, nil
+}
+
+// Sequence is a parsed check-in sequence number, "0025" or with a
+// suffix letter "0025A" when an airline splits a number.
+type Sequence struct {
+	Number int    `json:"number"`
+	Suffix string `json:"suffix"`
+}
+
+func (s Sequence) String() string {
+	return fmt.Sprintf("%04d%s", s.Number, s.Suffix)
+}
+
+// ParseSequence reads a check-in sequence number field: up to four
+// digits, or five without a suffix, then an optional suffix letter.
+func ParseSequence(s string) (Sequence, error) {
+	var seq Sequence
+	value := strings.ToUpper(strings.TrimSpace(s))
+	if value == "" || len(value) > 5 {
+		return seq, fmt.Errorf("%w: %q", ErrInvalidSequence, s)
+	}
+	digits := value
+	if last := value[len(value)-1]; last >= 'A' && last <= 'Z' {
+		digits = value[:len(value)-1]
+		seq.Suffix = string(last)
+	}
+	number, err := strconv.Atoi(digits)
+	if err != nil || !isDigits(digits) {
+		return Sequence{}, fmt.Errorf("%w: %q", ErrInvalidSequence, s)
+	}
+	seq.Number = number
+	return seq, nil
+}
+
+// Cabin is the cabin a compartment code belongs to.
+type Cabin string
+
+const (
+	CabinFirst          Cabin = "First"
+	CabinBusiness       Cabin = "Business"
+	CabinPremiumEconomy Cabin = "Premium Economy"
+	CabinEconomy        Cabin = "Economy"
+)
+
+// cabins follows the IATA reservations booking designators (Resolution
+// 728). Airlines file their own fare classes within these cabins.
+// W is the only premium economy designator; E and U are the shuttle
+// designators and, like G, board in economy.
+var cabins = map[byte]Cabin{
+	'P': CabinFirst, 'F': CabinFirst, 'A': CabinFirst, 'R': CabinFirst,
+	'J': CabinBusiness, 'C': CabinBusiness, 'D': CabinBusiness, 'I': CabinBusiness, 'Z': CabinBusiness,
+	'W': CabinPremiumEconomy,
+	'Y': CabinEconomy, 'B': CabinEconomy, 'H': CabinEconomy, 'K': CabinEconomy, 'L': CabinEconomy,
+	'M': CabinEconomy, 'N': CabinEconomy, 'Q': CabinEconomy, 'T': CabinEconomy, 'V': CabinEconomy,
+	'X': CabinEconomy, 'G': CabinEconomy, 'O': CabinEconomy, 'S': CabinEconomy, 'U': CabinEconomy,
+	'E': CabinEconomy,
+}
+
+// ParseCabin returns the cabin of a one letter compartment code.
+func ParseCabin(code string) (Cabin, error) {
+	value := strings.ToUpper(strings.TrimSpace(code))
+	if len(value) == 1 {
+		if cabin, ok := cabins[value[0]]; ok {
+			return cabin, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownCabin, code)
+}
+
+// SeatNumber parses the seat of the leg.
+func (l BCBPLeg) SeatNumber() (Seat, error) {
+	return ParseSeat(l.Seat)
+}
+
+// CheckinSequence parses the check-in sequence number of the leg.
+func (l BCBPLeg) CheckinSequence() (Sequence, error) {
+	return ParseSequence(l.Sequence)
+}
+
+// Cabin returns the cabin of the compartment code of the leg.
+func (l BCBPLeg) Cabin() (Cabin, error) {
+	return ParseCabin(l.Class)
+}
+
+// SeatNumber parses the seat of the first leg.
+func (b BCBP) SeatNumber() (Seat, error) {
+	return ParseSeat(b.Seat)
+}
+
+// CheckinSequence parses the check-in sequence number of the first leg.
+func (b BCBP) CheckinSequence() (Sequence, error) {
+	return ParseSequence(b.Sequence)
+}
+
+// Cabin returns the cabin of the compartment code of the first leg.
+func (b BCBP) Cabin() (Cabin, error) {
+	return ParseCabin(b.Class)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package qbcbp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSeat(t *testing.T) {
+	tests := []struct {
+		seat       string
+		want       Seat
+		wantString string
+		wantErr    error
+	}{
+		{"001A", Seat{Row: 1, Letter: "A"}, "1A", nil},
+		{"012C", Seat{Row: 12, Letter: "C"}, "12C", nil},
+		{"12A", Seat{Row: 12, Letter: "A"}, "12A", nil},
+		{"1K", Seat{Row: 1, Letter: "K"}, "1K", nil},
+		{"105k ", Seat{Row: 105, Letter: "K"}, "105K", nil},
+		{"INF", Seat{Infant: true}, SeatInfant, nil},
+		{"GATE", Seat{Gate: true}, SeatGate, nil},
+		{"stby", Seat{Standby: true}, SeatStandby, nil},
+		{"000A", Seat{}, "", ErrInvalidSeat},
+		{"12", Seat{}, "", ErrInvalidSeat},
+		{"A", Seat{}, "", ErrInvalidSeat},
+		{"1234A", Seat{}, "", ErrInvalidSeat},
+		{"-12A", Seat{}, "", ErrInvalidSeat},
+		{"A12", Seat{}, "", ErrInvalidSeat},
+		{"12+A", Seat{}, "", ErrInvalidSeat},
+		{"INFT", Seat{}, "", ErrInvalidSeat},
+		{"", Seat{}, "", ErrInvalidSeat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.seat, func(t *testing.T) {
+			got, err := ParseSeat(tt.seat)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseSeat() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSeat() = %+v, want %+v", got, tt.want)
+			}
+			if got.String() != tt.wantString {
+				t.Errorf("String() = %q, want %q", got.String(), tt.wantString)
+			}
+			if got.Assigned() != (tt.want.Row > 0) {
+				t.Errorf("Assigned() = %v, want %v", got.Assigned(), tt.want.Row > 0)
+			}
+		})
+	}
+}
+
+func TestParseSequence(t *testing.T) {
+	tests := []struct {
+		sequence   string
+		want       Sequence
+		wantString string
+		wantErr    error
+	}{
+		{"0025", Sequence{Number: 25}, "0025", nil},
+		{"0025A", Sequence{Number: 25, Suffix: "A"}, "0025A", nil},
+		{"25b", Sequence{Number: 25, Suffix: "B"}, "0025B", nil},
+		{"00025", Sequence{Number: 25}, "0025", nil},
+		{"12345", Sequence{Number: 12345}, "12345", nil},
+		{" 0001 ", Sequence{Number: 1}, "0001", nil},
+		{"12345A", Sequence{}, "", ErrInvalidSequence},
+		{"A", Sequence{}, "", ErrInvalidSequence},
+		{"00AB", Sequence{}, "", ErrInvalidSequence},
+		{"A025", Sequence{}, "", ErrInvalidSequence},
+		{"+025", Sequence{}, "", ErrInvalidSequence},
+		{"", Sequence{}, "", ErrInvalidSequence},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sequence, func(t *testing.T) {
+			got, err := ParseSequence(tt.sequence)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseSequence() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSequence() = %+v, want %+v", got, tt.want)
+			}
+			if tt.wantErr == nil && got.String() != tt.wantString {
+				t.Errorf("String() = %q, want %q", got.String(), tt.wantString)
+			}
+		})
+	}
+}
+
+func TestParseCabin(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    Cabin
+		wantErr error
+	}{
+		{"F", CabinFirst, nil},
+		{"J", CabinBusiness, nil},
+		{"W", CabinPremiumEconomy, nil},
+		{"E", CabinEconomy, nil},
+		{"Y", CabinEconomy, nil},
+		{" c ", CabinBusiness, nil},
+		{"", "", ErrUnknownCabin},
+		{"1", "", ErrUnknownCabin},
+		{"YY", "", ErrUnknownCabin},
+		{"<", "", ErrUnknownCabin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got, err := ParseCabin(tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseCabin() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCabin() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	for code := byte('A'); code <= 'Z'; code++ {
+		if _, err := ParseCabin(string(code)); err != nil {
+			t.Errorf("ParseCabin(%q) error = %v, want a cabin for every letter", code, err)
+		}
+	}
+}
+
+func TestLegSeat(t *testing.T) {
+	leg := BCBPLeg{Class: "J", Seat: "003A", Sequence: "0027"}
+	if seat, err := leg.SeatNumber(); err != nil || seat != (Seat{Row: 3, Letter: "A"}) {
+		t.Errorf("SeatNumber() = %+v, %v", seat, err)
+	}
+	if seq, err := leg.CheckinSequence(); err != nil || seq != (Sequence{Number: 27}) {
+		t.Errorf("CheckinSequence() = %+v, %v", seq, err)
+	}
+	if cabin, err := leg.Cabin(); err != nil || cabin != CabinBusiness {
+		t.Errorf("Cabin() = %q, %v", cabin, err)
+	}
+}
@@ -0,0 +1,331 @@
+package qbcbp
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+)
+
+// SecurityTypeSignature is the type of security data written by Sign: a
+// base64 encoded DER signature over the SHA-256 of everything before the
+// security section.
+const SecurityTypeSignature = "1"
+
+// Errors returned by KeyStore.Verify, wrapped in a *SecurityError.
+var (
+	ErrNoSecurityData      = errors.New("BCBP security data missing")
+	ErrInvalidSecurityData = errors.New("BCBP security data malformed")
+	ErrUnknownSigner       = errors.New("BCBP signing key not found")
+	ErrInvalidSignature    = errors.New("BCBP signature not valid")
+)
+
+// BCBPSecurity is the security section following the last leg.
+type BCBPSecurity struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+// SecurityError reports a boarding pass that failed signature
+// verification.
+type SecurityError struct {
+	Err     error  `json:"-"`
+	Airline string `json:"airline"`
+}
+
+func (e *SecurityError) Error() string {
+	if e.Airline == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (%s)", e.Err.Error(), e.Airline)
+}
+
+func (e *SecurityError) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the passenger status reported for the failure.
+func (e *SecurityError) Status() string {
+	return qconstant.PassengerStatusInvalidBCBP
+}
+
+// KeyStore holds the signing keys of airlines, keyed by airline
+// designator. Private keys are only needed to sign.
+type KeyStore struct {
+	public  map[string]interface{}
+	private map[string]interface{}
+}
+
+type KeyStoreOption func(*KeyStore) error
+
+// WithKeyPath loads every "<airline>.pem" file in dir, e.g. GA.pem.
+func WithKeyPath(dir string) KeyStoreOption {
+	return func(s *KeyStore) error {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if f.IsDir() || filepath.Ext(f.Name()) != ".pem" {
+				continue
+			}
+			airline := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+			if err := s.LoadFile(airline, filepath.Join(dir, f.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithKeyFile loads the key of a single airline.
+func WithKeyFile(airline, path string) KeyStoreOption {
+	return func(s *KeyStore) error {
+		return s.LoadFile(airline, path)
+	}
+}
+
+func NewKeyStore(opts ...KeyStoreOption) (*KeyStore, error) {
+	s := &KeyStore{
+		public:  map[string]interface{}{},
+		private: map[string]interface{}{},
+	}
+	for _, optFunc := range opts {
+		if err := optFunc(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// AddPublicKey sets the verification key of an airline. Only
+// *ecdsa.PublicKey and *dsa.PublicKey are supported; DSA is deprecated
+// and kept for airlines still publishing DSA keys, new keys should be
+// ECDSA.
+func (s *KeyStore) AddPublicKey(airline string, key interface{}) error {
+	switch key.(type) {
+	case *ecdsa.PublicKey, *dsa.PublicKey:
+	default:
+		return errors.New("unsupported public key type")
+	}
+	s.public[strings.ToUpper(airline)] = key
+	return nil
+}
+
+// AddPrivateKey sets the signing key of an airline, and its public key
+// for verification. Only *ecdsa.PrivateKey and *dsa.PrivateKey are
+// supported, ECDSA being preferred as for AddPublicKey.
+func (s *KeyStore) AddPrivateKey(airline string, key interface{}) error {
+	airline = strings.ToUpper(airline)
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		s.public[airline] = &k.PublicKey
+	case *dsa.PrivateKey:
+		s.public[airline] = &k.PublicKey
+	default:
+		return errors.New("unsupported private key type")
+	}
+	s.private[airline] = key
+	return nil
+}
+
+// LoadFile adds the keys of a PEM file holding a public key, a
+// certificate, or an EC, PKCS #8 or DSA private key.
+func (s *KeyStore) LoadFile(airline, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return err
+			}
+			if err := s.AddPublicKey(airline, key); err != nil {
+				return err
+			}
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return err
+			}
+			if err := s.AddPublicKey(airline, cert.PublicKey); err != nil {
+				return err
+			}
+		case "EC PRIVATE KEY":
+			key, err := x509.ParseECPrivateKey(block.Bytes)
+			if err != nil {
+				return err
+			}
+			if err := s.AddPrivateKey(airline, key); err != nil {
+				return err
+			}
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return err
+			}
+			if err := s.AddPrivateKey(airline, key); err != nil {
+				return err
+			}
+		case "DSA PRIVATE KEY":
+			key, err := parseDSAPrivateKey(block.Bytes)
+			if err != nil {
+				return err
+			}
+			if err := s.AddPrivateKey(airline, key); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("no key found in %s", path)
+	}
+	return nil
+}
+
+// Sign appends a security section to data signed with the private key
+// of airline. data must be a boarding pass without a security section
+// and without anything after its last leg.
+func (s *KeyStore) Sign(data, airline string) (string, error) {
+	key, ok := s.private[strings.ToUpper(airline)]
+	if !ok {
+		return "", &SecurityError{Err: ErrUnknownSigner, Airline: airline}
+	}
+	parsed, err := ParseBCBP(data)
+	if err != nil && !errors.Is(err, errTrailingData) {
+		return "", err
+	}
+	if err != nil || parsed.signed != data {
+		return "", &SecurityError{Err: ErrInvalidSecurityData, Airline: airline}
+	}
+
+	digest := sha256.Sum256([]byte(data))
+	var sig []byte
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		sig, err = ecdsa.SignASN1(rand.Reader, k, digest[:])
+	case *dsa.PrivateKey:
+		var ds dsaSignature
+		ds.R, ds.S, err = dsa.Sign(rand.Reader, k, dsaDigest(&k.PublicKey, digest[:]))
+		if err == nil {
+			sig, err = asn1.Marshal(ds)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(sig)
+	if len(encoded) > 0xff {
+		return "", errors.New("signature exceeds 255 characters")
+	}
+	return fmt.Sprintf("%s^%s%02X%s", data, SecurityTypeSignature, len(encoded), encoded), nil
+}
+
+// Verify parses data and checks its security section against the key of
+// the issuing airline, or of the operating carrier of the first leg
+// when the issuer is not encoded. Nothing may follow the security data.
+func (s *KeyStore) Verify(data string, opts ...ParseOption) (BCBP, error) {
+	result, err := ParseBCBP(data, opts...)
+	airline := result.Conditional.IssuerAirline
+	if airline == "" {
+		airline = result.Airline
+	}
+	if errors.Is(err, errTrailingData) {
+		return result, &SecurityError{Err: ErrInvalidSecurityData, Airline: airline}
+	}
+	if err != nil {
+		return result, err
+	}
+	if result.Security.Data == "" {
+		return result, &SecurityError{Err: ErrNoSecurityData, Airline: airline}
+	}
+	if len(result.signed)+len("^")+1+2+len(result.Security.Data) != len(data) {
+		return result, &SecurityError{Err: ErrInvalidSecurityData, Airline: airline}
+	}
+	key, ok := s.public[strings.ToUpper(airline)]
+	if !ok {
+		return result, &SecurityError{Err: ErrUnknownSigner, Airline: airline}
+	}
+	sig, err := base64.StdEncoding.DecodeString(result.Security.Data)
+	if err != nil {
+		return result, &SecurityError{Err: ErrInvalidSecurityData, Airline: airline}
+	}
+
+	digest := sha256.Sum256([]byte(result.signed))
+	valid := false
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(k, digest[:], sig)
+	case *dsa.PublicKey:
+		var ds dsaSignature
+		if rest, err := asn1.Unmarshal(sig, &ds); err == nil && len(rest) == 0 {
+			valid = dsa.Verify(k, dsaDigest(k, digest[:]), ds.R, ds.S)
+		}
+	}
+	if !valid {
+		return result, &SecurityError{Err: ErrInvalidSignature, Airline: airline}
+	}
+	return result, nil
+}
+
+type dsaSignature struct {
+	R, S *big.Int
+}
+
+// dsaDigest keeps the leftmost bytes of digest that fit the subgroup
+// order, as FIPS 186-4 section 4.6 requires and crypto/dsa leaves to
+// the caller. A SHA-256 digest is cut to 20 bytes for an N=160 key.
+func dsaDigest(k *dsa.PublicKey, digest []byte) []byte {
+	if n := (k.Q.BitLen() + 7) / 8; len(digest) > n {
+		return digest[:n]
+	}
+	return digest
+}
+
+// parseDSAPrivateKey reads the OpenSSL "DSA PRIVATE KEY" structure.
+func parseDSAPrivateKey(der []byte) (*dsa.PrivateKey, error) {
+	var k struct {
+		Version       int
+		P, Q, G, Y, X *big.Int
+	}
+	rest, err := asn1.Unmarshal(der, &k)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("trailing data after DSA private key")
+	}
+	return &dsa.PrivateKey{
+		PublicKey: dsa.PublicKey{
+			Parameters: dsa.Parameters{P: k.P, Q: k.Q, G: k.G},
+			Y:          k.Y,
+		},
+		X: k.X,
+	}, nil
+}
@@ -0,0 +1,140 @@
+package qbcbp
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func newTestKeyStore(t *testing.T, airline string, key interface{}) *KeyStore {
+	t.Helper()
+	s, err := NewKeyStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddPrivateKey(airline, key); err != nil {
+		t.Fatalf("AddPrivateKey() error = %v", err)
+	}
+	return s
+}
+
+func TestSignVerify(t *testing.T) {
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	var dsaKey dsa.PrivateKey
+	if err := dsa.GenerateParameters(&dsaKey.Parameters, rand.Reader, dsa.L1024N160); err != nil {
+		t.Fatal(err)
+	}
+	if err := dsa.GenerateKey(&dsaKey, rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  interface{}
+	}{
+		{"ECDSA P-256", p256},
+		{"ECDSA P-384", p384},
+		{"DSA", &dsaKey},
+	}
+	pass := encodeTestPass(t, "2026-10-18")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestKeyStore(t, "GA", tt.key)
+			signed, err := s.Sign(pass, "GA")
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if _, err := s.Verify(signed); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSignVerifyRejects(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s := newTestKeyStore(t, "GA", key)
+	pass := encodeTestPass(t, "2026-10-18")
+	signed, err := s.Sign(pass, "GA")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	if _, err := s.Sign(signed, "GA"); !errors.Is(err, ErrInvalidSecurityData) {
+		t.Errorf("Sign() of a signed pass error = %v, want %v", err, ErrInvalidSecurityData)
+	}
+	if _, err := s.Sign(pass+"XYZ", "GA"); !errors.Is(err, ErrInvalidSecurityData) {
+		t.Errorf("Sign() with trailing data error = %v, want %v", err, ErrInvalidSecurityData)
+	}
+	if _, err := s.Sign(pass, "QZ"); !errors.Is(err, ErrUnknownSigner) {
+		t.Errorf("Sign() for an unknown airline error = %v, want %v", err, ErrUnknownSigner)
+	}
+
+	forged, err := newTestKeyStore(t, "GA", other).Sign(pass, "GA")
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	tampered := []byte(signed)
+	tampered[len("M1ERIKSSON/ANNA")] = 'X'
+
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"trailing data", signed + "XYZ", ErrInvalidSecurityData},
+		{"unsigned", pass, ErrNoSecurityData},
+		{"signed by another key", forged, ErrInvalidSignature},
+		{"tampered", string(tampered), ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Verify(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBCBPNegativeSecuritySize(t *testing.T) {
+	pass := encodeTestPass(t, "2026-10-18")
+	if _, err := ParseBCBP(pass + "^1-1"); err == nil {
+		t.Error("ParseBCBP() error = nil, want an error")
+	}
+}
+
+func TestVerifyExternalDSASignature(t *testing.T) {
+	// testdata/dsa_l1024_n160.pem is an L=1024, N=160 key generated with
+	// OpenSSL 3.0, which also made the signature over mandatoryOnly:
+	//
+	//	openssl dgst -sha256 -sign key.pem -out sig.der pass.txt
+	const signature = "MC0CFQCe4KI4rBwYJ7bvfzlGlZC1wAw9aAIUONfo09fnY/asGXq4ennDexYMhoU="
+	s, err := NewKeyStore(WithKeyFile("AC", "testdata/dsa_l1024_n160.pem"))
+	if err != nil {
+		t.Fatalf("NewKeyStore() error = %v", err)
+	}
+	tampered := []byte(mandatoryOnly)
+	tampered[len("M1DESMARAIS/LU")] = 'K'
+
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"signed by OpenSSL", mandatoryOnly + "^1" + fmt.Sprintf("%02X", len(signature)) + signature, nil},
+		{"tampered", string(tampered) + "^1" + fmt.Sprintf("%02X", len(signature)) + signature, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Verify(tt.data, WithReferenceTime(sampleIssue)); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
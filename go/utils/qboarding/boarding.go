@@ -0,0 +1,380 @@
+package qboarding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+	"github.com/mhaqqiw/sdk/go/utils/qbcbp"
+)
+
+// Rule names a boarding eligibility check.
+type Rule string
+
+const (
+	// RuleFormat fails passes that cannot be parsed or carry an invalid
+	// format code, seat or check-in sequence number.
+	RuleFormat Rule = "format"
+	// RuleAirport fails passes with no leg departing from the gate
+	// airport.
+	RuleAirport Rule = "airport"
+	// RuleFlight fails passes for a flight not boarding at the gate.
+	RuleFlight Rule = "flight"
+	// RuleDate fails passes dated another day than the flight, or than
+	// today at the gate when the flight is unknown.
+	RuleDate Rule = "date"
+	// RuleBoarding fails passes presented outside the boarding window of
+	// the flight.
+	RuleBoarding Rule = "boarding"
+)
+
+// defaultStatuses are the status and event reported by each rule. The
+// configuration may override the status.
+var defaultStatuses = map[Rule][2]string{
+	RuleFormat:   {qconstant.PassengerStatusInvalidBCBP, ""},
+	RuleAirport:  {qconstant.PassengerStatusBoardNo, qconstant.PassengerGateNotMatch},
+	RuleFlight:   {qconstant.PassengerStatusBoardNo, qconstant.PassengerGateNotMatch},
+	RuleDate:     {qconstant.PassengerStatusBoardNo, qconstant.PassengerCannotBoard},
+	RuleBoarding: {qconstant.PassengerStatusBoardNo, qconstant.PassengerCannotBoard},
+}
+
+// RuleConfig enables a rule. Status replaces the default status of the
+// rule and must be a qconstant PassengerStatus, and Stop ends the
+// evaluation when the rule fails.
+type RuleConfig struct {
+	Rule   Rule   `json:"rule"`
+	Status string `json:"status"`
+	Stop   bool   `json:"stop"`
+}
+
+// Config lists the rules to run, in order. The boarding window of a
+// flight without explicit boarding times opens and closes the given
+// number of minutes before departure.
+type Config struct {
+	Rules                 []RuleConfig `json:"rules"`
+	BoardingOpensMinutes  int          `json:"boarding_opens_minutes"`
+	BoardingClosesMinutes int          `json:"boarding_closes_minutes"`
+}
+
+// DefaultConfig runs every rule, stopping after an unreadable pass, and
+// opens boarding 60 minutes before departure until 15 minutes before.
+var DefaultConfig = Config{
+	Rules: []RuleConfig{
+		{Rule: RuleFormat, Stop: true},
+		{Rule: RuleAirport},
+		{Rule: RuleFlight},
+		{Rule: RuleDate},
+		{Rule: RuleBoarding},
+	},
+	BoardingOpensMinutes:  60,
+	BoardingClosesMinutes: 15,
+}
+
+// LoadConfig reads a JSON Config from path.
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(file, &config); err != nil {
+		return config, err
+	}
+	return config, config.validate()
+}
+
+func (c Config) validate() error {
+	if len(c.Rules) == 0 {
+		return errors.New("no boarding rules configured")
+	}
+	for _, rule := range c.Rules {
+		if _, ok := defaultStatuses[rule.Rule]; !ok {
+			return fmt.Errorf("unknown boarding rule %q", rule.Rule)
+		}
+		if rule.Status != "" && !strings.HasPrefix(rule.Status, "PassengerStatus") {
+			return fmt.Errorf("boarding rule %q: %q is not a passenger status", rule.Rule, rule.Status)
+		}
+	}
+	if c.BoardingOpensMinutes < c.BoardingClosesMinutes {
+		return errors.New("boarding opens after it closes")
+	}
+	return nil
+}
+
+// Gate is the context a pass is presented in: the departure airport of
+// the gate, the IANA time zone of the airport, defaulting to the clock's,
+// and the flights boarding there.
+type Gate struct {
+	ID       string   `json:"id"`
+	Airport  string   `json:"airport"`
+	Timezone string   `json:"timezone"`
+	Flights  []Flight `json:"flights"`
+}
+
+// Flight is a flight boarding at a gate. Date is the local departure
+// date, "2006-01-02", defaulting to the date of Departure in the time
+// zone of the gate; it tells apart flights with the same number on
+// consecutive days. BoardingOpen and BoardingClose default to the
+// configured window around Departure; with neither set the boarding
+// rule passes.
+type Flight struct {
+	Airline       string    `json:"airline"`
+	FlightNumber  string    `json:"flight_number"`
+	Date          string    `json:"date"`
+	Departure     time.Time `json:"departure"`
+	BoardingOpen  time.Time `json:"boarding_open"`
+	BoardingClose time.Time `json:"boarding_close"`
+}
+
+// Finding is a failed rule. Status is the qconstant passenger status
+// reported and Event the matching qconstant device event, empty for an
+// unreadable pass.
+type Finding struct {
+	Rule   Rule   `json:"rule"`
+	Status string `json:"status"`
+	Event  string `json:"event"`
+	Reason string `json:"reason"`
+}
+
+// Result is the outcome of an evaluation. Findings are in rule order.
+// Leg is the index of the leg checked, -1 when the pass could not be
+// read.
+type Result struct {
+	Eligible bool      `json:"eligible"`
+	Leg      int       `json:"leg"`
+	Findings []Finding `json:"findings"`
+}
+
+// Status returns the status of the first finding, or
+// qconstant.PassengerStatusMatch when the passenger may board.
+func (r Result) Status() string {
+	if len(r.Findings) == 0 {
+		return qconstant.PassengerStatusMatch
+	}
+	return r.Findings[0].Status
+}
+
+// Event returns the event of the first finding, or
+// qconstant.PassengerMatch when the passenger may board.
+func (r Result) Event() string {
+	if len(r.Findings) == 0 {
+		return qconstant.PassengerMatch
+	}
+	return r.Findings[0].Event
+}
+
+// Engine evaluates boarding passes against a gate.
+type Engine struct {
+	config Config
+	now    func() time.Time
+}
+
+type Option func(*Engine)
+
+// WithConfig sets the rules to run. Defaults to DefaultConfig.
+func WithConfig(config Config) Option {
+	return func(e *Engine) {
+		e.config = config
+	}
+}
+
+// WithClock sets the clock boarding windows and dates are checked
+// against. Defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(e *Engine) {
+		e.now = now
+	}
+}
+
+func NewEngine(opts ...Option) (*Engine, error) {
+	e := &Engine{
+		config: DefaultConfig,
+		now:    time.Now,
+	}
+	for _, optFunc := range opts {
+		optFunc(e)
+	}
+	if err := e.config.validate(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Evaluate parses a raw boarding pass and checks it against gate.
+func (e *Engine) Evaluate(data string, gate Gate) Result {
+	now := e.now()
+	// Flight dates resolve to the nearest year whatever the window, the
+	// date rule deciding whether the pass is for the right day. A half
+	// year window only keeps them from being flagged.
+	pass, err := qbcbp.ParseBCBP(data, qbcbp.WithReferenceTime(now), qbcbp.WithDateWindow(183, 183))
+	if err != nil {
+		// An unreadable pass is never eligible, so it is reported even
+		// when the format rule is not configured.
+		rule := RuleConfig{Rule: RuleFormat}
+		for _, r := range e.config.Rules {
+			if r.Rule == RuleFormat {
+				rule = r
+			}
+		}
+		return Result{Leg: -1, Findings: []Finding{e.finding(rule, err.Error())}}
+	}
+	return e.evaluate(pass, gate, now)
+}
+
+// EvaluateBCBP checks a parsed boarding pass against gate.
+func (e *Engine) EvaluateBCBP(pass qbcbp.BCBP, gate Gate) Result {
+	return e.evaluate(pass, gate, e.now())
+}
+
+func (e *Engine) evaluate(pass qbcbp.BCBP, gate Gate, now time.Time) Result {
+	var result Result
+	if len(pass.Legs) == 0 {
+		pass.Legs = []qbcbp.BCBPLeg{{
+			From: pass.From, To: pass.To, Airline: pass.Airline, FlightNumber: pass.FlightNumber,
+			Date: pass.Date, Class: pass.Class, Seat: pass.Seat, Sequence: pass.Sequence,
+		}}
+	}
+
+	if gate.Timezone != "" {
+		if loc, err := time.LoadLocation(gate.Timezone); err == nil {
+			now = now.In(loc)
+		}
+	}
+
+	// The leg checked is the one departing from the gate, preferably on
+	// a flight boarding there.
+	var flight *Flight
+	for i, leg := range pass.Legs {
+		if !strings.EqualFold(leg.From, gate.Airport) {
+			continue
+		}
+		if f := gate.flight(leg, now.Location()); f != nil {
+			result.Leg, flight = i, f
+			break
+		}
+		if !strings.EqualFold(pass.Legs[result.Leg].From, gate.Airport) {
+			result.Leg = i
+		}
+	}
+	leg := pass.Legs[result.Leg]
+
+	for _, rule := range e.config.Rules {
+		reason := ""
+		switch rule.Rule {
+		case RuleFormat:
+			reason = checkFormat(pass, leg)
+		case RuleAirport:
+			if !strings.EqualFold(leg.From, gate.Airport) {
+				reason = fmt.Sprintf("pass departs from %s, gate is at %s", leg.From, gate.Airport)
+			}
+		case RuleFlight:
+			if flight == nil {
+				reason = fmt.Sprintf("flight %s%s does not board at gate %s", leg.Airline, leg.FlightNumber, gate.ID)
+			}
+		case RuleDate:
+			date := now.Format("2006-01-02")
+			if flight != nil && flight.date(now.Location()) != "" {
+				date = flight.date(now.Location())
+			}
+			if leg.Date != date {
+				reason = fmt.Sprintf("pass is dated %s, flight departs %s", leg.Date, date)
+			}
+		case RuleBoarding:
+			reason = e.checkBoarding(flight, now)
+		}
+		if reason == "" {
+			continue
+		}
+		result.Findings = append(result.Findings, e.finding(rule, reason))
+		if rule.Stop {
+			break
+		}
+	}
+	result.Eligible = len(result.Findings) == 0
+	return result
+}
+
+func (e *Engine) finding(rule RuleConfig, reason string) Finding {
+	status := rule.Status
+	if status == "" {
+		status = defaultStatuses[rule.Rule][0]
+	}
+	return Finding{Rule: rule.Rule, Status: status, Event: defaultStatuses[rule.Rule][1], Reason: reason}
+}
+
+func (e *Engine) checkBoarding(flight *Flight, now time.Time) string {
+	if flight == nil {
+		return ""
+	}
+	open, closing := flight.BoardingOpen, flight.BoardingClose
+	if !flight.Departure.IsZero() {
+		if open.IsZero() {
+			open = flight.Departure.Add(-time.Duration(e.config.BoardingOpensMinutes) * time.Minute)
+		}
+		if closing.IsZero() {
+			closing = flight.Departure.Add(-time.Duration(e.config.BoardingClosesMinutes) * time.Minute)
+		}
+	}
+	switch {
+	case !open.IsZero() && now.Before(open):
+		return "boarding opens at " + open.In(now.Location()).Format("15:04")
+	case !closing.IsZero() && now.After(closing):
+		return "boarding closed at " + closing.In(now.Location()).Format("15:04")
+	}
+	return ""
+}
+
+func checkFormat(pass qbcbp.BCBP, leg qbcbp.BCBPLeg) string {
+	if pass.FormatCode != "" && pass.FormatCode != "M" {
+		return fmt.Sprintf("unsupported format code %q", pass.FormatCode)
+	}
+	if _, err := leg.SeatNumber(); err != nil {
+		return err.Error()
+	}
+	if _, err := leg.CheckinSequence(); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// flight returns the flight of leg boarding at the gate, loc being the
+// time zone of the gate. A flight with the same number on another day is
+// returned only when none departs on the date of the leg, for the date
+// rule to report it.
+func (g Gate) flight(leg qbcbp.BCBPLeg, loc *time.Location) *Flight {
+	var found *Flight
+	for i, f := range g.Flights {
+		if !strings.EqualFold(f.Airline, leg.Airline) || !sameFlightNumber(f.FlightNumber, leg.FlightNumber) {
+			continue
+		}
+		if f.date(loc) == leg.Date {
+			return &g.Flights[i]
+		}
+		if found == nil {
+			found = &g.Flights[i]
+		}
+	}
+	return found
+}
+
+// date returns the local departure date of the flight in loc, empty when
+// neither Date nor Departure is set.
+func (f Flight) date(loc *time.Location) string {
+	switch {
+	case f.Date != "":
+		return f.Date
+	case !f.Departure.IsZero():
+		return f.Departure.In(loc).Format("2006-01-02")
+	}
+	return ""
+}
+
+func sameFlightNumber(a, b string) bool {
+	a = strings.TrimLeft(strings.TrimSpace(a), "0")
+	b = strings.TrimLeft(strings.TrimSpace(b), "0")
+	return strings.EqualFold(a, b)
+}
@@ -0,0 +1,237 @@
+package qboarding
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+	"github.com/mhaqqiw/sdk/go/utils/qbcbp"
+)
+
+// testNow is 09:30 at Jakarta.
+var testNow = time.Date(2026, 10, 18, 2, 30, 0, 0, time.UTC)
+
+func encodePass(t *testing.T, from, flightNumber, date, seat string) string {
+	t.Helper()
+	pass, err := qbcbp.EncodeBCBP(qbcbp.BCBP{
+		LastName:  "ERIKSSON",
+		FirstName: "ANNA",
+		Legs: []qbcbp.BCBPLeg{{
+			PnrCode:      "ABC123",
+			From:         from,
+			To:           "DPS",
+			Airline:      "GA",
+			FlightNumber: flightNumber,
+			Date:         date,
+			Class:        "Y",
+			Seat:         seat,
+			Sequence:     "1",
+			Status:       "1",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("EncodeBCBP() error = %v", err)
+	}
+	return pass
+}
+
+func testGate(flight Flight) Gate {
+	return Gate{ID: "D5", Airport: "CGK", Timezone: "Asia/Jakarta", Flights: []Flight{flight}}
+}
+
+func TestEvaluate(t *testing.T) {
+	departure := time.Date(2026, 10, 18, 10, 15, 0, 0, time.FixedZone("WIB", 7*3600))
+	dated := Flight{Airline: "GA", FlightNumber: "404", Date: "2026-10-18", Departure: departure}
+	undated := Flight{Airline: "GA", FlightNumber: "0404", Departure: departure}
+	// Departing at 00:30 at Jakarta, boarding from 23:30 the day before.
+	midnight := Flight{Airline: "GA", FlightNumber: "404", Departure: time.Date(2026, 10, 17, 17, 30, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name   string
+		pass   string
+		flight Flight
+		now    time.Time
+		rules  []Rule
+		leg    int
+	}{
+		{"eligible", encodePass(t, "CGK", "404", "2026-10-18", "12A"), dated, testNow, nil, 0},
+		{"flight number with leading zero", encodePass(t, "CGK", "404", "2026-10-18", "12A"), undated, testNow, nil, 0},
+		{"yesterday's pass", encodePass(t, "CGK", "404", "2026-10-17", "12A"), dated, testNow, []Rule{RuleDate}, 0},
+		{"pass from three days ago", encodePass(t, "CGK", "404", "2026-10-15", "12A"), dated, testNow, []Rule{RuleDate}, 0},
+		{"date from departure after midnight", encodePass(t, "CGK", "404", "2026-10-18", "12A"), midnight,
+			time.Date(2026, 10, 17, 16, 50, 0, 0, time.UTC), nil, 0},
+		{"another airport", encodePass(t, "SUB", "404", "2026-10-18", "12A"), dated, testNow, []Rule{RuleAirport, RuleFlight}, 0},
+		{"another flight", encodePass(t, "CGK", "406", "2026-10-18", "12A"), dated, testNow, []Rule{RuleFlight}, 0},
+		{"boarding not open", encodePass(t, "CGK", "404", "2026-10-18", "12A"), dated, testNow.Add(-time.Hour), []Rule{RuleBoarding}, 0},
+		{"boarding closed", encodePass(t, "CGK", "404", "2026-10-18", "12A"), dated, testNow.Add(40 * time.Minute), []Rule{RuleBoarding}, 0},
+		{"invalid seat stops", encodePass(t, "SUB", "404", "2026-10-18", "A12"), dated, testNow, []Rule{RuleFormat}, 0},
+		{"unreadable", "M1ERIKSSON", dated, testNow, []Rule{RuleFormat}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEngine(WithClock(func() time.Time { return tt.now }))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := e.Evaluate(tt.pass, testGate(tt.flight))
+			var rules []Rule
+			for _, f := range got.Findings {
+				rules = append(rules, f.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("Evaluate() findings = %v, want %v", got.Findings, tt.rules)
+			}
+			if got.Eligible != (len(tt.rules) == 0) || got.Leg != tt.leg {
+				t.Errorf("Evaluate() eligible = %v, leg = %d, want %v, %d", got.Eligible, got.Leg, len(tt.rules) == 0, tt.leg)
+			}
+		})
+	}
+}
+
+func TestEvaluateRuleOrder(t *testing.T) {
+	// Dated the day before, at another airport, on a flight not boarding.
+	pass := encodePass(t, "SUB", "406", "2026-10-17", "12A")
+	gate := testGate(Flight{Airline: "GA", FlightNumber: "404", Date: "2026-10-18"})
+
+	tests := []struct {
+		name   string
+		rules  []RuleConfig
+		want   []Rule
+		status string
+		event  string
+	}{
+		{"configured order", []RuleConfig{{Rule: RuleDate}, {Rule: RuleAirport}, {Rule: RuleFlight}},
+			[]Rule{RuleDate, RuleAirport, RuleFlight}, qconstant.PassengerStatusBoardNo, qconstant.PassengerCannotBoard},
+		{"reversed", []RuleConfig{{Rule: RuleFlight}, {Rule: RuleAirport}, {Rule: RuleDate}},
+			[]Rule{RuleFlight, RuleAirport, RuleDate}, qconstant.PassengerStatusBoardNo, qconstant.PassengerGateNotMatch},
+		{"stop", []RuleConfig{{Rule: RuleAirport, Stop: true}, {Rule: RuleFlight}, {Rule: RuleDate}},
+			[]Rule{RuleAirport}, qconstant.PassengerStatusBoardNo, qconstant.PassengerGateNotMatch},
+		{"stop on a passing rule", []RuleConfig{{Rule: RuleFormat, Stop: true}, {Rule: RuleDate}},
+			[]Rule{RuleDate}, qconstant.PassengerStatusBoardNo, qconstant.PassengerCannotBoard},
+		{"status override", []RuleConfig{{Rule: RuleDate, Status: qconstant.PassengerStatusRevoked, Stop: true}, {Rule: RuleAirport}},
+			[]Rule{RuleDate}, qconstant.PassengerStatusRevoked, qconstant.PassengerCannotBoard},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEngine(
+				WithConfig(Config{Rules: tt.rules, BoardingOpensMinutes: 60, BoardingClosesMinutes: 15}),
+				WithClock(func() time.Time { return testNow }),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := e.Evaluate(pass, gate)
+			var rules []Rule
+			for _, f := range got.Findings {
+				rules = append(rules, f.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.want) {
+				t.Errorf("Evaluate() findings = %v, want %v", rules, tt.want)
+			}
+			if got.Status() != tt.status {
+				t.Errorf("Status() = %s, want %s", got.Status(), tt.status)
+			}
+			if got.Event() != tt.event {
+				t.Errorf("Event() = %s, want %s", got.Event(), tt.event)
+			}
+		})
+	}
+}
+
+func TestEvaluateStatus(t *testing.T) {
+	e, err := NewEngine(WithClock(func() time.Time { return testNow }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gate := testGate(Flight{Airline: "GA", FlightNumber: "404", Date: "2026-10-18"})
+
+	tests := []struct {
+		name   string
+		pass   string
+		status string
+		event  string
+	}{
+		{"eligible", encodePass(t, "CGK", "404", "2026-10-18", "12A"), qconstant.PassengerStatusMatch, qconstant.PassengerMatch},
+		{"unreadable", "M1ERIKSSON", qconstant.PassengerStatusInvalidBCBP, ""},
+		{"another flight", encodePass(t, "CGK", "406", "2026-10-18", "12A"), qconstant.PassengerStatusBoardNo, qconstant.PassengerGateNotMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.Evaluate(tt.pass, gate)
+			if got.Status() != tt.status || got.Event() != tt.event {
+				t.Errorf("Evaluate() status = %s, event = %s, want %s, %s", got.Status(), got.Event(), tt.status, tt.event)
+			}
+		})
+	}
+}
+
+func TestEvaluateConsecutiveDays(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	tests := []struct {
+		name    string
+		flights []Flight
+	}{
+		{"dated", []Flight{
+			{Airline: "GA", FlightNumber: "404", Date: "2026-10-19", Departure: time.Date(2026, 10, 19, 10, 15, 0, 0, wib)},
+			{Airline: "GA", FlightNumber: "404", Date: "2026-10-18", Departure: time.Date(2026, 10, 18, 10, 15, 0, 0, wib)},
+		}},
+		// Departures in UTC fall on the day before at 00:30 at Jakarta, so
+		// the dates only match in the time zone of the gate.
+		{"departure only", []Flight{
+			{Airline: "GA", FlightNumber: "404", Departure: time.Date(2026, 10, 18, 17, 30, 0, 0, time.UTC)},
+			{Airline: "GA", FlightNumber: "404", Departure: time.Date(2026, 10, 17, 17, 30, 0, 0, time.UTC)},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate := Gate{ID: "D5", Airport: "CGK", Timezone: "Asia/Jakarta", Flights: tt.flights}
+			for i, date := range []string{"2026-10-19", "2026-10-18"} {
+				// Boarding for each flight, 30 minutes before departure.
+				now := tt.flights[i].Departure.Add(-30 * time.Minute)
+				e, err := NewEngine(WithClock(func() time.Time { return now }))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := e.Evaluate(encodePass(t, "CGK", "404", date, "12A"), gate)
+				if !got.Eligible {
+					t.Errorf("Evaluate() of the %s pass findings = %v, want eligible", date, got.Findings)
+				}
+			}
+
+			// A day early, the pass for the next day is refused by the
+			// boarding window of its own flight, not by the date rule.
+			e, err := NewEngine(WithClock(func() time.Time { return tt.flights[1].Departure.Add(-30 * time.Minute) }))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := e.Evaluate(encodePass(t, "CGK", "404", "2026-10-19", "12A"), gate)
+			var rules []Rule
+			for _, f := range got.Findings {
+				rules = append(rules, f.Rule)
+			}
+			if want := []Rule{RuleBoarding}; !reflect.DeepEqual(rules, want) {
+				t.Errorf("Evaluate() of the next day's pass findings = %v, want %v", got.Findings, want)
+			}
+		})
+	}
+}
+
+func TestNewEngineInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"no rules", Config{}},
+		{"unknown rule", Config{Rules: []RuleConfig{{Rule: "weather"}}}},
+		{"window reversed", Config{Rules: []RuleConfig{{Rule: RuleDate}}, BoardingOpensMinutes: 10, BoardingClosesMinutes: 20}},
+		{"event as status", Config{Rules: []RuleConfig{{Rule: RuleDate, Status: qconstant.PassengerCannotBoard}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEngine(WithConfig(tt.config)); err == nil {
+				t.Error("NewEngine() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package qiata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	// Airport time zones must load on hosts and containers without a
+	// system time zone database.
+	_ "time/tzdata"
+)
+
+var (
+	ErrUnknownAirport = errors.New("unknown IATA airport code")
+	ErrUnknownAirline = errors.New("unknown IATA airline designator")
+	ErrNotInitialized = errors.New("IATA registry not initialized")
+)
+
+// Airport is an IATA location identifier with its IANA time zone.
+// Country is the ICAO 9303 code of the country, as printed in passport
+// MRZs and used by qpolicy: "IDN", or "D" for Germany.
+type Airport struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	City     string `json:"city"`
+	Country  string `json:"country"`
+	Timezone string `json:"timezone"`
+}
+
+// Location loads the time zone of the airport.
+func (a Airport) Location() (*time.Location, error) {
+	return time.LoadLocation(a.Timezone)
+}
+
+// Airline is an IATA airline designator. NumericCode is the three digit
+// accounting code printed on tickets, empty when the airline has none.
+// Country is an ICAO 9303 code as for Airport.
+type Airline struct {
+	Code        string `json:"code"`
+	ICAO        string `json:"icao"`
+	NumericCode string `json:"numeric_code"`
+	Name        string `json:"name"`
+	Country     string `json:"country"`
+}
+
+// Registry holds the airports and airlines loaded from airport.json and
+// airline.json. It is safe for concurrent use and may be reloaded while
+// in use.
+type Registry struct {
+	mu       sync.RWMutex
+	airports map[string]Airport
+	airlines map[string]Airline
+}
+
+type option struct {
+	sdkPath string
+}
+
+type Option func(*option)
+
+// WithPath sets the directory holding airport.json and airline.json.
+// Defaults to files/etc/sdk of the SDK.
+func WithPath(path string) Option {
+	return func(o *option) {
+		o.sdkPath = path
+	}
+}
+
+var defaultRegistry = &Registry{}
+
+// Init loads the default registry used by LookupAirport, LookupAirline
+// and Default. It may be called again to pick up updated files.
+func Init(opts ...Option) error {
+	return defaultRegistry.Load(opts...)
+}
+
+// Default returns the registry loaded by Init.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// NewRegistry loads a registry independent of the default one.
+func NewRegistry(opts ...Option) (*Registry, error) {
+	r := &Registry{}
+	if err := r.Load(opts...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Load reads the registry files and replaces the content of r. On error
+// r is left unchanged.
+func (r *Registry) Load(opts ...Option) error {
+	_, b, _, _ := runtime.Caller(0)
+	opt := &option{
+		sdkPath: filepath.Join(filepath.Dir(b), "../../../files/etc/sdk/"),
+	}
+
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+
+	airportFile, err := os.ReadFile(filepath.Join(opt.sdkPath, "airport.json"))
+	if err != nil {
+		return err
+	}
+	airportList := make([]Airport, 0)
+	err = json.Unmarshal(airportFile, &airportList)
+	if err != nil {
+		return err
+	}
+	airports := make(map[string]Airport, len(airportList))
+	for _, airport := range airportList {
+		airport.Code = strings.ToUpper(airport.Code)
+		if _, err := airport.Location(); err != nil {
+			return fmt.Errorf("airport %s: %w", airport.Code, err)
+		}
+		airports[airport.Code] = airport
+	}
+
+	airlineFile, err := os.ReadFile(filepath.Join(opt.sdkPath, "airline.json"))
+	if err != nil {
+		return err
+	}
+	airlineList := make([]Airline, 0)
+	err = json.Unmarshal(airlineFile, &airlineList)
+	if err != nil {
+		return err
+	}
+	airlines := make(map[string]Airline, len(airlineList))
+	for _, airline := range airlineList {
+		airline.Code = strings.ToUpper(airline.Code)
+		airlines[airline.Code] = airline
+	}
+
+	r.mu.Lock()
+	r.airports, r.airlines = airports, airlines
+	r.mu.Unlock()
+	return nil
+}
+
+// Airport returns the airport with the three letter code.
+func (r *Registry) Airport(code string) (Airport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.airports == nil {
+		return Airport{}, ErrNotInitialized
+	}
+	airport, ok := r.airports[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Airport{}, fmt.Errorf("%w: %q", ErrUnknownAirport, code)
+	}
+	return airport, nil
+}
+
+// Airline returns the airline with the two character designator, or the
+// three letter ICAO code.
+func (r *Registry) Airline(code string) (Airline, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.airlines == nil {
+		return Airline{}, ErrNotInitialized
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if airline, ok := r.airlines[code]; ok {
+		return airline, nil
+	}
+	if len(code) == 3 {
+		for _, airline := range r.airlines {
+			if airline.ICAO == code {
+				return airline, nil
+			}
+		}
+	}
+	return Airline{}, fmt.Errorf("%w: %q", ErrUnknownAirline, code)
+}
+
+// LookupAirport returns an airport of the default registry.
+func LookupAirport(code string) (Airport, error) {
+	return defaultRegistry.Airport(code)
+}
+
+// LookupAirline returns an airline of the default registry.
+func LookupAirline(code string) (Airline, error) {
+	return defaultRegistry.Airline(code)
+}
@@ -0,0 +1,169 @@
+package qiata
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mhaqqiw/sdk/go/utils/qmrz"
+)
+
+func writeRegistry(t *testing.T, airports, airlines string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{"airport.json": airports, "airline.json": airlines} {
+		if content == "" {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestNewRegistry(t *testing.T) {
+	r, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	if len(r.airports) == 0 || len(r.airlines) == 0 {
+		t.Fatalf("NewRegistry() loaded %d airports and %d airlines", len(r.airports), len(r.airlines))
+	}
+	for code, airport := range r.airports {
+		if !qmrz.IsValidCountryCode(airport.Country) || len(airport.Country) == 2 {
+			t.Errorf("airport %s country %q is not an ICAO 9303 code", code, airport.Country)
+		}
+	}
+	for code, airline := range r.airlines {
+		if !qmrz.IsValidCountryCode(airline.Country) || len(airline.Country) == 2 {
+			t.Errorf("airline %s country %q is not an ICAO 9303 code", code, airline.Country)
+		}
+	}
+	if fra, err := r.Airport("FRA"); err == nil && fra.Country != "D" {
+		t.Errorf("FRA country = %q, want D", fra.Country)
+	}
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r, err := NewRegistry()
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	airports := []struct {
+		code    string
+		want    string
+		wantErr error
+	}{
+		{"CGK", "Asia/Jakarta", nil},
+		{" cgk ", "Asia/Jakarta", nil},
+		{"AKL", "Pacific/Auckland", nil},
+		{"QQQ", "", ErrUnknownAirport},
+		{"", "", ErrUnknownAirport},
+	}
+	for _, tt := range airports {
+		t.Run("airport "+tt.code, func(t *testing.T) {
+			got, err := r.Airport(tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Airport() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.Timezone != tt.want {
+				t.Errorf("Airport() timezone = %q, want %q", got.Timezone, tt.want)
+			}
+		})
+	}
+	airlines := []struct {
+		code    string
+		want    string
+		wantErr error
+	}{
+		{"GA", "GA", nil},
+		{"ga", "GA", nil},
+		{"GIA", "GA", nil},
+		{"QQ", "", ErrUnknownAirline},
+		{"QQQ", "", ErrUnknownAirline},
+		{"", "", ErrUnknownAirline},
+	}
+	for _, tt := range airlines {
+		t.Run("airline "+tt.code, func(t *testing.T) {
+			got, err := r.Airline(tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Airline() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.Code != tt.want {
+				t.Errorf("Airline() = %q, want %q", got.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotInitialized(t *testing.T) {
+	var r Registry
+	if _, err := r.Airport("CGK"); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("Airport() error = %v, want %v", err, ErrNotInitialized)
+	}
+	if _, err := r.Airline("GA"); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("Airline() error = %v, want %v", err, ErrNotInitialized)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	const (
+		airports = `[{"code": "cgk", "name": "Soekarno-Hatta", "city": "Jakarta", "country": "IDN", "timezone": "Asia/Jakarta"}]`
+		airlines = `[{"code": "ga", "icao": "GIA", "numeric_code": "126", "name": "Garuda Indonesia", "country": "IDN"}]`
+	)
+	r, err := NewRegistry(WithPath(writeRegistry(t, airports, airlines)))
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	if _, err := r.Airport("CGK"); err != nil {
+		t.Errorf("Airport() error = %v", err)
+	}
+	if _, err := r.Airport("DPS"); !errors.Is(err, ErrUnknownAirport) {
+		t.Errorf("Airport() of an airport not in the file error = %v, want %v", err, ErrUnknownAirport)
+	}
+	if _, err := r.Airline("GA"); err != nil {
+		t.Errorf("Airline() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		airports string
+		airlines string
+	}{
+		{"missing airports", "", airlines},
+		{"missing airlines", airports, ""},
+		{"malformed airports", `[{"code": `, airlines},
+		{"malformed airlines", airports, `{}`},
+		{"unknown time zone", `[{"code": "DPS", "timezone": "Asia/Denpasar"}]`, airlines},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Load(WithPath(writeRegistry(t, tt.airports, tt.airlines))); err == nil {
+				t.Error("Load() error = nil, want an error")
+			}
+			if _, err := r.Airport("CGK"); err != nil {
+				t.Errorf("Airport() after failed Load() error = %v", err)
+			}
+		})
+	}
+	if _, err := NewRegistry(WithPath(t.TempDir())); err == nil {
+		t.Error("NewRegistry() of an empty directory error = nil, want an error")
+	}
+}
+
+func TestInit(t *testing.T) {
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if Default() == nil {
+		t.Fatal("Default() = nil")
+	}
+	if _, err := LookupAirport("CGK"); err != nil {
+		t.Errorf("LookupAirport() error = %v", err)
+	}
+	if _, err := LookupAirline("GA"); err != nil {
+		t.Errorf("LookupAirline() error = %v", err)
+	}
+}
@@ -0,0 +1,291 @@
+package qjourney
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+)
+
+var (
+	ErrUnknownStatus     = errors.New("unknown passenger status")
+	ErrUnknownEvent      = errors.New("unknown passenger event")
+	ErrIllegalTransition = errors.New("illegal passenger status transition")
+	ErrTerminal          = errors.New("passenger journey already ended")
+)
+
+// Status is a passenger status. Its values are the qconstant
+// PassengerStatus strings, so it converts to and from them directly.
+type Status string
+
+// StatusNone is the status of a passenger nothing has happened to yet.
+const StatusNone Status = ""
+
+const (
+	StatusMatch               Status = qconstant.PassengerStatusMatch
+	StatusNoMatch             Status = qconstant.PassengerStatusNoMatch
+	StatusPresent             Status = qconstant.PassengerStatusPresent
+	StatusCheckin             Status = qconstant.PassengerStatusCheckin
+	StatusCheckinFailed       Status = qconstant.PassengerStatusCheckinFailed
+	StatusLeaveGate           Status = qconstant.PassengerStatusLeaveGate
+	StatusUpdatePassenger     Status = qconstant.PassengerStatusUpdatePassenger
+	StatusPassSCP             Status = qconstant.PassengerStatusPassSCP
+	StatusPassBoarding        Status = qconstant.PassengerStatusPassBoarding
+	StatusEnrollMatch         Status = qconstant.PassengerStatusEnrollMatch
+	StatusEnrollNotMatch      Status = qconstant.PassengerStatusEnrollNotMatch
+	StatusLowFaceScore        Status = qconstant.PassengerStatusLowFaceScore
+	StatusNotPassSCP          Status = qconstant.PassengerStatusNotPassSCP
+	StatusMaxSCPCount         Status = qconstant.PassengerStatusMaxSCPCount
+	StatusMaxImmigrationCount Status = qconstant.PassengerStatusMaxImmigrationCount
+	StatusMaxBoardingCount    Status = qconstant.PassengerStatusMaxBoardingCount
+	StatusPassportExpired     Status = qconstant.PassengerStatusPassportExpired
+	StatusPassportExpiredSoon Status = qconstant.PassengerStatusPassportExpiredSoon
+	StatusBoardNo             Status = qconstant.PassengerStatusBoardNo
+	StatusErrorBGR            Status = qconstant.PassengerStatusErrorBGR
+	StatusInvalidBCBP         Status = qconstant.PassengerStatusInvalidBCBP
+	StatusInvalidMRZ          Status = qconstant.PassengerStatusInvalidMRZ
+	StatusTailgating          Status = qconstant.PassengerStatusTailgating
+	StatusRevoked             Status = qconstant.PassengerStatusRevoked
+	StatusTimeout             Status = qconstant.PassengerStatusTimeout
+	StatusSpoof               Status = qconstant.PassengerStatusSpoof
+	StatusMask                Status = qconstant.PassengerStatusMask
+	StatusNotPassedThrough    Status = qconstant.PassengerStatusNotPassedThrough
+	StatusNameMissmatch       Status = qconstant.PassengerStatusNameMissmatch
+	StatusChipMismatch        Status = qconstant.PassengerStatusChipMismatch
+	StatusInternalError       Status = qconstant.PassengerStatusInternalError
+	StatusException           Status = qconstant.PassengerStatusException
+)
+
+// Kinds of status. A milestone moves the passenger along the journey, an
+// outcome reports the result of an attempt at a touchpoint and leaves
+// the journey where it was, and a terminal status ends it.
+const (
+	KindMilestone = "milestone"
+	KindOutcome   = "outcome"
+	KindTerminal  = "terminal"
+)
+
+var statusKinds = map[Status]string{
+	StatusEnrollMatch:  KindMilestone,
+	StatusCheckin:      KindMilestone,
+	StatusPassSCP:      KindMilestone,
+	StatusPassBoarding: KindMilestone,
+
+	StatusLeaveGate: KindTerminal,
+	StatusRevoked:   KindTerminal,
+
+	StatusMatch:               KindOutcome,
+	StatusNoMatch:             KindOutcome,
+	StatusPresent:             KindOutcome,
+	StatusCheckinFailed:       KindOutcome,
+	StatusUpdatePassenger:     KindOutcome,
+	StatusEnrollNotMatch:      KindOutcome,
+	StatusLowFaceScore:        KindOutcome,
+	StatusNotPassSCP:          KindOutcome,
+	StatusMaxSCPCount:         KindOutcome,
+	StatusMaxImmigrationCount: KindOutcome,
+	StatusMaxBoardingCount:    KindOutcome,
+	StatusPassportExpired:     KindOutcome,
+	StatusPassportExpiredSoon: KindOutcome,
+	StatusBoardNo:             KindOutcome,
+	StatusErrorBGR:            KindOutcome,
+	StatusInvalidBCBP:         KindOutcome,
+	StatusInvalidMRZ:          KindOutcome,
+	StatusTailgating:          KindOutcome,
+	StatusTimeout:             KindOutcome,
+	StatusSpoof:               KindOutcome,
+	StatusMask:                KindOutcome,
+	StatusNotPassedThrough:    KindOutcome,
+	StatusNameMissmatch:       KindOutcome,
+	StatusChipMismatch:        KindOutcome,
+	StatusInternalError:       KindOutcome,
+	StatusException:           KindOutcome,
+}
+
+// ParseStatus returns the status of a qconstant PassengerStatus string.
+func ParseStatus(s string) (Status, error) {
+	status := Status(s)
+	if _, ok := statusKinds[status]; !ok {
+		return StatusNone, fmt.Errorf("%w: %q", ErrUnknownStatus, s)
+	}
+	return status, nil
+}
+
+// Kind returns KindMilestone, KindOutcome or KindTerminal, or an empty
+// string for StatusNone and unknown statuses.
+func (s Status) Kind() string {
+	return statusKinds[s]
+}
+
+// IsTerminal reports whether s ends the journey.
+func (s Status) IsTerminal() bool {
+	return statusKinds[s] == KindTerminal
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// eventStatuses maps the lowercase device events of qconstant to the
+// status they report. A gate mismatch and a refused boarding both
+// report StatusBoardNo.
+var eventStatuses = map[string]Status{
+	qconstant.PassengerMatch:               StatusMatch,
+	qconstant.PassengerNoMatch:             StatusNoMatch,
+	qconstant.PassengerTailgating:          StatusTailgating,
+	qconstant.PassengerPassportExpired:     StatusPassportExpired,
+	qconstant.PassengerPassportExpiredSoon: StatusPassportExpiredSoon,
+	qconstant.PassengerMaxImmigrationCount: StatusMaxImmigrationCount,
+	qconstant.PassengerMaxBoardingCount:    StatusMaxBoardingCount,
+	qconstant.PassengerMaxSCPCount:         StatusMaxSCPCount,
+	qconstant.PassengerGateNotMatch:        StatusBoardNo,
+	qconstant.PassengerCannotBoard:         StatusBoardNo,
+	qconstant.PassengerPresent:             StatusPresent,
+	qconstant.PassengerEnrollMatch:         StatusEnrollMatch,
+	qconstant.PassengerEnrollNotMatch:      StatusEnrollNotMatch,
+	qconstant.PassengerNotPassedThrough:    StatusNotPassedThrough,
+	qconstant.PassengerTimeout:             StatusTimeout,
+}
+
+// StatusForEvent returns the status reported by a qconstant event such
+// as qconstant.PassengerTailgating.
+func StatusForEvent(event string) (Status, error) {
+	status, ok := eventStatuses[event]
+	if !ok {
+		return StatusNone, fmt.Errorf("%w: %q", ErrUnknownEvent, event)
+	}
+	return status, nil
+}
+
+// Journey is where a passenger stands: the last status reported and the
+// last milestone reached.
+type Journey struct {
+	Status    Status `json:"status"`
+	Milestone Status `json:"milestone"`
+}
+
+// Transition is an attempted status change, passed to hooks whether it
+// was accepted or not. To is the journey after the change, unchanged
+// when Err is set.
+type Transition struct {
+	From   Journey `json:"from"`
+	Status Status  `json:"status"`
+	To     Journey `json:"to"`
+	Err    error   `json:"-"`
+}
+
+// Hook is called after every transition attempt, e.g. to audit them.
+type Hook func(Transition)
+
+// defaultMilestones declares which milestone may follow which. Passing
+// security and boarding may repeat; the SCP, immigration and boarding
+// counters limit how often.
+var defaultMilestones = map[Status][]Status{
+	StatusNone:         {StatusEnrollMatch, StatusCheckin},
+	StatusEnrollMatch:  {StatusEnrollMatch, StatusCheckin, StatusPassSCP},
+	StatusCheckin:      {StatusEnrollMatch, StatusPassSCP},
+	StatusPassSCP:      {StatusPassSCP, StatusPassBoarding},
+	StatusPassBoarding: {StatusPassBoarding},
+}
+
+// Machine validates status changes. Outcomes are allowed at any point of
+// an unfinished journey, milestones only in the declared order, and
+// StatusLeaveGate only after boarding. StatusRevoked may end any
+// unfinished journey.
+type Machine struct {
+	milestones map[Status]map[Status]bool
+	hooks      []Hook
+}
+
+type Option func(*Machine)
+
+// WithHook adds a hook called after every transition attempt. Hooks run
+// in the order added, on the goroutine calling Transition.
+func WithHook(h Hook) Option {
+	return func(m *Machine) {
+		m.hooks = append(m.hooks, h)
+	}
+}
+
+// WithMilestones also allows the given milestones after from, for
+// deployments whose journey differs from the default one.
+func WithMilestones(from Status, to ...Status) Option {
+	return func(m *Machine) {
+		if m.milestones[from] == nil {
+			m.milestones[from] = map[Status]bool{}
+		}
+		for _, s := range to {
+			m.milestones[from][s] = true
+		}
+	}
+}
+
+func NewMachine(opts ...Option) *Machine {
+	m := &Machine{milestones: map[Status]map[Status]bool{}}
+	for from, to := range defaultMilestones {
+		m.milestones[from] = map[Status]bool{}
+		for _, s := range to {
+			m.milestones[from][s] = true
+		}
+	}
+	for _, optFunc := range opts {
+		optFunc(m)
+	}
+	return m
+}
+
+// Can reports whether status may be reported for the journey.
+func (m *Machine) Can(j Journey, status Status) bool {
+	return m.check(j, status) == nil
+}
+
+func (m *Machine) check(j Journey, status Status) error {
+	kind, ok := statusKinds[status]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownStatus, status)
+	}
+	if j.Status.IsTerminal() {
+		return fmt.Errorf("%w: %s", ErrTerminal, j.Status)
+	}
+	switch {
+	case kind == KindOutcome, status == StatusRevoked:
+		return nil
+	case status == StatusLeaveGate:
+		if j.Milestone == StatusPassBoarding {
+			return nil
+		}
+	case m.milestones[j.Milestone][status]:
+		return nil
+	}
+	from := j.Milestone
+	if from == StatusNone {
+		from = "start"
+	}
+	return fmt.Errorf("%w: %s to %s", ErrIllegalTransition, from, status)
+}
+
+// Transition reports status for the journey and returns the journey
+// after it, or the journey unchanged and an error wrapping
+// ErrIllegalTransition, ErrTerminal or ErrUnknownStatus.
+func (m *Machine) Transition(j Journey, status Status) (Journey, error) {
+	t := Transition{From: j, Status: status, To: j}
+	if t.Err = m.check(j, status); t.Err == nil {
+		t.To.Status = status
+		if status.Kind() != KindOutcome {
+			t.To.Milestone = status
+		}
+	}
+	for _, h := range m.hooks {
+		h(t)
+	}
+	return t.To, t.Err
+}
+
+// Event reports the status of a qconstant event for the journey.
+func (m *Machine) Event(j Journey, event string) (Journey, error) {
+	status, err := StatusForEvent(event)
+	if err != nil {
+		return j, err
+	}
+	return m.Transition(j, status)
+}
@@ -0,0 +1,181 @@
+package qjourney
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+)
+
+func TestTransition(t *testing.T) {
+	start := Journey{}
+	enrolled := Journey{Status: StatusEnrollMatch, Milestone: StatusEnrollMatch}
+	checkedIn := Journey{Status: StatusCheckin, Milestone: StatusCheckin}
+	screened := Journey{Status: StatusPassSCP, Milestone: StatusPassSCP}
+	boarded := Journey{Status: StatusPassBoarding, Milestone: StatusPassBoarding}
+	left := Journey{Status: StatusLeaveGate, Milestone: StatusLeaveGate}
+	revoked := Journey{Status: StatusRevoked, Milestone: StatusRevoked}
+
+	tests := []struct {
+		name    string
+		from    Journey
+		status  Status
+		want    Journey
+		wantErr error
+	}{
+		{"enroll", start, StatusEnrollMatch, enrolled, nil},
+		{"check in", start, StatusCheckin, checkedIn, nil},
+		{"check in after enrolment", enrolled, StatusCheckin, checkedIn, nil},
+		{"enrol after check in", checkedIn, StatusEnrollMatch, enrolled, nil},
+		{"pass security", checkedIn, StatusPassSCP, screened, nil},
+		{"pass security again", screened, StatusPassSCP, screened, nil},
+		{"board", screened, StatusPassBoarding, boarded, nil},
+		{"board again", boarded, StatusPassBoarding, boarded, nil},
+		{"leave gate", boarded, StatusLeaveGate, left, nil},
+		{"outcome keeps milestone", screened, StatusNotPassSCP,
+			Journey{Status: StatusNotPassSCP, Milestone: StatusPassSCP}, nil},
+		{"outcome at start", start, StatusInvalidBCBP, Journey{Status: StatusInvalidBCBP}, nil},
+		{"milestone after outcome", Journey{Status: StatusNotPassSCP, Milestone: StatusPassSCP}, StatusPassBoarding, boarded, nil},
+		{"revoke", checkedIn, StatusRevoked, revoked, nil},
+		{"revoke at start", start, StatusRevoked, revoked, nil},
+
+		{"board at start", start, StatusPassBoarding, start, ErrIllegalTransition},
+		{"security before check in", start, StatusPassSCP, start, ErrIllegalTransition},
+		{"board before security", checkedIn, StatusPassBoarding, checkedIn, ErrIllegalTransition},
+		{"check in after security", screened, StatusCheckin, screened, ErrIllegalTransition},
+		{"security after boarding", boarded, StatusPassSCP, boarded, ErrIllegalTransition},
+		{"leave gate before boarding", screened, StatusLeaveGate, screened, ErrIllegalTransition},
+		{"outcome after leaving", left, StatusTailgating, left, ErrTerminal},
+		{"milestone after revoke", revoked, StatusCheckin, revoked, ErrTerminal},
+		{"revoke twice", revoked, StatusRevoked, revoked, ErrTerminal},
+		{"unknown status", checkedIn, Status("UNKNOWN"), checkedIn, ErrUnknownStatus},
+		{"none status", checkedIn, StatusNone, checkedIn, ErrUnknownStatus},
+	}
+	m := NewMachine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Transition(tt.from, tt.status)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Transition() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Transition() = %+v, want %+v", got, tt.want)
+			}
+			if can := m.Can(tt.from, tt.status); can != (tt.wantErr == nil) {
+				t.Errorf("Can() = %v, want %v", can, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestWithMilestones(t *testing.T) {
+	checkedIn := Journey{Status: StatusCheckin, Milestone: StatusCheckin}
+	if NewMachine().Can(checkedIn, StatusPassBoarding) {
+		t.Fatal("Can() = true before WithMilestones, want false")
+	}
+	m := NewMachine(WithMilestones(StatusCheckin, StatusPassBoarding))
+	got, err := m.Transition(checkedIn, StatusPassBoarding)
+	if err != nil {
+		t.Fatalf("Transition() error = %v", err)
+	}
+	if want := (Journey{Status: StatusPassBoarding, Milestone: StatusPassBoarding}); got != want {
+		t.Errorf("Transition() = %+v, want %+v", got, want)
+	}
+	if !m.Can(checkedIn, StatusPassSCP) {
+		t.Error("Can() = false for a default milestone, want true")
+	}
+}
+
+func TestHooks(t *testing.T) {
+	var calls []string
+	var transitions []Transition
+	m := NewMachine(
+		WithHook(func(tr Transition) {
+			calls = append(calls, "first")
+			transitions = append(transitions, tr)
+		}),
+		WithHook(func(Transition) { calls = append(calls, "second") }),
+	)
+
+	start := Journey{}
+	checkedIn, _ := m.Transition(start, StatusCheckin)
+	_, err := m.Transition(checkedIn, StatusLeaveGate)
+
+	if want := []string{"first", "second", "first", "second"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("hook calls = %v, want %v", calls, want)
+	}
+	if len(transitions) != 2 {
+		t.Fatalf("hook got %d transitions, want 2", len(transitions))
+	}
+	want := Transition{From: start, Status: StatusCheckin, To: checkedIn}
+	if got := transitions[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("accepted transition = %+v, want %+v", got, want)
+	}
+	rejected := transitions[1]
+	if rejected.From != checkedIn || rejected.To != checkedIn || rejected.Status != StatusLeaveGate {
+		t.Errorf("rejected transition = %+v, want journey %+v unchanged", rejected, checkedIn)
+	}
+	if !errors.Is(rejected.Err, ErrIllegalTransition) || rejected.Err != err {
+		t.Errorf("rejected transition error = %v, want %v", rejected.Err, err)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   string
+		want    Status
+		wantErr error
+	}{
+		{"match", qconstant.PassengerMatch, StatusMatch, nil},
+		{"gate not match", qconstant.PassengerGateNotMatch, StatusBoardNo, nil},
+		{"cannot board", qconstant.PassengerCannotBoard, StatusBoardNo, nil},
+		{"tailgating", qconstant.PassengerTailgating, StatusTailgating, nil},
+		{"status string", qconstant.PassengerStatusMatch, StatusNone, ErrUnknownEvent},
+		{"unknown", "unknown", StatusNone, ErrUnknownEvent},
+	}
+	m := NewMachine()
+	screened := Journey{Status: StatusPassSCP, Milestone: StatusPassSCP}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StatusForEvent(tt.event)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("StatusForEvent() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("StatusForEvent() = %v, want %v", got, tt.want)
+			}
+
+			j, err := m.Event(screened, tt.event)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Event() error = %v, want %v", err, tt.wantErr)
+			}
+			want := screened
+			if tt.wantErr == nil {
+				want.Status = tt.want
+			}
+			if j != want {
+				t.Errorf("Event() = %+v, want %+v", j, want)
+			}
+		})
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	for status, kind := range statusKinds {
+		got, err := ParseStatus(string(status))
+		if err != nil || got != status {
+			t.Errorf("ParseStatus(%q) = %v, %v, want %v", status, got, err, status)
+		}
+		if got.Kind() != kind {
+			t.Errorf("%v.Kind() = %v, want %v", status, got.Kind(), kind)
+		}
+	}
+	if _, err := ParseStatus("UNKNOWN"); !errors.Is(err, ErrUnknownStatus) {
+		t.Errorf("ParseStatus() error = %v, want %v", err, ErrUnknownStatus)
+	}
+	if StatusNone.Kind() != "" || StatusNone.IsTerminal() {
+		t.Error("StatusNone has a kind, want none")
+	}
+}
@@ -0,0 +1,190 @@
+package qmessage
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mhaqqiw/sdk/go/utils/qjourney"
+)
+
+var (
+	ErrUnknownStatus = errors.New("no message for passenger status")
+	ErrUnknownLocale = errors.New("no messages for locale")
+)
+
+// messages holds one <locale>.json file per language, keyed by
+// qconstant PassengerStatus value.
+//
+//go:embed messages/*.json
+var messages embed.FS
+
+type Severity string
+
+const (
+	SeveritySuccess Severity = "success"
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Message is the text shown for a passenger status: a short title, what
+// the passenger or operator should do, and how prominently to show it.
+type Message struct {
+	Title       string   `json:"title"`
+	Instruction string   `json:"instruction"`
+	Severity    Severity `json:"severity"`
+}
+
+// Catalog holds the messages of every locale. It is safe for concurrent
+// use and may be reloaded while in use.
+type Catalog struct {
+	mu       sync.RWMutex
+	locales  map[string]map[string]Message
+	fallback string
+}
+
+type option struct {
+	path     string
+	fallback string
+}
+
+type Option func(*option)
+
+// WithPath sets a directory of <locale>.json files overriding the
+// embedded messages. Entries replace the embedded message of the same
+// status and locale; new locales may be added this way.
+func WithPath(dir string) Option {
+	return func(o *option) {
+		o.path = dir
+	}
+}
+
+// WithFallbackLocale sets the locale used for locales without messages
+// and for statuses missing from a locale. Defaults to "en".
+func WithFallbackLocale(locale string) Option {
+	return func(o *option) {
+		o.fallback = locale
+	}
+}
+
+// NewCatalog loads the embedded messages and the overrides.
+func NewCatalog(opts ...Option) (*Catalog, error) {
+	c := &Catalog{}
+	if err := c.Load(opts...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load reads the messages again and replaces the content of c. On error
+// c is left unchanged.
+func (c *Catalog) Load(opts ...Option) error {
+	opt := &option{fallback: "en"}
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+
+	locales := map[string]map[string]Message{}
+	if err := loadLocales(locales, messages, "messages"); err != nil {
+		return err
+	}
+	if opt.path != "" {
+		if err := loadLocales(locales, os.DirFS(opt.path), "."); err != nil {
+			return err
+		}
+	}
+	fallback := normalizeLocale(opt.fallback)
+	if _, ok := locales[fallback]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownLocale, opt.fallback)
+	}
+
+	c.mu.Lock()
+	c.locales, c.fallback = locales, fallback
+	c.mu.Unlock()
+	return nil
+}
+
+func loadLocales(locales map[string]map[string]Message, fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		file, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		entries := map[string]Message{}
+		if err := json.Unmarshal(file, &entries); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		locale := normalizeLocale(strings.TrimSuffix(path.Base(name), ".json"))
+		if locales[locale] == nil {
+			locales[locale] = map[string]Message{}
+		}
+		for status, msg := range entries {
+			if !msg.Severity.valid() {
+				return fmt.Errorf("%s: %s: unknown severity %q", name, status, msg.Severity)
+			}
+			locales[locale][status] = msg
+		}
+	}
+	return nil
+}
+
+func (s Severity) valid() bool {
+	switch s {
+	case SeveritySuccess, SeverityInfo, SeverityWarning, SeverityError:
+		return true
+	}
+	return false
+}
+
+// Message returns the message of a qconstant PassengerStatus value, or
+// of the status a lowercase qconstant event such as
+// qconstant.PassengerGateNotMatch reports when it has no message of its
+// own in the same locale. A regional locale such as "id-ID" falls back
+// to its language, then to the fallback locale.
+func (c *Catalog) Message(status, locale string) (Message, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	locale = normalizeLocale(locale)
+	keys := []string{status}
+	if s, err := qjourney.StatusForEvent(status); err == nil {
+		keys = append(keys, s.String())
+	}
+	// The locale comes first: a message of the status in the requested
+	// language beats one of the event in the fallback language.
+	for _, l := range []string{locale, strings.SplitN(locale, "-", 2)[0], c.fallback} {
+		for _, key := range keys {
+			if msg, ok := c.locales[l][key]; ok {
+				return msg, nil
+			}
+		}
+	}
+	return Message{}, fmt.Errorf("%w: %s", ErrUnknownStatus, status)
+}
+
+// Locales returns the locales with messages, sorted.
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ret := make([]string, 0, len(c.locales))
+	for l := range c.locales {
+		ret = append(ret, l)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
@@ -0,0 +1,213 @@
+package qmessage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+	"github.com/mhaqqiw/sdk/go/utils/qjourney"
+)
+
+func writeLocale(t *testing.T, dir, locale, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, locale+".json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmbeddedLocales(t *testing.T) {
+	c, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	en, id := c.locales["en"], c.locales["id"]
+	if len(en) == 0 {
+		t.Fatal("no en messages")
+	}
+	for status := range en {
+		if _, ok := id[status]; !ok {
+			t.Errorf("%s has no id message", status)
+		}
+		if _, err := qjourney.ParseStatus(status); err != nil {
+			t.Errorf("%s is not a passenger status", status)
+		}
+	}
+	for status := range id {
+		if _, ok := en[status]; !ok {
+			t.Errorf("%s has no en message", status)
+		}
+	}
+	for _, locale := range []map[string]Message{en, id} {
+		for status, msg := range locale {
+			if msg.Title == "" || msg.Instruction == "" {
+				t.Errorf("%s has an empty title or instruction", status)
+			}
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	c, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		status  string
+		locale  string
+		want    Message
+		wantErr error
+	}{
+		{"status", qconstant.PassengerStatusMatch, "en", c.locales["en"][qconstant.PassengerStatusMatch], nil},
+		{"regional locale", qconstant.PassengerStatusMatch, "id_ID", c.locales["id"][qconstant.PassengerStatusMatch], nil},
+		{"unknown locale", qconstant.PassengerStatusMatch, "fr", c.locales["en"][qconstant.PassengerStatusMatch], nil},
+		{"match event", qconstant.PassengerMatch, "id", c.locales["id"][qconstant.PassengerStatusMatch], nil},
+		{"gate not match event", qconstant.PassengerGateNotMatch, "en", c.locales["en"][qconstant.PassengerStatusBoardNo], nil},
+		{"cannot board event", qconstant.PassengerCannotBoard, "en", c.locales["en"][qconstant.PassengerStatusBoardNo], nil},
+		{"unknown status", "PassengerStatusUnknown", "en", Message{}, ErrUnknownStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Message(tt.status, tt.locale)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Message() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Message() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageEvents(t *testing.T) {
+	c, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	events := []string{
+		qconstant.PassengerMatch, qconstant.PassengerNoMatch, qconstant.PassengerTailgating,
+		qconstant.PassengerPassportExpired, qconstant.PassengerPassportExpiredSoon,
+		qconstant.PassengerMaxImmigrationCount, qconstant.PassengerMaxBoardingCount,
+		qconstant.PassengerMaxSCPCount, qconstant.PassengerGateNotMatch, qconstant.PassengerCannotBoard,
+		qconstant.PassengerPresent, qconstant.PassengerEnrollMatch, qconstant.PassengerEnrollNotMatch,
+		qconstant.PassengerNotPassedThrough, qconstant.PassengerTimeout,
+	}
+	for _, event := range events {
+		for _, locale := range []string{"en", "id"} {
+			if _, err := c.Message(event, locale); err != nil {
+				t.Errorf("Message(%q, %q) error = %v", event, locale, err)
+			}
+		}
+	}
+}
+
+func TestWithPath(t *testing.T) {
+	dir := t.TempDir()
+	writeLocale(t, dir, "en", `{"PassengerStatusMatch": {"title": "Welcome", "instruction": "Go ahead.", "severity": "info"}}`)
+	writeLocale(t, dir, "ms", `{"PassengerStatusMatch": {"title": "Selamat datang", "instruction": "Sila teruskan.", "severity": "success"}}`)
+
+	embedded, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	c, err := NewCatalog(WithPath(dir))
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		status string
+		locale string
+		want   Message
+	}{
+		{"overridden", qconstant.PassengerStatusMatch, "en",
+			Message{Title: "Welcome", Instruction: "Go ahead.", Severity: SeverityInfo}},
+		{"not overridden", qconstant.PassengerStatusNoMatch, "en", embedded.locales["en"][qconstant.PassengerStatusNoMatch]},
+		{"other locale", qconstant.PassengerStatusMatch, "id", embedded.locales["id"][qconstant.PassengerStatusMatch]},
+		{"added locale", qconstant.PassengerStatusMatch, "ms-MY",
+			Message{Title: "Selamat datang", Instruction: "Sila teruskan.", Severity: SeveritySuccess}},
+		{"added locale falls back", qconstant.PassengerStatusNoMatch, "ms", embedded.locales["en"][qconstant.PassengerStatusNoMatch]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Message(tt.status, tt.locale)
+			if err != nil {
+				t.Fatalf("Message() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Message() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if got, want := len(c.Locales()), 3; got != want {
+		t.Errorf("Locales() = %v, want %d locales", c.Locales(), want)
+	}
+}
+
+func TestMessageEventOverride(t *testing.T) {
+	dir := t.TempDir()
+	writeLocale(t, dir, "en", `{"gate_not_match": {"title": "Wrong gate", "instruction": "Check your gate.", "severity": "error"}}`)
+
+	embedded, err := NewCatalog()
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	c, err := NewCatalog(WithPath(dir))
+	if err != nil {
+		t.Fatalf("NewCatalog() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		locale string
+		want   Message
+	}{
+		{"event message", "en", Message{Title: "Wrong gate", Instruction: "Check your gate.", Severity: SeverityError}},
+		{"status message in the locale", "id", embedded.locales["id"][qconstant.PassengerStatusBoardNo]},
+		{"status message in the language", "id-ID", embedded.locales["id"][qconstant.PassengerStatusBoardNo]},
+		{"event message in the fallback", "fr", Message{Title: "Wrong gate", Instruction: "Check your gate.", Severity: SeverityError}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Message(qconstant.PassengerGateNotMatch, tt.locale)
+			if err != nil {
+				t.Fatalf("Message() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Message() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		fallback string
+		wantErr  error
+	}{
+		{"unknown severity", `{"PassengerStatusMatch": {"title": "Welcome", "instruction": "Go ahead.", "severity": "fatal"}}`, "en", nil},
+		{"missing severity", `{"PassengerStatusMatch": {"title": "Welcome", "instruction": "Go ahead."}}`, "en", nil},
+		{"malformed", `{"PassengerStatusMatch": `, "en", nil},
+		{"unknown fallback", `{}`, "fr", ErrUnknownLocale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCatalog()
+			if err != nil {
+				t.Fatalf("NewCatalog() error = %v", err)
+			}
+			dir := t.TempDir()
+			writeLocale(t, dir, "en", tt.content)
+			err = c.Load(WithPath(dir), WithFallbackLocale(tt.fallback))
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("Load() error = %v, want an error", err)
+			}
+			if _, err := c.Message(qconstant.PassengerStatusMatch, "en"); err != nil {
+				t.Errorf("Message() after failed Load() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package qmrz
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// BAC key derivation counters from ICAO 9303 Part 11, section 9.7.1.
+const (
+	bacCounterEnc uint32 = 1
+	bacCounterMac uint32 = 2
+)
+
+// BACKeys holds the Basic Access Control keys derived from the MRZ
+// information. Enc and Mac are two-key 3DES keys with parity adjusted.
+type BACKeys struct {
+	Seed []byte `json:"seed"`
+	Enc  []byte `json:"enc"`
+	Mac  []byte `json:"mac"`
+}
+
+// BACInfo returns the "MRZ information" used for BAC: the document
+// number, date of birth and date of expiry, each followed by its check
+// digit. TD1 document numbers longer than nine characters are read back
+// from the optional data as described in ICAO 9303 Part 5.
+func (m MRZ) BACInfo() (string, error) {
+	var docNumber, dob, expiry string
+	switch m.DocumentClass {
+	case TD1:
+		docNumber, dob, expiry = m.TD1.DocNumber, m.TD1.DOB, m.TD1.ExpiredDate
+		if m.TD1.HashDocNumber == "" && m.TD1.AdditionalInfo1 != "" {
+			ext := strings.Fields(m.TD1.AdditionalInfo1)[0]
+			docNumber += ext[:len(ext)-1]
+		}
+	case TD2:
+		docNumber, dob, expiry = m.TD2.DocNumber, m.TD2.DOB, m.TD2.ExpiredDate
+	case TD3:
+		docNumber, dob, expiry = m.Passport.DocNumber, m.Passport.DOB, m.Passport.ExpiredDate
+	case VISA_A:
+		docNumber, dob, expiry = m.VISAA.DocNumber, m.VISAA.DOB, m.VISAA.ExpiredDate
+	case VISA_B:
+		docNumber, dob, expiry = m.VISAB.DocNumber, m.VISAB.DOB, m.VISAB.ExpiredDate
+	default:
+		return "", ErrNotSupported
+	}
+	if docNumber == "" {
+		return "", errors.New("empty document number")
+	}
+	if !isValidICAODate(dob) {
+		return "", ErrInvalidDOB
+	}
+	if !isValidICAODate(expiry) {
+		return "", ErrInvalidExpiredDate
+	}
+
+	docNumberField := strings.ReplaceAll(docNumber, " ", "<")
+	if len(docNumberField) < 9 {
+		docNumberField = padRight(docNumberField, 9, '<')
+	}
+	return docNumberField + strconv.Itoa(computeCheckDigit(docNumberField)) +
+		dob + strconv.Itoa(computeCheckDigit(dob)) +
+		expiry + strconv.Itoa(computeCheckDigit(expiry)), nil
+}
+
+// BACKeys derives the BAC keys of the parsed document.
+func (m MRZ) BACKeys() (BACKeys, error) {
+	info, err := m.BACInfo()
+	if err != nil {
+		return BACKeys{}, err
+	}
+	return DeriveBACKeys(info), nil
+}
+
+// DeriveBACKeys computes K_seed, K_enc and K_mac from the MRZ
+// information as specified in ICAO 9303 Part 11, Appendix D.
+func DeriveBACKeys(mrzInfo string) BACKeys {
+	h := sha1.Sum([]byte(mrzInfo))
+	seed := h[:16]
+	return BACKeys{
+		Seed: seed,
+		Enc:  deriveKey(seed, bacCounterEnc),
+		Mac:  deriveKey(seed, bacCounterMac),
+	}
+}
+
+// deriveKey is the 3DES key derivation function of ICAO 9303 Part 11,
+// section 9.7.1.
+func deriveKey(seed []byte, counter uint32) []byte {
+	d := make([]byte, len(seed)+4)
+	copy(d, seed)
+	binary.BigEndian.PutUint32(d[len(seed):], counter)
+	h := sha1.Sum(d)
+
+	key := make([]byte, 16)
+	copy(key, h[:16])
+	for i, b := range key {
+		key[i] = withOddParity(b)
+	}
+	return key
+}
+
+func withOddParity(b byte) byte {
+	ones := 0
+	for v := b >> 1; v > 0; v >>= 1 {
+		ones += int(v & 1)
+	}
+	if ones%2 == 0 {
+		return b | 1
+	}
+	return b &^ 1
+}
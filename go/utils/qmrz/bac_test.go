@@ -0,0 +1,84 @@
+package qmrz
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// The worked example of ICAO 9303 Part 11, Appendix D.2.
+const (
+	icaoBACInfo = "L898902C<369080619406236"
+	icaoBACSeed = "239AB9CB282DAF66231DC5A4DF6BFBAE"
+	icaoBACEnc  = "AB94FDECF2674FDFB9B391F85D7F76F2"
+	icaoBACMac  = "7962D9ECE03D1ACD4C76089DCE131543"
+)
+
+func TestDeriveBACKeys(t *testing.T) {
+	keys := DeriveBACKeys(icaoBACInfo)
+	tests := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"K_seed", keys.Seed, icaoBACSeed},
+		{"K_enc", keys.Enc, icaoBACEnc},
+		{"K_mac", keys.Mac, icaoBACMac},
+	}
+	for _, tt := range tests {
+		if got := strings.ToUpper(hex.EncodeToString(tt.got)); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBACInfo(t *testing.T) {
+	var m MRZ
+	m.DocumentClass = TD3
+	m.Passport.DocNumber = "L898902C"
+	m.Passport.DOB = "690806"
+	m.Passport.ExpiredDate = "940623"
+
+	info, err := m.BACInfo()
+	if err != nil {
+		t.Fatalf("BACInfo() error = %v", err)
+	}
+	if info != icaoBACInfo {
+		t.Errorf("BACInfo() = %q, want %q", info, icaoBACInfo)
+	}
+
+	keys, err := m.BACKeys()
+	if err != nil {
+		t.Fatalf("BACKeys() error = %v", err)
+	}
+	if got := strings.ToUpper(hex.EncodeToString(keys.Enc)); got != icaoBACEnc {
+		t.Errorf("BACKeys().Enc = %s, want %s", got, icaoBACEnc)
+	}
+}
+
+func TestBACInfoErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		class  string
+		number string
+		dob    string
+		expiry string
+	}{
+		{"unsupported class", "", "L898902C", "690806", "940623"},
+		{"empty document number", TD3, "", "690806", "940623"},
+		{"invalid dob", TD3, "L898902C", "691306", "940623"},
+		{"invalid expiry", TD3, "L898902C", "690806", "9406XX"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m MRZ
+			m.DocumentClass = tt.class
+			m.Passport.DocNumber = tt.number
+			m.Passport.DOB = tt.dob
+			m.Passport.ExpiredDate = tt.expiry
+			if _, err := m.BACInfo(); err == nil {
+				t.Error("BACInfo() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package qmrz
+
+import "strings"
+
+const (
+	CountryTypeState        = "state"
+	CountryTypeSpecial      = "special"
+	CountryTypeOrganization = "organization"
+)
+
+type Country struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// icaoCountries holds the ICAO 9303 Part 3 issuing state and nationality
+// codes, keyed by the code with fillers removed ("D<<" is stored as "D").
+var icaoCountries = map[string]Country{
+	"ABW": {"ABW", "Aruba", CountryTypeState},
+	"AFG": {"AFG", "Afghanistan", CountryTypeState},
+	"AGO": {"AGO", "Angola", CountryTypeState},
+	"AIA": {"AIA", "Anguilla", CountryTypeState},
+	"ALA": {"ALA", "Åland Islands", CountryTypeState},
+	"ALB": {"ALB", "Albania", CountryTypeState},
+	"AND": {"AND", "Andorra", CountryTypeState},
+	"ARE": {"ARE", "United Arab Emirates", CountryTypeState},
+	"ARG": {"ARG", "Argentina", CountryTypeState},
+	"ARM": {"ARM", "Armenia", CountryTypeState},
+	"ASM": {"ASM", "American Samoa", CountryTypeState},
+	"ATA": {"ATA", "Antarctica", CountryTypeState},
+	"ATF": {"ATF", "French Southern Territories", CountryTypeState},
+	"ATG": {"ATG", "Antigua and Barbuda", CountryTypeState},
+	"AUS": {"AUS", "Australia", CountryTypeState},
+	"AUT": {"AUT", "Austria", CountryTypeState},
+	"AZE": {"AZE", "Azerbaijan", CountryTypeState},
+	"BDI": {"BDI", "Burundi", CountryTypeState},
+	"BEL": {"BEL", "Belgium", CountryTypeState},
+	"BEN": {"BEN", "Benin", CountryTypeState},
+	"BES": {"BES", "Bonaire, Sint Eustatius and Saba", CountryTypeState},
+	"BFA": {"BFA", "Burkina Faso", CountryTypeState},
+	"BGD": {"BGD", "Bangladesh", CountryTypeState},
+	"BGR": {"BGR", "Bulgaria", CountryTypeState},
+	"BHR": {"BHR", "Bahrain", CountryTypeState},
+	"BHS": {"BHS", "Bahamas", CountryTypeState},
+	"BIH": {"BIH", "Bosnia and Herzegovina", CountryTypeState},
+	"BLM": {"BLM", "Saint Barthélemy", CountryTypeState},
+	"BLR": {"BLR", "Belarus", CountryTypeState},
+	"BLZ": {"BLZ", "Belize", CountryTypeState},
+	"BMU": {"BMU", "Bermuda", CountryTypeState},
+	"BOL": {"BOL", "Bolivia", CountryTypeState},
+	"BRA": {"BRA", "Brazil", CountryTypeState},
+	"BRB": {"BRB", "Barbados", CountryTypeState},
+	"BRN": {"BRN", "Brunei Darussalam", CountryTypeState},
+	"BTN": {"BTN", "Bhutan", CountryTypeState},
+	"BVT": {"BVT", "Bouvet Island", CountryTypeState},
+	"BWA": {"BWA", "Botswana", CountryTypeState},
+	"CAF": {"CAF", "Central African Republic", CountryTypeState},
+	"CAN": {"CAN", "Canada", CountryTypeState},
+	"CCK": {"CCK", "Cocos (Keeling) Islands", CountryTypeState},
+	"CHE": {"CHE", "Switzerland", CountryTypeState},
+	"CHL": {"CHL", "Chile", CountryTypeState},
+	"CHN": {"CHN", "China", CountryTypeState},
+	"CIV": {"CIV", "Côte d'Ivoire", CountryTypeState},
+	"CMR": {"CMR", "Cameroon", CountryTypeState},
+	"COD": {"COD", "Congo, The Democratic Republic of the", CountryTypeState},
+	"COG": {"COG", "Congo", CountryTypeState},
+	"COK": {"COK", "Cook Islands", CountryTypeState},
+	"COL": {"COL", "Colombia", CountryTypeState},
+	"COM": {"COM", "Comoros", CountryTypeState},
+	"CPV": {"CPV", "Cabo Verde", CountryTypeState},
+	"CRI": {"CRI", "Costa Rica", CountryTypeState},
+	"CUB": {"CUB", "Cuba", CountryTypeState},
+	"CUW": {"CUW", "Curaçao", CountryTypeState},
+	"CXR": {"CXR", "Christmas Island", CountryTypeState},
+	"CYM": {"CYM", "Cayman Islands", CountryTypeState},
+	"CYP": {"CYP", "Cyprus", CountryTypeState},
+	"CZE": {"CZE", "Czechia", CountryTypeState},
+	"D":   {"D", "Germany", CountryTypeState},
+	"DJI": {"DJI", "Djibouti", CountryTypeState},
+	"DMA": {"DMA", "Dominica", CountryTypeState},
+	"DNK": {"DNK", "Denmark", CountryTypeState},
+	"DOM": {"DOM", "Dominican Republic", CountryTypeState},
+	"DZA": {"DZA", "Algeria", CountryTypeState},
+	"ECU": {"ECU", "Ecuador", CountryTypeState},
+	"EGY": {"EGY", "Egypt", CountryTypeState},
+	"ERI": {"ERI", "Eritrea", CountryTypeState},
+	"ESH": {"ESH", "Western Sahara", CountryTypeState},
+	"ESP": {"ESP", "Spain", CountryTypeState},
+	"EST": {"EST", "Estonia", CountryTypeState},
+	"ETH": {"ETH", "Ethiopia", CountryTypeState},
+	"EUE": {"EUE", "European Union", CountryTypeOrganization},
+	"FIN": {"FIN", "Finland", CountryTypeState},
+	"FJI": {"FJI", "Fiji", CountryTypeState},
+	"FLK": {"FLK", "Falkland Islands (Malvinas)", CountryTypeState},
+	"FRA": {"FRA", "France", CountryTypeState},
+	"FRO": {"FRO", "Faroe Islands", CountryTypeState},
+	"FSM": {"FSM", "Micronesia, Federated States of", CountryTypeState},
+	"GAB": {"GAB", "Gabon", CountryTypeState},
+	"GBD": {"GBD", "British Overseas Territories Citizen", CountryTypeSpecial},
+	"GBN": {"GBN", "British National (Overseas)", CountryTypeSpecial},
+	"GBO": {"GBO", "British Overseas Citizen", CountryTypeSpecial},
+	"GBP": {"GBP", "British Protected Person", CountryTypeSpecial},
+	"GBR": {"GBR", "United Kingdom", CountryTypeState},
+	"GBS": {"GBS", "British Subject", CountryTypeSpecial},
+	"GEO": {"GEO", "Georgia", CountryTypeState},
+	"GGY": {"GGY", "Guernsey", CountryTypeState},
+	"GHA": {"GHA", "Ghana", CountryTypeState},
+	"GIB": {"GIB", "Gibraltar", CountryTypeState},
+	"GIN": {"GIN", "Guinea", CountryTypeState},
+	"GLP": {"GLP", "Guadeloupe", CountryTypeState},
+	"GMB": {"GMB", "Gambia", CountryTypeState},
+	"GNB": {"GNB", "Guinea-Bissau", CountryTypeState},
+	"GNQ": {"GNQ", "Equatorial Guinea", CountryTypeState},
+	"GRC": {"GRC", "Greece", CountryTypeState},
+	"GRD": {"GRD", "Grenada", CountryTypeState},
+	"GRL": {"GRL", "Greenland", CountryTypeState},
+	"GTM": {"GTM", "Guatemala", CountryTypeState},
+	"GUF": {"GUF", "French Guiana", CountryTypeState},
+	"GUM": {"GUM", "Guam", CountryTypeState},
+	"GUY": {"GUY", "Guyana", CountryTypeState},
+	"HKG": {"HKG", "Hong Kong", CountryTypeState},
+	"HMD": {"HMD", "Heard Island and McDonald Islands", CountryTypeState},
+	"HND": {"HND", "Honduras", CountryTypeState},
+	"HRV": {"HRV", "Croatia", CountryTypeState},
+	"HTI": {"HTI", "Haiti", CountryTypeState},
+	"HUN": {"HUN", "Hungary", CountryTypeState},
+	"IDN": {"IDN", "Indonesia", CountryTypeState},
+	"IMN": {"IMN", "Isle of Man", CountryTypeState},
+	"IND": {"IND", "India", CountryTypeState},
+	"IOT": {"IOT", "British Indian Ocean Territory", CountryTypeState},
+	"IRL": {"IRL", "Ireland", CountryTypeState},
+	"IRN": {"IRN", "Iran", CountryTypeState},
+	"IRQ": {"IRQ", "Iraq", CountryTypeState},
+	"ISL": {"ISL", "Iceland", CountryTypeState},
+	"ISR": {"ISR", "Israel", CountryTypeState},
+	"ITA": {"ITA", "Italy", CountryTypeState},
+	"JAM": {"JAM", "Jamaica", CountryTypeState},
+	"JEY": {"JEY", "Jersey", CountryTypeState},
+	"JOR": {"JOR", "Jordan", CountryTypeState},
+	"JPN": {"JPN", "Japan", CountryTypeState},
+	"KAZ": {"KAZ", "Kazakhstan", CountryTypeState},
+	"KEN": {"KEN", "Kenya", CountryTypeState},
+	"KGZ": {"KGZ", "Kyrgyzstan", CountryTypeState},
+	"KHM": {"KHM", "Cambodia", CountryTypeState},
+	"KIR": {"KIR", "Kiribati", CountryTypeState},
+	"KNA": {"KNA", "Saint Kitts and Nevis", CountryTypeState},
+	"KOR": {"KOR", "South Korea", CountryTypeState},
+	"KWT": {"KWT", "Kuwait", CountryTypeState},
+	"LAO": {"LAO", "Laos", CountryTypeState},
+	"LBN": {"LBN", "Lebanon", CountryTypeState},
+	"LBR": {"LBR", "Liberia", CountryTypeState},
+	"LBY": {"LBY", "Libya", CountryTypeState},
+	"LCA": {"LCA", "Saint Lucia", CountryTypeState},
+	"LIE": {"LIE", "Liechtenstein", CountryTypeState},
+	"LKA": {"LKA", "Sri Lanka", CountryTypeState},
+	"LSO": {"LSO", "Lesotho", CountryTypeState},
+	"LTU": {"LTU", "Lithuania", CountryTypeState},
+	"LUX": {"LUX", "Luxembourg", CountryTypeState},
+	"LVA": {"LVA", "Latvia", CountryTypeState},
+	"MAC": {"MAC", "Macao", CountryTypeState},
+	"MAF": {"MAF", "Saint Martin (French part)", CountryTypeState},
+	"MAR": {"MAR", "Morocco", CountryTypeState},
+	"MCO": {"MCO", "Monaco", CountryTypeState},
+	"MDA": {"MDA", "Moldova", CountryTypeState},
+	"MDG": {"MDG", "Madagascar", CountryTypeState},
+	"MDV": {"MDV", "Maldives", CountryTypeState},
+	"MEX": {"MEX", "Mexico", CountryTypeState},
+	"MHL": {"MHL", "Marshall Islands", CountryTypeState},
+	"MKD": {"MKD", "North Macedonia", CountryTypeState},
+	"MLI": {"MLI", "Mali", CountryTypeState},
+	"MLT": {"MLT", "Malta", CountryTypeState},
+	"MMR": {"MMR", "Myanmar", CountryTypeState},
+	"MNE": {"MNE", "Montenegro", CountryTypeState},
+	"MNG": {"MNG", "Mongolia", CountryTypeState},
+	"MNP": {"MNP", "Northern Mariana Islands", CountryTypeState},
+	"MOZ": {"MOZ", "Mozambique", CountryTypeState},
+	"MRT": {"MRT", "Mauritania", CountryTypeState},
+	"MSR": {"MSR", "Montserrat", CountryTypeState},
+	"MTQ": {"MTQ", "Martinique", CountryTypeState},
+	"MUS": {"MUS", "Mauritius", CountryTypeState},
+	"MWI": {"MWI", "Malawi", CountryTypeState},
+	"MYS": {"MYS", "Malaysia", CountryTypeState},
+	"MYT": {"MYT", "Mayotte", CountryTypeState},
+	"NAM": {"NAM", "Namibia", CountryTypeState},
+	"NCL": {"NCL", "New Caledonia", CountryTypeState},
+	"NER": {"NER", "Niger", CountryTypeState},
+	"NFK": {"NFK", "Norfolk Island", CountryTypeState},
+	"NGA": {"NGA", "Nigeria", CountryTypeState},
+	"NIC": {"NIC", "Nicaragua", CountryTypeState},
+	"NIU": {"NIU", "Niue", CountryTypeState},
+	"NLD": {"NLD", "Netherlands", CountryTypeState},
+	"NOR": {"NOR", "Norway", CountryTypeState},
+	"NPL": {"NPL", "Nepal", CountryTypeState},
+	"NRU": {"NRU", "Nauru", CountryTypeState},
+	"NZL": {"NZL", "New Zealand", CountryTypeState},
+	"OMN": {"OMN", "Oman", CountryTypeState},
+	"PAK": {"PAK", "Pakistan", CountryTypeState},
+	"PAN": {"PAN", "Panama", CountryTypeState},
+	"PCN": {"PCN", "Pitcairn", CountryTypeState},
+	"PER": {"PER", "Peru", CountryTypeState},
+	"PHL": {"PHL", "Philippines", CountryTypeState},
+	"PLW": {"PLW", "Palau", CountryTypeState},
+	"PNG": {"PNG", "Papua New Guinea", CountryTypeState},
+	"POL": {"POL", "Poland", CountryTypeState},
+	"PRI": {"PRI", "Puerto Rico", CountryTypeState},
+	"PRK": {"PRK", "North Korea", CountryTypeState},
+	"PRT": {"PRT", "Portugal", CountryTypeState},
+	"PRY": {"PRY", "Paraguay", CountryTypeState},
+	"PSE": {"PSE", "Palestine, State of", CountryTypeState},
+	"PYF": {"PYF", "French Polynesia", CountryTypeState},
+	"QAT": {"QAT", "Qatar", CountryTypeState},
+	"REU": {"REU", "Réunion", CountryTypeState},
+	"RKS": {"RKS", "Kosovo", CountryTypeSpecial},
+	"ROU": {"ROU", "Romania", CountryTypeState},
+	"RUS": {"RUS", "Russian Federation", CountryTypeState},
+	"RWA": {"RWA", "Rwanda", CountryTypeState},
+	"SAU": {"SAU", "Saudi Arabia", CountryTypeState},
+	"SDN": {"SDN", "Sudan", CountryTypeState},
+	"SEN": {"SEN", "Senegal", CountryTypeState},
+	"SGP": {"SGP", "Singapore", CountryTypeState},
+	"SGS": {"SGS", "South Georgia and the South Sandwich Islands", CountryTypeState},
+	"SHN": {"SHN", "Saint Helena, Ascension and Tristan da Cunha", CountryTypeState},
+	"SJM": {"SJM", "Svalbard and Jan Mayen", CountryTypeState},
+	"SLB": {"SLB", "Solomon Islands", CountryTypeState},
+	"SLE": {"SLE", "Sierra Leone", CountryTypeState},
+	"SLV": {"SLV", "El Salvador", CountryTypeState},
+	"SMR": {"SMR", "San Marino", CountryTypeState},
+	"SOM": {"SOM", "Somalia", CountryTypeState},
+	"SPM": {"SPM", "Saint Pierre and Miquelon", CountryTypeState},
+	"SRB": {"SRB", "Serbia", CountryTypeState},
+	"SSD": {"SSD", "South Sudan", CountryTypeState},
+	"STP": {"STP", "Sao Tome and Principe", CountryTypeState},
+	"SUR": {"SUR", "Suriname", CountryTypeState},
+	"SVK": {"SVK", "Slovakia", CountryTypeState},
+	"SVN": {"SVN", "Slovenia", CountryTypeState},
+	"SWE": {"SWE", "Sweden", CountryTypeState},
+	"SWZ": {"SWZ", "Eswatini", CountryTypeState},
+	"SXM": {"SXM", "Sint Maarten (Dutch part)", CountryTypeState},
+	"SYC": {"SYC", "Seychelles", CountryTypeState},
+	"SYR": {"SYR", "Syria", CountryTypeState},
+	"TCA": {"TCA", "Turks and Caicos Islands", CountryTypeState},
+	"TCD": {"TCD", "Chad", CountryTypeState},
+	"TGO": {"TGO", "Togo", CountryTypeState},
+	"THA": {"THA", "Thailand", CountryTypeState},
+	"TJK": {"TJK", "Tajikistan", CountryTypeState},
+	"TKL": {"TKL", "Tokelau", CountryTypeState},
+	"TKM": {"TKM", "Turkmenistan", CountryTypeState},
+	"TLS": {"TLS", "Timor-Leste", CountryTypeState},
+	"TON": {"TON", "Tonga", CountryTypeState},
+	"TTO": {"TTO", "Trinidad and Tobago", CountryTypeState},
+	"TUN": {"TUN", "Tunisia", CountryTypeState},
+	"TUR": {"TUR", "Türkiye", CountryTypeState},
+	"TUV": {"TUV", "Tuvalu", CountryTypeState},
+	"TWN": {"TWN", "Taiwan", CountryTypeState},
+	"TZA": {"TZA", "Tanzania", CountryTypeState},
+	"UGA": {"UGA", "Uganda", CountryTypeState},
+	"UKR": {"UKR", "Ukraine", CountryTypeState},
+	"UMI": {"UMI", "United States Minor Outlying Islands", CountryTypeState},
+	"UNA": {"UNA", "United Nations Specialized Agency", CountryTypeOrganization},
+	"UNK": {"UNK", "Kosovo (UNMIK)", CountryTypeOrganization},
+	"UNO": {"UNO", "United Nations Organization", CountryTypeOrganization},
+	"URY": {"URY", "Uruguay", CountryTypeState},
+	"USA": {"USA", "United States", CountryTypeState},
+	"UTO": {"UTO", "Utopia", CountryTypeSpecial},
+	"UZB": {"UZB", "Uzbekistan", CountryTypeState},
+	"VAT": {"VAT", "Holy See (Vatican City State)", CountryTypeState},
+	"VCT": {"VCT", "Saint Vincent and the Grenadines", CountryTypeState},
+	"VEN": {"VEN", "Venezuela", CountryTypeState},
+	"VGB": {"VGB", "Virgin Islands, British", CountryTypeState},
+	"VIR": {"VIR", "Virgin Islands, U.S.", CountryTypeState},
+	"VNM": {"VNM", "Vietnam", CountryTypeState},
+	"VUT": {"VUT", "Vanuatu", CountryTypeState},
+	"WLF": {"WLF", "Wallis and Futuna", CountryTypeState},
+	"WSM": {"WSM", "Samoa", CountryTypeState},
+	"XBA": {"XBA", "African Development Bank", CountryTypeOrganization},
+	"XCC": {"XCC", "Caribbean Community", CountryTypeOrganization},
+	"XCE": {"XCE", "Council of Europe", CountryTypeOrganization},
+	"XCO": {"XCO", "Common Market for Eastern and Southern Africa", CountryTypeOrganization},
+	"XDC": {"XDC", "Southern African Development Community", CountryTypeOrganization},
+	"XEC": {"XEC", "Economic Community of West African States", CountryTypeOrganization},
+	"XES": {"XES", "Organisation of Eastern Caribbean States", CountryTypeOrganization},
+	"XIM": {"XIM", "African Export-Import Bank", CountryTypeOrganization},
+	"XMP": {"XMP", "Parliamentary Assembly of the Mediterranean", CountryTypeOrganization},
+	"XOM": {"XOM", "Sovereign Military Order of Malta", CountryTypeOrganization},
+	"XPO": {"XPO", "International Criminal Police Organization", CountryTypeOrganization},
+	"XXA": {"XXA", "Stateless person", CountryTypeSpecial},
+	"XXB": {"XXB", "Refugee (1951 Convention)", CountryTypeSpecial},
+	"XXC": {"XXC", "Refugee (other)", CountryTypeSpecial},
+	"XXX": {"XXX", "Unspecified nationality", CountryTypeSpecial},
+	"YEM": {"YEM", "Yemen", CountryTypeState},
+	"ZAF": {"ZAF", "South Africa", CountryTypeState},
+	"ZMB": {"ZMB", "Zambia", CountryTypeState},
+	"ZWE": {"ZWE", "Zimbabwe", CountryTypeState},
+}
+
+// LookupCountry returns the registry entry for an MRZ country code. The
+// code may still carry fillers, e.g. "D<<".
+func LookupCountry(code string) (Country, bool) {
+	c, ok := icaoCountries[clear(strings.ToUpper(code))]
+	return c, ok
+}
+
+// IsValidCountryCode reports whether code is a known ICAO 9303 issuing
+// state, nationality or organisation code.
+func IsValidCountryCode(code string) bool {
+	_, ok := LookupCountry(code)
+	return ok
+}
+
+// CountryName returns the name registered for code, or an empty string
+// when the code is unknown.
+func CountryName(code string) string {
+	c, _ := LookupCountry(code)
+	return c.Name
+}
+
+// Countries returns a copy of the whole registry keyed by code.
+func Countries() map[string]Country {
+	ret := make(map[string]Country, len(icaoCountries))
+	for k, v := range icaoCountries {
+		ret[k] = v
+	}
+	return ret
+}
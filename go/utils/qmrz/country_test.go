@@ -0,0 +1,81 @@
+package qmrz
+
+import (
+	"testing"
+)
+
+func TestLookupCountry(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		want   Country
+		wantOK bool
+	}{
+		{"Germany", "D", Country{"D", "Germany", CountryTypeState}, true},
+		{"Germany with fillers", "D<<", Country{"D", "Germany", CountryTypeState}, true},
+		{"British Overseas Territories Citizen", "GBD", Country{"GBD", "British Overseas Territories Citizen", CountryTypeSpecial}, true},
+		{"United Nations", "UNO", Country{"UNO", "United Nations Organization", CountryTypeOrganization}, true},
+		{"stateless", "XXA", Country{"XXA", "Stateless person", CountryTypeSpecial}, true},
+		{"European Union", "EUE", Country{"EUE", "European Union", CountryTypeOrganization}, true},
+		{"lower case", "idn", Country{"IDN", "Indonesia", CountryTypeState}, true},
+		{"lower case with fillers", "d<<", Country{"D", "Germany", CountryTypeState}, true},
+		{"ISO code of Germany", "DEU", Country{}, false},
+		{"unknown", "QQQ", Country{}, false},
+		{"fillers only", "<<<", Country{}, false},
+		{"empty", "", Country{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := LookupCountry(tt.code)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("LookupCountry(%q) = %+v, %v, want %+v, %v", tt.code, got, ok, tt.want, tt.wantOK)
+			}
+			if valid := IsValidCountryCode(tt.code); valid != tt.wantOK {
+				t.Errorf("IsValidCountryCode(%q) = %v, want %v", tt.code, valid, tt.wantOK)
+			}
+			if name := CountryName(tt.code); name != tt.want.Name {
+				t.Errorf("CountryName(%q) = %q, want %q", tt.code, name, tt.want.Name)
+			}
+		})
+	}
+}
+
+func TestCountries(t *testing.T) {
+	countries := Countries()
+	if len(countries) != len(icaoCountries) {
+		t.Fatalf("Countries() has %d codes, want %d", len(countries), len(icaoCountries))
+	}
+	codes := map[string]string{}
+	for key, c := range countries {
+		if key != c.Code {
+			t.Errorf("key %q holds code %q", key, c.Code)
+		}
+		if other, ok := codes[c.Code]; ok {
+			t.Errorf("code %q registered under %q and %q", c.Code, key, other)
+		}
+		codes[c.Code] = key
+		if len(key) == 0 || len(key) > 3 {
+			t.Errorf("key %q is not one to three characters", key)
+		}
+		for _, r := range key {
+			if r < 'A' || r > 'Z' {
+				t.Errorf("key %q is not upper case letters", key)
+				break
+			}
+		}
+		if c.Name == "" {
+			t.Errorf("%s has no name", key)
+		}
+		switch c.Type {
+		case CountryTypeState, CountryTypeSpecial, CountryTypeOrganization:
+		default:
+			t.Errorf("%s has type %q", key, c.Type)
+		}
+	}
+
+	// Countries returns a copy.
+	delete(countries, "D")
+	if !IsValidCountryCode("D") {
+		t.Error("deleting from Countries() changed the registry")
+	}
+}
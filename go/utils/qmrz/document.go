@@ -0,0 +1,201 @@
+package qmrz
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Document is a layout independent view over a parsed MRZ, so callers
+// can read the holder data without switching on DocumentClass.
+// NameTruncated reports whether the name filled its whole field and may
+// have been cut.
+type Document interface {
+	Class() string
+	Type() string
+	IssuingCountry() string
+	FirstName() string
+	LastName() string
+	FullName() string
+	NameTruncated() bool
+	DocNumber() string
+	Nationality() string
+	Sex() string
+	DOB() time.Time
+	ExpiredDate() time.Time
+	OptionalData() []string
+	IsValid() bool
+	json.Marshaler
+}
+
+type document struct {
+	class          string
+	docType        string
+	issuingCountry string
+	firstName      string
+	lastName       string
+	nameTruncated  bool
+	docNumber      string
+	nationality    string
+	sex            string
+	dob            time.Time
+	expiredDate    time.Time
+	optionalData   []string
+	isValid        bool
+}
+
+type documentJSON struct {
+	DocumentClass  string    `json:"document_class"`
+	DocumentType   string    `json:"document_type"`
+	IssuingCountry string    `json:"issuing_country"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	FullName       string    `json:"full_name"`
+	NameTruncated  bool      `json:"name_truncated"`
+	DocNumber      string    `json:"doc_number"`
+	Nationality    string    `json:"nationality"`
+	Sex            string    `json:"sex"`
+	DOB            time.Time `json:"dob"`
+	ExpiredDate    time.Time `json:"expired_date"`
+	OptionalData   []string  `json:"optional_data"`
+	IsValid        bool      `json:"is_valid"`
+}
+
+// Document returns the layout independent view of m. The centuries of
+// DOB and expiry are resolved with ResolveMRZDates; the dates are zero
+// when they could not be parsed.
+func (m MRZ) Document() Document {
+	return m.DocumentAt(time.Now())
+}
+
+// DocumentAt is Document with the dates resolved as of now, such as the
+// date of travel of the holder.
+func (m MRZ) DocumentAt(now time.Time) Document {
+	d := document{
+		class:   m.DocumentClass,
+		docType: m.DocumentType,
+	}
+	var dob, expiry string
+	switch m.DocumentClass {
+	case TD1:
+		d.issuingCountry = m.TD1.Country
+		d.firstName = m.TD1.FirstName
+		d.lastName = m.TD1.LastName
+		d.nameTruncated = m.TD1.NameTruncated
+		d.docNumber = m.TD1.DocNumber
+		d.nationality = m.TD1.Nationality
+		d.sex = m.TD1.Sex
+		d.optionalData = nonEmpty(m.TD1.AdditionalInfo1, m.TD1.AdditionalInfo2)
+		d.isValid = m.TD1.ExpectedHash.IsValid && m.TD1.ExpectedHash.DOBValid &&
+			m.TD1.ExpectedHash.ExpiredDateValid && m.TD1.ExpectedHash.NameValid &&
+			m.TD1.ExpectedHash.CountryValid && m.TD1.ExpectedHash.NationalityValid
+		dob, expiry = m.TD1.DOB, m.TD1.ExpiredDate
+	case TD2:
+		d.issuingCountry = m.TD2.Country
+		d.firstName = m.TD2.FirstName
+		d.lastName = m.TD2.LastName
+		d.nameTruncated = m.TD2.NameTruncated
+		d.docNumber = m.TD2.DocNumber
+		d.nationality = m.TD2.Nationality
+		d.sex = m.TD2.Sex
+		d.optionalData = nonEmpty(m.TD2.AdditionalInfo)
+		d.isValid = m.TD2.ExpectedHash.IsValid && m.TD2.ExpectedHash.DOBValid &&
+			m.TD2.ExpectedHash.ExpiredDateValid && m.TD2.ExpectedHash.NameValid &&
+			m.TD2.ExpectedHash.CountryValid && m.TD2.ExpectedHash.NationalityValid
+		dob, expiry = m.TD2.DOB, m.TD2.ExpiredDate
+	case TD3:
+		d.issuingCountry = m.Passport.Country
+		d.firstName = m.Passport.FirstName
+		d.lastName = m.Passport.LastName
+		d.nameTruncated = m.Passport.NameTruncated
+		d.docNumber = m.Passport.DocNumber
+		d.nationality = m.Passport.Nationality
+		d.sex = m.Passport.Sex
+		d.optionalData = nonEmpty(m.Passport.PersonalNumber)
+		d.isValid = m.Passport.ExpectedHash.IsValid && m.Passport.ExpectedHash.DOBValid &&
+			m.Passport.ExpectedHash.ExpiredDateValid && m.Passport.ExpectedHash.NameValid &&
+			m.Passport.ExpectedHash.CountryValid && m.Passport.ExpectedHash.NationalityValid
+		dob, expiry = m.Passport.DOB, m.Passport.ExpiredDate
+	case VISA_A:
+		d.issuingCountry = m.VISAA.Country
+		d.firstName = m.VISAA.FirstName
+		d.lastName = m.VISAA.LastName
+		d.nameTruncated = m.VISAA.NameTruncated
+		d.docNumber = m.VISAA.DocNumber
+		d.nationality = m.VISAA.Nationality
+		d.sex = m.VISAA.Sex
+		d.optionalData = nonEmpty(m.VISAA.AdditionalInfo)
+		d.isValid = m.VISAA.ExpectedHash.IsValid && m.VISAA.ExpectedHash.DOBValid &&
+			m.VISAA.ExpectedHash.ExpiredDateValid && m.VISAA.ExpectedHash.NameValid &&
+			m.VISAA.ExpectedHash.CountryValid && m.VISAA.ExpectedHash.NationalityValid
+		dob, expiry = m.VISAA.DOB, m.VISAA.ExpiredDate
+	case VISA_B:
+		d.issuingCountry = m.VISAB.Country
+		d.firstName = m.VISAB.FirstName
+		d.lastName = m.VISAB.LastName
+		d.nameTruncated = m.VISAB.NameTruncated
+		d.docNumber = m.VISAB.DocNumber
+		d.nationality = m.VISAB.Nationality
+		d.sex = m.VISAB.Sex
+		d.optionalData = nonEmpty(m.VISAB.AdditionalInfo)
+		d.isValid = m.VISAB.ExpectedHash.IsValid && m.VISAB.ExpectedHash.DOBValid &&
+			m.VISAB.ExpectedHash.ExpiredDateValid && m.VISAB.ExpectedHash.NameValid &&
+			m.VISAB.ExpectedHash.CountryValid && m.VISAB.ExpectedHash.NationalityValid
+		dob, expiry = m.VISAB.DOB, m.VISAB.ExpiredDate
+	}
+
+	if dates, err := ResolveMRZDates(dob, expiry, m.DocumentClass, now); err == nil {
+		d.dob, d.expiredDate = dates.DOB, dates.ExpiredDate
+	} else {
+		d.dob, _ = ParseMRZDOB(dob)
+		d.expiredDate, _ = ParseMRZExpiry(expiry)
+	}
+	return d
+}
+
+func (d document) Class() string          { return d.class }
+func (d document) Type() string           { return d.docType }
+func (d document) IssuingCountry() string { return d.issuingCountry }
+func (d document) FirstName() string      { return d.firstName }
+func (d document) LastName() string       { return d.lastName }
+func (d document) NameTruncated() bool    { return d.nameTruncated }
+func (d document) DocNumber() string      { return d.docNumber }
+func (d document) Nationality() string    { return d.nationality }
+func (d document) Sex() string            { return d.sex }
+func (d document) DOB() time.Time         { return d.dob }
+func (d document) ExpiredDate() time.Time { return d.expiredDate }
+func (d document) OptionalData() []string { return d.optionalData }
+func (d document) IsValid() bool          { return d.isValid }
+
+func (d document) FullName() string {
+	return strings.TrimSpace(d.firstName + " " + d.lastName)
+}
+
+func (d document) MarshalJSON() ([]byte, error) {
+	return json.Marshal(documentJSON{
+		DocumentClass:  d.class,
+		DocumentType:   d.docType,
+		IssuingCountry: d.issuingCountry,
+		FirstName:      d.firstName,
+		LastName:       d.lastName,
+		FullName:       d.FullName(),
+		NameTruncated:  d.nameTruncated,
+		DocNumber:      d.docNumber,
+		Nationality:    d.nationality,
+		Sex:            d.sex,
+		DOB:            d.dob,
+		ExpiredDate:    d.expiredDate,
+		OptionalData:   d.optionalData,
+		IsValid:        d.isValid,
+	})
+}
+
+func nonEmpty(values ...string) []string {
+	ret := []string{}
+	for _, v := range values {
+		if v != "" {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
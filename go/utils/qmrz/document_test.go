@@ -0,0 +1,170 @@
+package qmrz
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type documentFields struct {
+	class, docType, issuingCountry string
+	firstName, lastName, fullName  string
+	nameTruncated                  bool
+	docNumber, nationality, sex    string
+	dob, expiredDate               time.Time
+	optionalData                   []string
+	isValid                        bool
+}
+
+func fieldsOfDocument(d Document) documentFields {
+	return documentFields{
+		d.Class(), d.Type(), d.IssuingCountry(),
+		d.FirstName(), d.LastName(), d.FullName(), d.NameTruncated(),
+		d.DocNumber(), d.Nationality(), d.Sex(),
+		d.DOB(), d.ExpiredDate(),
+		d.OptionalData(),
+		d.IsValid(),
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDocument(t *testing.T) {
+	now := date(2026, 10, 18)
+	tests := []struct {
+		name     string
+		mrz      string
+		want     documentFields
+		wantJSON string
+	}{
+		{
+			"TD1",
+			"I<UTOL898902C36AB12<<<<<<<<<<<\n7408122M3204153NLDXY9<<<<<<<<0\nERIKSSON<<ANNA<MARIA<<<<<<<<<<",
+			documentFields{TD1, "I", "UTO", "ANNA MARIA", "ERIKSSON", "ANNA MARIA ERIKSSON", false,
+				"L898902C3", "NLD", "M", date(1974, 8, 12), date(2032, 4, 15), []string{"AB12", "XY9"}, true},
+			`{"document_class":"TD1","document_type":"I","issuing_country":"UTO","first_name":"ANNA MARIA",` +
+				`"last_name":"ERIKSSON","full_name":"ANNA MARIA ERIKSSON","name_truncated":false,"doc_number":"L898902C3","nationality":"NLD",` +
+				`"sex":"M","dob":"1974-08-12T00:00:00Z","expired_date":"2032-04-15T00:00:00Z",` +
+				`"optional_data":["AB12","XY9"],"is_valid":true}`,
+		},
+		{
+			"TD2",
+			"I<D<<MUSTERMANN<<ERIKA<<<<<<<<<<<<<<\nC01X00T478UTO7408122F3204153AB12<<<8",
+			documentFields{TD2, "I", "D", "ERIKA", "MUSTERMANN", "ERIKA MUSTERMANN", false,
+				"C01X00T47", "UTO", "F", date(1974, 8, 12), date(2032, 4, 15), []string{"AB12"}, true},
+			`{"document_class":"TD2","document_type":"I","issuing_country":"D","first_name":"ERIKA",` +
+				`"last_name":"MUSTERMANN","full_name":"ERIKA MUSTERMANN","name_truncated":false,"doc_number":"C01X00T47","nationality":"UTO",` +
+				`"sex":"F","dob":"1974-08-12T00:00:00Z","expired_date":"2032-04-15T00:00:00Z",` +
+				`"optional_data":["AB12"],"is_valid":true}`,
+		},
+		{
+			"TD3",
+			"P<UTOSMITH<<JOHN<<<<<<<<<<<<<<<<<<<<<<<<<<<<\nL898902C36GBD0102281F3204153AB12<<<<<<<<<<88",
+			documentFields{TD3, "P", "UTO", "JOHN", "SMITH", "JOHN SMITH", false,
+				"L898902C3", "GBD", "F", date(2001, 2, 28), date(2032, 4, 15), []string{"AB12"}, true},
+			`{"document_class":"TD3","document_type":"P","issuing_country":"UTO","first_name":"JOHN",` +
+				`"last_name":"SMITH","full_name":"JOHN SMITH","name_truncated":false,"doc_number":"L898902C3","nationality":"GBD",` +
+				`"sex":"F","dob":"2001-02-28T00:00:00Z","expired_date":"2032-04-15T00:00:00Z",` +
+				`"optional_data":["AB12"],"is_valid":true}`,
+		},
+		{
+			"MRV-A",
+			"V<UTONGUYEN<<VAN<AN<<<<<<<<<<<<<<<<<<<<<<<<<\nL898902C36UTO7408122<3204153<<<<<<<<<<<<<<<<",
+			documentFields{VISA_A, "V", "UTO", "VAN AN", "NGUYEN", "VAN AN NGUYEN", false,
+				"L898902C3", "UTO", "", date(1974, 8, 12), date(2032, 4, 15), []string{}, true},
+			`{"document_class":"MRV-A","document_type":"V","issuing_country":"UTO","first_name":"VAN AN",` +
+				`"last_name":"NGUYEN","full_name":"VAN AN NGUYEN","name_truncated":false,"doc_number":"L898902C3","nationality":"UTO",` +
+				`"sex":"","dob":"1974-08-12T00:00:00Z","expired_date":"2032-04-15T00:00:00Z",` +
+				`"optional_data":[],"is_valid":true}`,
+		},
+		{
+			"MRV-B",
+			"V<EUEMULLER<<HANS<<<<<<<<<<<<<<<<<<<\nL898902C36UTO7408122F3001318AB12<<<<",
+			documentFields{VISA_B, "V", "EUE", "HANS", "MULLER", "HANS MULLER", false,
+				"L898902C3", "UTO", "F", date(1974, 8, 12), date(2030, 1, 31), []string{"AB12"}, true},
+			`{"document_class":"MRV-B","document_type":"V","issuing_country":"EUE","first_name":"HANS",` +
+				`"last_name":"MULLER","full_name":"HANS MULLER","name_truncated":false,"doc_number":"L898902C3","nationality":"UTO",` +
+				`"sex":"F","dob":"1974-08-12T00:00:00Z","expired_date":"2030-01-31T00:00:00Z",` +
+				`"optional_data":["AB12"],"is_valid":true}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMRZ(tt.mrz)
+			if err != nil {
+				t.Fatalf("ParseMRZ() error = %v", err)
+			}
+			d := m.DocumentAt(now)
+			if got := fieldsOfDocument(d); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DocumentAt() = %+v, want %+v", got, tt.want)
+			}
+			b, err := json.Marshal(d)
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+			if string(b) != tt.wantJSON {
+				t.Errorf("MarshalJSON() = %s, want %s", b, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestDocumentNameTruncated(t *testing.T) {
+	tests := []struct {
+		name string
+		mrz  string
+		want bool
+	}{
+		{"TD1", "I<UTOL898902C36AB12<<<<<<<<<<<\n7408122M3204153NLDXY9<<<<<<<<0\nPRAWIRANEGARA<<MUHAMMAD<ABDULL", true},
+		{"TD1 padded", "I<UTOL898902C36AB12<<<<<<<<<<<\n7408122M3204153NLDXY9<<<<<<<<0\nERIKSSON<<ANNA<MARIA<<<<<<<<<<", false},
+		{"TD2", "I<D<<PRAWIRANEGARA<<MUHAMMAD<ABDULLA\nC01X00T478UTO7408122F3204153AB12<<<8", true},
+		{"TD3", "P<UTOPRAWIRANEGARA<<MUHAMMAD<ABDULLAH<SETIAW\nL898902C36GBD0102281F3204153AB12<<<<<<<<<<88", true},
+		{"MRV-A", "V<UTOPRAWIRANEGARA<<MUHAMMAD<ABDULLAH<SETIAW\nL898902C36UTO7408122<3204153<<<<<<<<<<<<<<<<", true},
+		{"MRV-B", "V<EUEPRAWIRANEGARA<<MUHAMMAD<ABDULLA\nL898902C36UTO7408122F3001318AB12<<<<", true},
+		{"MRV-B padded", "V<EUEMULLER<<HANS<<<<<<<<<<<<<<<<<<<\nL898902C36UTO7408122F3001318AB12<<<<", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMRZ(tt.mrz)
+			if err != nil {
+				t.Fatalf("ParseMRZ() error = %v", err)
+			}
+			if got := m.Document().NameTruncated(); got != tt.want {
+				t.Errorf("NameTruncated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentInvalid(t *testing.T) {
+	now := date(2026, 10, 18)
+	tests := []struct {
+		name    string
+		mrz     MRZ
+		dob     time.Time
+		expired time.Time
+	}{
+		{"empty", MRZ{}, time.Time{}, time.Time{}},
+		{"unreadable dates", MRZ{DocumentClass: TD3, Passport: Passport{DOB: "74O812", ExpiredDate: "321345"}},
+			time.Time{}, time.Time{}},
+		{"check digits not verified", MRZ{DocumentClass: TD3, Passport: Passport{DOB: "740812", ExpiredDate: "320415"}},
+			date(1974, 8, 12), date(2032, 4, 15)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.mrz.DocumentAt(now)
+			if d.IsValid() {
+				t.Error("IsValid() = true, want false")
+			}
+			if !d.DOB().Equal(tt.dob) || !d.ExpiredDate().Equal(tt.expired) {
+				t.Errorf("DOB(), ExpiredDate() = %v, %v, want %v, %v", d.DOB(), d.ExpiredDate(), tt.dob, tt.expired)
+			}
+			if got := d.OptionalData(); len(got) != 0 {
+				t.Errorf("OptionalData() = %#v, want empty", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package qmrz
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sentinel errors returned by the MRZ parsers and generators. Parse
+// errors are wrapped in a *FieldError, use errors.Is to test for them.
+var (
+	ErrEmptyMRZ            = errors.New("Empty MRZ")
+	ErrNotSupported        = errors.New("MRZ not supported (Code: 2)")
+	ErrInvalidLineCount    = errors.New("Invalid MRZ line count")
+	ErrInvalidLength       = errors.New("Invalid MRZ (Code: 3)")
+	ErrInvalidName         = errors.New("Invalid characters in name field")
+	ErrInvalidDOB          = errors.New("DOB not valid")
+	ErrInvalidExpiredDate  = errors.New("ExpiredDate not valid")
+	ErrInvalidCountry      = errors.New("Country not valid")
+	ErrInvalidNationality  = errors.New("Nationality not valid")
+	ErrInvalidChecksum     = errors.New("Invalid MRZ Checksum")
+	ErrUnsupportedType     = errors.New("Unsupported MRZ Type")
+	ErrInvalidDocumentType = errors.New("Invalid Document Type")
+	ErrAmbiguousOCR        = errors.New("Ambiguous MRZ OCR reading")
+)
+
+// FieldError locates a parse problem in the MRZ. Field is the json name
+// of the affected field (empty for structural problems), Line and
+// Column are one based. Error() returns the message of the wrapped
+// sentinel.
+type FieldError struct {
+	Err    error  `json:"-"`
+	Code   string `json:"code"`
+	Field  string `json:"field"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// IsStructural reports whether the MRZ could not be read at all (empty,
+// unknown layout, wrong length or line count), which usually calls for
+// a rescan rather than rejecting the document.
+func (e *FieldError) IsStructural() bool {
+	return errors.Is(e.Err, ErrEmptyMRZ) ||
+		errors.Is(e.Err, ErrNotSupported) ||
+		errors.Is(e.Err, ErrInvalidLineCount) ||
+		errors.Is(e.Err, ErrInvalidLength)
+}
+
+// ValidationErrors holds every problem found by ParseMRZAll.
+type ValidationErrors []*FieldError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, e := range v {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (v ValidationErrors) Unwrap() []error {
+	ret := make([]error, 0, len(v))
+	for _, e := range v {
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+var errorCodes = map[error]string{
+	ErrEmptyMRZ:           "empty",
+	ErrNotSupported:       "not_supported",
+	ErrInvalidLineCount:   "invalid_line_count",
+	ErrInvalidLength:      "invalid_length",
+	ErrInvalidName:        "invalid_name",
+	ErrInvalidDOB:         "invalid_dob",
+	ErrInvalidExpiredDate: "invalid_expired_date",
+	ErrInvalidCountry:     "invalid_country",
+	ErrInvalidNationality: "invalid_nationality",
+	ErrInvalidChecksum:    "invalid_checksum",
+	ErrAmbiguousOCR:       "ambiguous_ocr",
+}
+
+type mrzErrors struct {
+	failFast bool
+	list     ValidationErrors
+}
+
+// add records a problem and reports whether parsing should stop.
+func (e *mrzErrors) add(err error, field string, line, column int) bool {
+	e.list = append(e.list, &FieldError{
+		Err:    err,
+		Code:   errorCodes[err],
+		Field:  field,
+		Line:   line,
+		Column: column,
+	})
+	return e.failFast
+}
+
+func (e *mrzErrors) err() error {
+	if len(e.list) == 0 {
+		return nil
+	}
+	if e.failFast {
+		return e.list[0]
+	}
+	return e.list
+}
+
+type checkField struct {
+	field  string
+	check  string
+	name   string
+	line   int
+	column int
+}
+
+// checkDigits verifies every field against its check digit, recording a
+// problem for each mismatch.
+func (e *mrzErrors) checkDigits(fields ...checkField) bool {
+	valid := true
+	for _, f := range fields {
+		if verifyCheckDigit(f.field, f.check) {
+			continue
+		}
+		valid = false
+		if e.add(ErrInvalidChecksum, f.name, f.line, f.column) {
+			break
+		}
+	}
+	return valid
+}
@@ -0,0 +1,125 @@
+package qmrz
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseMRZErrors(t *testing.T) {
+	td3Country := noise(noise(noise(specimenTD3, 0, 2, 'Q'), 0, 3, 'Q'), 0, 4, 'Q')
+	td3Nationality := noise(noise(noise(specimenTD3, 1, 10, 'Q'), 1, 11, 'Q'), 1, 12, 'Q')
+	tests := []struct {
+		name       string
+		mrz        string
+		wantErr    error
+		field      string
+		line       int
+		column     int
+		structural bool
+	}{
+		{"empty", "", ErrEmptyMRZ, "", 1, 1, true},
+		{"blank", " \n ", ErrEmptyMRZ, "", 1, 1, true},
+		{"unknown document type", "X" + specimenTD3[1:], ErrNotSupported, "document_type", 1, 1, true},
+		{"missing line", specimenTD2[:36], ErrInvalidLineCount, "", 1, 1, true},
+		{"short line", specimenTD3[:len(specimenTD3)-4], ErrInvalidLength, "", 2, 41, true},
+		{"name", noise(specimenTD3, 0, 11, '0'), ErrInvalidName, "name", 1, 6, false},
+		{"date of birth", noise(specimenTD3, 1, 15, 'O'), ErrInvalidDOB, "dob", 2, 14, false},
+		{"expiry date", noise(specimenTD3, 1, 23, '9'), ErrInvalidExpiredDate, "expired_date", 2, 22, false},
+		{"country", td3Country, ErrInvalidCountry, "country", 1, 3, false},
+		{"nationality", td3Nationality, ErrInvalidNationality, "nationality", 2, 11, false},
+		{"document number check digit", noise(specimenTD3, 1, 9, '7'), ErrInvalidChecksum, "hash_doc_number", 2, 10, false},
+		{"composite check digit", noise(specimenTD3, 1, 43, '1'), ErrInvalidChecksum, "final_hash", 2, 44, false},
+		{"TD1 optional data check digit", noise(specimenTD1, 1, 29, '1'), ErrInvalidChecksum, "final_hash", 2, 30, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMRZ(tt.mrz)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseMRZ() error = %v, want %v", err, tt.wantErr)
+			}
+			var fe *FieldError
+			if !errors.As(err, &fe) {
+				t.Fatalf("ParseMRZ() error = %T, want *FieldError", err)
+			}
+			if fe.Field != tt.field || fe.Line != tt.line || fe.Column != tt.column {
+				t.Errorf("FieldError at %q %d:%d, want %q %d:%d", fe.Field, fe.Line, fe.Column, tt.field, tt.line, tt.column)
+			}
+			if fe.Code != errorCodes[tt.wantErr] || fe.Code == "" {
+				t.Errorf("FieldError.Code = %q, want %q", fe.Code, errorCodes[tt.wantErr])
+			}
+			if fe.Error() != tt.wantErr.Error() {
+				t.Errorf("FieldError.Error() = %q, want %q", fe.Error(), tt.wantErr.Error())
+			}
+			if fe.IsStructural() != tt.structural {
+				t.Errorf("IsStructural() = %v, want %v", fe.IsStructural(), tt.structural)
+			}
+		})
+	}
+}
+
+func TestParseMRZAll(t *testing.T) {
+	// A misread name, an unknown issuing state and a wrong document
+	// number check digit, which also breaks the composite check digit.
+	mrz := noise(noise(noise(specimenTD3, 0, 11, '0'), 0, 4, 'Q'), 1, 9, '7')
+	want := ValidationErrors{
+		{Err: ErrInvalidName, Code: "invalid_name", Field: "name", Line: 1, Column: 6},
+		{Err: ErrInvalidCountry, Code: "invalid_country", Field: "country", Line: 1, Column: 3},
+		{Err: ErrInvalidChecksum, Code: "invalid_checksum", Field: "hash_doc_number", Line: 2, Column: 10},
+		{Err: ErrInvalidChecksum, Code: "invalid_checksum", Field: "final_hash", Line: 2, Column: 44},
+	}
+
+	_, err := ParseMRZ(mrz)
+	var fe *FieldError
+	if !errors.As(err, &fe) || !reflect.DeepEqual(fe, want[0]) {
+		t.Errorf("ParseMRZ() error = %#v, want %#v", err, want[0])
+	}
+
+	got, err := ParseMRZAll(mrz)
+	var all ValidationErrors
+	if !errors.As(err, &all) {
+		t.Fatalf("ParseMRZAll() error = %T, want ValidationErrors", err)
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("ParseMRZAll() error = %v, want %v", all, want)
+	}
+	for _, sentinel := range []error{ErrInvalidName, ErrInvalidCountry, ErrInvalidChecksum} {
+		if !errors.Is(err, sentinel) {
+			t.Errorf("errors.Is(ParseMRZAll(), %v) = false, want true", sentinel)
+		}
+	}
+	if errors.Is(err, ErrInvalidDOB) {
+		t.Errorf("errors.Is(ParseMRZAll(), %v) = true, want false", ErrInvalidDOB)
+	}
+	const msg = "Invalid characters in name field; Country not valid; Invalid MRZ Checksum; Invalid MRZ Checksum"
+	if err.Error() != msg {
+		t.Errorf("ParseMRZAll() error = %q, want %q", err.Error(), msg)
+	}
+	// The remaining fields are still read.
+	if got.Passport.Country != "UTQ" || got.Passport.DocNumber != "L898902C3" || got.Passport.LastName != "ERIKSS0N" {
+		t.Errorf("ParseMRZAll() = %+v, want every field filled in", got.Passport)
+	}
+
+	b, err := json.Marshal(all[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"code":"invalid_name","field":"name","line":1,"column":6}`; string(b) != want {
+		t.Errorf("json.Marshal(FieldError) = %s, want %s", b, want)
+	}
+
+	if _, err := ParseMRZAll(specimenTD3); err != nil {
+		t.Errorf("ParseMRZAll(specimen) error = %v, want nil", err)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	seen := map[string]error{}
+	for err, code := range errorCodes {
+		if other, ok := seen[code]; ok {
+			t.Errorf("%v and %v share the code %q", err, other, code)
+		}
+		seen[code] = err
+	}
+}
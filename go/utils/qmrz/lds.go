@@ -0,0 +1,218 @@
+package qmrz
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+)
+
+// LDS tags from ICAO 9303 Part 10.
+const (
+	TagEFCOM          = 0x60
+	TagDG1            = 0x61
+	TagMRZData        = 0x5F1F
+	TagLDSVersion     = 0x5F01
+	TagUnicodeVersion = 0x5F36
+	TagTagList        = 0x5C
+)
+
+// Errors returned when decoding LDS data groups.
+var (
+	ErrInvalidTLV  = errors.New("Invalid BER-TLV data")
+	ErrTagNotFound = errors.New("BER-TLV tag not found")
+)
+
+// dataGroupTags maps the EF.COM tag list entries to data group numbers.
+var dataGroupTags = map[byte]int{
+	0x61: 1, 0x75: 2, 0x63: 3, 0x76: 4, 0x65: 5, 0x66: 6, 0x67: 7, 0x68: 8,
+	0x69: 9, 0x6A: 10, 0x6B: 11, 0x6C: 12, 0x6D: 13, 0x6E: 14, 0x6F: 15, 0x70: 16,
+}
+
+type EFCOM struct {
+	LDSVersion     string `json:"lds_version"`
+	UnicodeVersion string `json:"unicode_version"`
+	DataGroups     []int  `json:"data_groups"`
+}
+
+// FieldMismatch is a field whose chip (DG1) value differs from the
+// printed (OCR) value.
+type FieldMismatch struct {
+	Field   string `json:"field"`
+	Chip    string `json:"chip"`
+	Printed string `json:"printed"`
+}
+
+// Mismatches is the result of CompareMRZ.
+type Mismatches []FieldMismatch
+
+// Status returns PassengerStatusChipMismatch when the chip and the
+// printed MRZ disagree, and an empty string when they agree.
+func (m Mismatches) Status() string {
+	if len(m) == 0 {
+		return ""
+	}
+	return qconstant.PassengerStatusChipMismatch
+}
+
+// ParseDG1 extracts the MRZ from a DG1 data group (tag 0x61 wrapping the
+// MRZ data object 0x5F1F) and parses it like an OCR read MRZ.
+func ParseDG1(data []byte) (MRZ, error) {
+	value, err := unwrapTLV(data, TagDG1)
+	if err != nil {
+		return MRZ{}, err
+	}
+	raw, err := findTLV(value, TagMRZData)
+	if err != nil {
+		return MRZ{}, err
+	}
+
+	mrz := string(raw)
+	for _, n := range []int{TD1_CHAR_LEN, TD3_CHAR_LEN, TD2_CHAR_LEN} {
+		lineCount := 2
+		if n == TD1_CHAR_LEN {
+			lineCount = 3
+		}
+		if len(mrz) == n*lineCount {
+			mrz = strings.Join(splitByN(mrz, n), "\n")
+			break
+		}
+	}
+	return ParseMRZ(mrz)
+}
+
+// ParseEFCOM reads the LDS version, Unicode version and the list of data
+// groups present on the chip from EF.COM.
+func ParseEFCOM(data []byte) (EFCOM, error) {
+	var ret EFCOM
+	value, err := unwrapTLV(data, TagEFCOM)
+	if err != nil {
+		return ret, err
+	}
+
+	for len(value) > 0 {
+		tag, v, rest, err := readTLV(value)
+		if err != nil {
+			return ret, err
+		}
+		switch tag {
+		case TagLDSVersion:
+			ret.LDSVersion = string(v)
+		case TagUnicodeVersion:
+			ret.UnicodeVersion = string(v)
+		case TagTagList:
+			for _, b := range v {
+				if dg, ok := dataGroupTags[b]; ok {
+					ret.DataGroups = append(ret.DataGroups, dg)
+				}
+			}
+		}
+		value = rest
+	}
+	return ret, nil
+}
+
+// CompareMRZ reports the fields that differ between the MRZ read from
+// the chip and the printed MRZ. An empty result means both agree.
+func CompareMRZ(chip, printed MRZ) Mismatches {
+	c, p := chip.Document(), printed.Document()
+	fields := []struct {
+		name          string
+		chip, printed string
+	}{
+		{"document_class", c.Class(), p.Class()},
+		{"document_type", c.Type(), p.Type()},
+		{"issuing_country", c.IssuingCountry(), p.IssuingCountry()},
+		{"last_name", c.LastName(), p.LastName()},
+		{"first_name", c.FirstName(), p.FirstName()},
+		{"doc_number", c.DocNumber(), p.DocNumber()},
+		{"nationality", c.Nationality(), p.Nationality()},
+		{"sex", c.Sex(), p.Sex()},
+		{"dob", c.DOB().Format("2006-01-02"), p.DOB().Format("2006-01-02")},
+		{"expired_date", c.ExpiredDate().Format("2006-01-02"), p.ExpiredDate().Format("2006-01-02")},
+		{"optional_data", strings.Join(c.OptionalData(), " "), strings.Join(p.OptionalData(), " ")},
+	}
+
+	ret := Mismatches{}
+	for _, f := range fields {
+		if f.chip != f.printed {
+			ret = append(ret, FieldMismatch{f.name, f.chip, f.printed})
+		}
+	}
+	return ret
+}
+
+// unwrapTLV returns the value of data, which must be a single TLV object
+// with the expected tag.
+func unwrapTLV(data []byte, expected uint32) ([]byte, error) {
+	tag, value, _, err := readTLV(data)
+	if err != nil {
+		return nil, err
+	}
+	if tag != expected {
+		return nil, fmt.Errorf("%w: got %X, want %X", ErrTagNotFound, tag, expected)
+	}
+	return value, nil
+}
+
+// findTLV returns the value of the first object with the given tag in a
+// sequence of TLV objects.
+func findTLV(data []byte, expected uint32) ([]byte, error) {
+	for len(data) > 0 {
+		tag, value, rest, err := readTLV(data)
+		if err != nil {
+			return nil, err
+		}
+		if tag == expected {
+			return value, nil
+		}
+		data = rest
+	}
+	return nil, fmt.Errorf("%w: %X", ErrTagNotFound, expected)
+}
+
+// readTLV decodes one BER-TLV object and returns the remaining bytes.
+func readTLV(data []byte) (uint32, []byte, []byte, error) {
+	if len(data) < 2 {
+		return 0, nil, nil, fmt.Errorf("%w: object too short", ErrInvalidTLV)
+	}
+
+	i := 0
+	tag := uint32(data[i])
+	i++
+	if data[0]&0x1F == 0x1F {
+		for {
+			if i >= len(data) {
+				return 0, nil, nil, fmt.Errorf("%w: truncated tag", ErrInvalidTLV)
+			}
+			tag = tag<<8 | uint32(data[i])
+			i++
+			if data[i-1]&0x80 == 0 {
+				break
+			}
+		}
+	}
+
+	if i >= len(data) {
+		return 0, nil, nil, fmt.Errorf("%w: bad length", ErrInvalidTLV)
+	}
+	length := int(data[i])
+	i++
+	if length&0x80 != 0 {
+		n := length & 0x7F
+		if n == 0 || n > 3 || i+n > len(data) {
+			return 0, nil, nil, fmt.Errorf("%w: bad length", ErrInvalidTLV)
+		}
+		length = 0
+		for _, b := range data[i : i+n] {
+			length = length<<8 | int(b)
+		}
+		i += n
+	}
+
+	if i+length > len(data) {
+		return 0, nil, nil, fmt.Errorf("%w: truncated value", ErrInvalidTLV)
+	}
+	return tag, data[i : i+length], data[i+length:], nil
+}
@@ -0,0 +1,148 @@
+package qmrz
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+)
+
+// dg1 wraps the MRZ lines in a DG1 data group with short form lengths.
+func dg1(mrz string) []byte {
+	raw := strings.ReplaceAll(mrz, "\n", "")
+	data := append([]byte{0x5F, 0x1F, byte(len(raw))}, raw...)
+	return append([]byte{TagDG1, byte(len(data))}, data...)
+}
+
+func TestParseDG1(t *testing.T) {
+	td3 := strings.ReplaceAll(specimenTD3, "\n", "")
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr error
+	}{
+		// 61 5B 5F1F 58 <88 bytes>, the TD3 DG1 of ICAO 9303 Part 10.
+		{"TD3", dg1(specimenTD3), specimenTD3, nil},
+		{"TD1", dg1(specimenTD1), specimenTD1, nil},
+		{"TD2", dg1(specimenTD2), specimenTD2, nil},
+		{"long form lengths",
+			append([]byte{0x61, 0x81, 0x5C, 0x5F, 0x1F, 0x81, 0x58}, td3...), specimenTD3, nil},
+		{"two byte long form length",
+			append([]byte{0x61, 0x82, 0x00, 0x5D, 0x5F, 0x1F, 0x82, 0x00, 0x58}, td3...), specimenTD3, nil},
+		{"other objects before the MRZ",
+			append([]byte{0x61, 0x60, 0x5F, 0x01, 0x02, 0x30, 0x31, 0x5F, 0x1F, 0x58}, td3...), specimenTD3, nil},
+		{"truncated value", dg1(specimenTD3)[:90], "", ErrInvalidTLV},
+		{"truncated inner value",
+			append([]byte{0x61, 0x5A, 0x5F, 0x1F, 0x58}, td3[:87]...), "", ErrInvalidTLV},
+		{"truncated tag", []byte{0x5F}, "", ErrInvalidTLV},
+		{"truncated multi byte tag", []byte{0x5F, 0x9F}, "", ErrInvalidTLV},
+		{"missing length", []byte{0x5F, 0x1F}, "", ErrInvalidTLV},
+		{"indefinite length", []byte{0x61, 0x80, 0x00, 0x00}, "", ErrInvalidTLV},
+		{"long form length too large", []byte{0x61, 0x84, 0x00, 0x00, 0x00, 0x01, 0x00}, "", ErrInvalidTLV},
+		{"truncated long form length", []byte{0x61, 0x82, 0x01}, "", ErrInvalidTLV},
+		{"empty", nil, "", ErrInvalidTLV},
+		{"wrong data group", append([]byte{0x75}, dg1(specimenTD3)[1:]...), "", ErrTagNotFound},
+		{"no MRZ data object", []byte{0x61, 0x04, 0x5F, 0x01, 0x01, 0x30}, "", ErrTagNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDG1(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseDG1() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			want, err := ParseMRZ(tt.want)
+			if err != nil {
+				t.Fatalf("ParseMRZ() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseDG1() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseEFCOM(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    EFCOM
+		wantErr error
+	}{
+		// LDS 1.7, Unicode 4.0.0 with DG1, DG2, DG3 and DG4.
+		{"LDS 1.7",
+			[]byte{0x60, 0x16,
+				0x5F, 0x01, 0x04, 0x30, 0x31, 0x30, 0x37,
+				0x5F, 0x36, 0x06, 0x30, 0x34, 0x30, 0x30, 0x30, 0x30,
+				0x5C, 0x04, 0x61, 0x75, 0x63, 0x76},
+			EFCOM{"0107", "040000", []int{1, 2, 3, 4}}, nil},
+		{"long form length and unknown tags",
+			[]byte{0x60, 0x81, 0x0F,
+				0x5F, 0x01, 0x04, 0x30, 0x31, 0x30, 0x38,
+				0x53, 0x01, 0x00,
+				0x5C, 0x03, 0x61, 0x6E, 0x99},
+			EFCOM{LDSVersion: "0108", DataGroups: []int{1, 14}}, nil},
+		{"no data groups", []byte{0x60, 0x02, 0x5C, 0x00}, EFCOM{}, nil},
+		{"truncated", []byte{0x60, 0x16, 0x5F, 0x01, 0x04, 0x30}, EFCOM{}, ErrInvalidTLV},
+		{"truncated inner object", []byte{0x60, 0x05, 0x5F, 0x01, 0x04, 0x30, 0x31}, EFCOM{}, ErrInvalidTLV},
+		{"DG1 instead of EF.COM", dg1(specimenTD3), EFCOM{}, ErrTagNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEFCOM(tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseEFCOM() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEFCOM() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareMRZ(t *testing.T) {
+	parse := func(mrz string) MRZ {
+		t.Helper()
+		m, _ := ParseMRZAll(mrz)
+		return m
+	}
+	chip, err := ParseDG1(dg1(specimenTD3))
+	if err != nil {
+		t.Fatalf("ParseDG1() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		printed MRZ
+		want    Mismatches
+		status  string
+	}{
+		{"same MRZ", parse(specimenTD3), Mismatches{}, ""},
+		{"name", parse(noise(specimenTD3, 0, 11, '0')),
+			Mismatches{{"last_name", "ERIKSSON", "ERIKSS0N"}}, qconstant.PassengerStatusChipMismatch},
+		{"document number and sex", parse(noise(noise(specimenTD3, 1, 8, '4'), 1, 20, 'M')),
+			Mismatches{{"doc_number", "L898902C3", "L898902C4"}, {"sex", "F", "M"}}, qconstant.PassengerStatusChipMismatch},
+		{"optional data", parse(noise(specimenTD3, 1, 28, 'X')),
+			Mismatches{{"optional_data", "ZE184226B", "XE184226B"}}, qconstant.PassengerStatusChipMismatch},
+		{"expiry date", parse(noise(specimenTD3, 1, 21, '3')),
+			Mismatches{{"expired_date", "2012-04-15", "2032-04-15"}}, qconstant.PassengerStatusChipMismatch},
+		{"another layout", parse(specimenTD1),
+			Mismatches{{"document_class", TD3, TD1}, {"document_type", "P", "I"}, {"doc_number", "L898902C3", "D23145890"},
+				{"optional_data", "ZE184226B", ""}}, qconstant.PassengerStatusChipMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CompareMRZ(chip, tt.printed)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CompareMRZ() = %+v, want %+v", got, tt.want)
+			}
+			if got.Status() != tt.status {
+				t.Errorf("Status() = %q, want %q", got.Status(), tt.status)
+			}
+		})
+	}
+}
@@ -25,22 +25,26 @@ const (
 
 type Passport struct {
 	Country            string `json:"country"`
+	CountryName        string `json:"country_name"`
 	Name               string `json:"name"`
 	FirstName          string `json:"first_name"`
 	LastName           string `json:"last_name"`
 	DocNumber          string `json:"doc_number"`
 	HashDocNumber      string `json:"hash_doc_number"`
 	Nationality        string `json:"nationality"`
+	NationalityName    string `json:"nationality_name"`
 	DOB                string `json:"dob"`
 	HashDOB            string `json:"hash_dob"`
 	Sex                string `json:"sex"`
 	ExpiredDate        string `json:"expired_date"`
 	HashExpiredDate    string `json:"hash_expired_date"`
+	NameTruncated      bool   `json:"name_truncated"`
 	PersonalNumber     string `json:"personal_number"`
 	HashPersonalNumber string `json:"hash_personal_number"`
 	FinalHash          string `json:"final_hash"`
 	ExpectedHash       struct {
 		IsValid            bool   `json:"is_valid"`
+		PassiveAuthValid   bool   `json:"passive_auth_valid"`
 		HashDocNumber      string `json:"hash_doc_number"`
 		HashDOB            string `json:"hash_dob"`
 		HashExpiredDate    string `json:"hash_expired_date"`
@@ -49,6 +53,8 @@ type Passport struct {
 		DOBValid           bool   `json:"dob_valid"`
 		ExpiredDateValid   bool   `json:"expired_date_valid"`
 		NameValid          bool   `json:"name_valid"`
+		CountryValid       bool   `json:"country_valid"`
+		NationalityValid   bool   `json:"nationality_valid"`
 	} `json:"expected_hash"`
 }
 
@@ -58,6 +64,7 @@ type MRZ struct {
 	Passport      Passport `json:"passport"`
 	TD1           struct {
 		Country         string `json:"country"`
+		CountryName     string `json:"country_name"`
 		DocNumber       string `json:"doc_number"`
 		HashDocNumber   string `json:"hash_doc_number"`
 		AdditionalInfo1 string `json:"additional_info_1"`
@@ -67,13 +74,16 @@ type MRZ struct {
 		ExpiredDate     string `json:"expired_date"`
 		HashExpiredDate string `json:"hash_expired_date"`
 		Nationality     string `json:"nationality"`
+		NationalityName string `json:"nationality_name"`
 		AdditionalInfo2 string `json:"additional_info_2"`
 		FinalHash       string `json:"final_hash"`
 		Name            string `json:"name"`
 		FirstName       string `json:"first_name"`
 		LastName        string `json:"last_name"`
+		NameTruncated   bool   `json:"name_truncated"`
 		ExpectedHash    struct {
 			IsValid          bool   `json:"is_valid"`
+			PassiveAuthValid bool   `json:"passive_auth_valid"`
 			HashDocNumber    string `json:"hash_doc_number"`
 			HashDOB          string `json:"hash_dob"`
 			HashExpiredDate  string `json:"hash_expired_date"`
@@ -81,14 +91,21 @@ type MRZ struct {
 			DOBValid         bool   `json:"dob_valid"`
 			ExpiredDateValid bool   `json:"expired_date_valid"`
 			NameValid        bool   `json:"name_valid"`
+			CountryValid     bool   `json:"country_valid"`
+			NationalityValid bool   `json:"nationality_valid"`
 		} `json:"expected_hash"`
 	} `json:"td1"`
 	TD2 struct {
 		Country         string `json:"country"`
+		CountryName     string `json:"country_name"`
 		Name            string `json:"name"`
+		FirstName       string `json:"first_name"`
+		LastName        string `json:"last_name"`
+		NameTruncated   bool   `json:"name_truncated"`
 		DocNumber       string `json:"doc_number"`
 		HashDocNumber   string `json:"hash_doc_number"`
 		Nationality     string `json:"nationality"`
+		NationalityName string `json:"nationality_name"`
 		DOB             string `json:"dob"`
 		HashDOB         string `json:"hash_dob"`
 		Sex             string `json:"sex"`
@@ -98,6 +115,7 @@ type MRZ struct {
 		FinalHash       string `json:"final_hash"`
 		ExpectedHash    struct {
 			IsValid          bool   `json:"is_valid"`
+			PassiveAuthValid bool   `json:"passive_auth_valid"`
 			HashDocNumber    string `json:"hash_doc_number"`
 			HashDOB          string `json:"hash_dob"`
 			HashExpiredDate  string `json:"hash_expired_date"`
@@ -105,14 +123,21 @@ type MRZ struct {
 			DOBValid         bool   `json:"dob_valid"`
 			ExpiredDateValid bool   `json:"expired_date_valid"`
 			NameValid        bool   `json:"name_valid"`
+			CountryValid     bool   `json:"country_valid"`
+			NationalityValid bool   `json:"nationality_valid"`
 		} `json:"expected_hash"`
 	} `json:"td2"`
 	VISAA struct {
 		Country         string `json:"country"`
+		CountryName     string `json:"country_name"`
 		Name            string `json:"name"`
+		FirstName       string `json:"first_name"`
+		LastName        string `json:"last_name"`
+		NameTruncated   bool   `json:"name_truncated"`
 		DocNumber       string `json:"doc_number"`
 		HashDocNumber   string `json:"hash_doc_number"`
 		Nationality     string `json:"nationality"`
+		NationalityName string `json:"nationality_name"`
 		DOB             string `json:"dob"`
 		HashDOB         string `json:"hash_dob"`
 		Sex             string `json:"sex"`
@@ -120,18 +145,28 @@ type MRZ struct {
 		HashExpiredDate string `json:"hash_expired_date"`
 		AdditionalInfo  string `json:"additional_info"`
 		ExpectedHash    struct {
-			IsValid         bool   `json:"is_valid"`
-			HashDocNumber   string `json:"hash_doc_number"`
-			HashDOB         string `json:"hash_dob"`
-			HashExpiredDate string `json:"hash_expired_date"`
+			IsValid          bool   `json:"is_valid"`
+			HashDocNumber    string `json:"hash_doc_number"`
+			HashDOB          string `json:"hash_dob"`
+			HashExpiredDate  string `json:"hash_expired_date"`
+			DOBValid         bool   `json:"dob_valid"`
+			ExpiredDateValid bool   `json:"expired_date_valid"`
+			NameValid        bool   `json:"name_valid"`
+			CountryValid     bool   `json:"country_valid"`
+			NationalityValid bool   `json:"nationality_valid"`
 		} `json:"expected_hash"`
 	} `json:"visa_a"`
 	VISAB struct {
 		Country         string `json:"country"`
+		CountryName     string `json:"country_name"`
 		Name            string `json:"name"`
+		FirstName       string `json:"first_name"`
+		LastName        string `json:"last_name"`
+		NameTruncated   bool   `json:"name_truncated"`
 		DocNumber       string `json:"doc_number"`
 		HashDocNumber   string `json:"hash_doc_number"`
 		Nationality     string `json:"nationality"`
+		NationalityName string `json:"nationality_name"`
 		DOB             string `json:"dob"`
 		HashDOB         string `json:"hash_dob"`
 		Sex             string `json:"sex"`
@@ -139,10 +174,15 @@ type MRZ struct {
 		HashExpiredDate string `json:"hash_expired_date"`
 		AdditionalInfo  string `json:"additional_info"`
 		ExpectedHash    struct {
-			IsValid         bool   `json:"is_valid"`
-			HashDocNumber   string `json:"hash_doc_number"`
-			HashDOB         string `json:"hash_dob"`
-			HashExpiredDate string `json:"hash_expired_date"`
+			IsValid          bool   `json:"is_valid"`
+			HashDocNumber    string `json:"hash_doc_number"`
+			HashDOB          string `json:"hash_dob"`
+			HashExpiredDate  string `json:"hash_expired_date"`
+			DOBValid         bool   `json:"dob_valid"`
+			ExpiredDateValid bool   `json:"expired_date_valid"`
+			NameValid        bool   `json:"name_valid"`
+			CountryValid     bool   `json:"country_valid"`
+			NationalityValid bool   `json:"nationality_valid"`
 		} `json:"expected_hash"`
 	} `json:"visa_b"`
 }
@@ -275,8 +315,8 @@ func padRight(s string, length int, pad rune) string {
 
 func filterMRZChars(s string) string {
 	var result strings.Builder
-	for _, r := range s {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+	for _, r := range Transliterate(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
 			result.WriteRune(r)
 		} else {
 			result.WriteRune('<')
@@ -296,12 +336,6 @@ func parseName(name string) (string, string) {
 	return parts[0], strings.Join(parts[1:], "<")
 }
 
-func formatNameGen(s string) string {
-	s = strings.ToUpper(s)
-	s = strings.ReplaceAll(s, " ", "<")
-	return filterMRZChars(s)
-}
-
 func formatField(s string, max int) string {
 	s = strings.ToUpper(s)
 	s = filterMRZChars(s)
@@ -311,28 +345,96 @@ func formatField(s string, max int) string {
 	return padRight(s, max, '<')
 }
 
+// nameFieldLengths is the length of the name field of each layout.
+var nameFieldLengths = map[string]int{
+	TD1:    30,
+	TD2:    31,
+	TD3:    39,
+	VISA_A: 39,
+	VISA_B: 31,
+}
+
+func formatNameField(name string, max int) (string, bool) {
+	surname, given := parseName(name)
+	return FormatMRZName(surname, given, max)
+}
+
+func nameField(name string, mrzType string) string {
+	field, _ := formatNameField(name, nameFieldLengths[mrzType])
+	return field
+}
+
+// NameTruncated reports whether GenerateMRZ of the given type has to
+// truncate name, given as the surname followed by the given names.
+// Parsing the generated MRZ reports the same in Document.NameTruncated.
+func NameTruncated(mrzType string, name string) (bool, error) {
+	length, ok := nameFieldLengths[mrzType]
+	if !ok {
+		return false, ErrUnsupportedType
+	}
+	_, truncated := formatNameField(name, length)
+	return truncated, nil
+}
+
+func formatSex(sex string) string {
+	if len(sex) == 0 {
+		return "<"
+	}
+	return strings.ToUpper(string(sex[0]))
+}
+
+func formatDocType(docType string, allowed string, fallback string) (string, error) {
+	if docType == "" {
+		return fallback, nil
+	}
+	docType = formatField(docType, 2)
+	if !strings.ContainsRune(allowed, rune(docType[0])) {
+		return "", ErrInvalidDocumentType
+	}
+	return docType, nil
+}
+
 // --- Generate functions ---
 
 func GenerateMRZ(mrzType string, mrz MRZ) (string, error) {
-	if mrzType != TD3 {
-		return "", errors.New("Unsupported MRZ Type")
-	}
-	line1, line2, err := GenerateMRZPassport(mrz.Passport)
-	if err != nil {
-		return "", err
+	switch mrzType {
+	case TD1:
+		line1, line2, line3, err := GenerateMRZTD1(mrz)
+		if err != nil {
+			return "", err
+		}
+		return line1 + "\n" + line2 + "\n" + line3, nil
+	case TD2:
+		line1, line2, err := GenerateMRZTD2(mrz)
+		if err != nil {
+			return "", err
+		}
+		return line1 + "\n" + line2, nil
+	case TD3:
+		line1, line2, err := GenerateMRZPassport(mrz.Passport)
+		if err != nil {
+			return "", err
+		}
+		return line1 + "\n" + line2, nil
+	case VISA_A:
+		line1, line2, err := GenerateMRZVISAA(mrz)
+		if err != nil {
+			return "", err
+		}
+		return line1 + "\n" + line2, nil
+	case VISA_B:
+		line1, line2, err := GenerateMRZVISAB(mrz)
+		if err != nil {
+			return "", err
+		}
+		return line1 + "\n" + line2, nil
+	default:
+		return "", ErrUnsupportedType
 	}
-	return line1 + "\n" + line2, nil
 }
 
 func GenerateMRZPassport(p Passport) (string, string, error) {
-	surname, given := parseName(p.Name)
-
-	line1 := fmt.Sprintf("P<%s%s<<%s",
-		formatField(p.Country, 3),
-		formatNameGen(surname),
-		formatNameGen(given),
-	)
-	line1 = padRight(line1, 44, '<')
+	line1 := "P<" + formatField(p.Country, 3) + nameField(p.Name, TD3)
 
 	dob := formatDate(p.DOB)
 	exp := formatDate(p.ExpiredDate)
@@ -379,20 +481,169 @@ func GenerateMRZPassport(p Passport) (string, string, error) {
 	return line1, line2, nil
 }
 
+// GenerateMRZTD1 builds the three 30 character lines of a TD1 (ID card)
+// MRZ from mrz.TD1. Dates are expected in "2006-01-02" format.
+func GenerateMRZTD1(mrz MRZ) (string, string, string, error) {
+	d := mrz.TD1
+	docType, err := formatDocType(mrz.DocumentType, "ACI", "I<")
+	if err != nil {
+		return "", "", "", err
+	}
+
+	docNumber := formatField(d.DocNumber, 9)
+	docCheck := computeCheckDigit(docNumber)
+	line1 := fmt.Sprintf("%s%s%s%d%s",
+		docType,
+		formatField(d.Country, 3),
+		docNumber,
+		docCheck,
+		formatField(d.AdditionalInfo1, 15),
+	)
+
+	dob := formatDate(d.DOB)
+	exp := formatDate(d.ExpiredDate)
+	dobCheck := computeCheckDigit(dob)
+	expCheck := computeCheckDigit(exp)
+	additionalInfo2 := formatField(d.AdditionalInfo2, 11)
+
+	line2 := fmt.Sprintf("%s%d%s%s%d%s%s",
+		dob,
+		dobCheck,
+		formatSex(d.Sex),
+		exp,
+		expCheck,
+		formatField(d.Nationality, 3),
+		additionalInfo2,
+	)
+
+	compositeCheckField := line1[5:30] + line2[:7] + line2[8:15] + line2[18:29]
+	finalCheck := computeCheckDigit(compositeCheckField)
+	line2 = fmt.Sprintf("%s%d", line2, finalCheck)
+
+	line3 := nameField(d.Name, TD1)
+
+	return line1, line2, line3, nil
+}
+
+// GenerateMRZTD2 builds the two 36 character lines of a TD2 MRZ from
+// mrz.TD2. Dates are expected in "2006-01-02" format.
+func GenerateMRZTD2(mrz MRZ) (string, string, error) {
+	d := mrz.TD2
+	docType, err := formatDocType(mrz.DocumentType, "ACI", "I<")
+	if err != nil {
+		return "", "", err
+	}
+
+	line1 := docType + formatField(d.Country, 3) + nameField(d.Name, TD2)
+
+	docNumber := formatField(d.DocNumber, 9)
+	dob := formatDate(d.DOB)
+	exp := formatDate(d.ExpiredDate)
+	docCheck := computeCheckDigit(docNumber)
+	dobCheck := computeCheckDigit(dob)
+	expCheck := computeCheckDigit(exp)
+
+	line2 := fmt.Sprintf("%s%d%s%s%d%s%s%d%s",
+		docNumber,
+		docCheck,
+		formatField(d.Nationality, 3),
+		dob,
+		dobCheck,
+		formatSex(d.Sex),
+		exp,
+		expCheck,
+		formatField(d.AdditionalInfo, 7),
+	)
+
+	compositeCheckField := line2[:10] + line2[13:20] + line2[21:35]
+	finalCheck := computeCheckDigit(compositeCheckField)
+	line2 = fmt.Sprintf("%s%d", line2, finalCheck)
+
+	return line1, line2, nil
+}
+
+// GenerateMRZVISAA builds the two 44 character lines of a MRV-A visa MRZ
+// from mrz.VISAA. Dates are expected in "2006-01-02" format.
+func GenerateMRZVISAA(mrz MRZ) (string, string, error) {
+	d := mrz.VISAA
+	docType, err := formatDocType(mrz.DocumentType, "V", "V<")
+	if err != nil {
+		return "", "", err
+	}
+
+	line1 := docType + formatField(d.Country, 3) + nameField(d.Name, VISA_A)
+	line2 := generateVisaLine2(d.DocNumber, d.Nationality, d.DOB, d.Sex, d.ExpiredDate, d.AdditionalInfo, 16)
+
+	return line1, line2, nil
+}
+
+// GenerateMRZVISAB builds the two 36 character lines of a MRV-B visa MRZ
+// from mrz.VISAB. Dates are expected in "2006-01-02" format.
+func GenerateMRZVISAB(mrz MRZ) (string, string, error) {
+	d := mrz.VISAB
+	docType, err := formatDocType(mrz.DocumentType, "V", "V<")
+	if err != nil {
+		return "", "", err
+	}
+
+	line1 := docType + formatField(d.Country, 3) + nameField(d.Name, VISA_B)
+	line2 := generateVisaLine2(d.DocNumber, d.Nationality, d.DOB, d.Sex, d.ExpiredDate, d.AdditionalInfo, 8)
+
+	return line1, line2, nil
+}
+
+func generateVisaLine2(docNumber, nationality, dob, sex, expiredDate, additionalInfo string, additionalLen int) string {
+	docNumber = formatField(docNumber, 9)
+	dob = formatDate(dob)
+	exp := formatDate(expiredDate)
+
+	return fmt.Sprintf("%s%d%s%s%d%s%s%d%s",
+		docNumber,
+		computeCheckDigit(docNumber),
+		formatField(nationality, 3),
+		dob,
+		computeCheckDigit(dob),
+		formatSex(sex),
+		exp,
+		computeCheckDigit(exp),
+		formatField(additionalInfo, additionalLen),
+	)
+}
+
 // --- Parse functions ---
 
+// ParseMRZ parses an MRZ and stops at the first problem found. Errors
+// are *FieldError values wrapping one of the Err* sentinels.
 func ParseMRZ(mrz string) (ret MRZ, err error) {
+	return parseMRZ(mrz, true)
+}
+
+// ParseMRZAll parses an MRZ without stopping at validation problems so
+// every field of the result is filled in. All problems are returned
+// together as ValidationErrors; structural problems that prevent reading
+// the remaining fields still end the parse early.
+func ParseMRZAll(mrz string) (ret MRZ, err error) {
+	return parseMRZ(mrz, false)
+}
+
+func parseMRZ(mrz string, failFast bool) (ret MRZ, err error) {
+	errs := &mrzErrors{failFast: failFast}
 	if len(mrz) == 0 {
-		return ret, errors.New("Empty MRZ")
+		errs.add(ErrEmptyMRZ, "", 1, 1)
+		return ret, errs.err()
 	}
 	arr := strings.Split(strings.TrimSpace(mrz), "\n")
+	for i := range arr {
+		arr[i] = strings.TrimSpace(arr[i])
+	}
 
 	if len(arr[0]) == 0 {
-		return ret, errors.New("Empty MRZ")
+		errs.add(ErrEmptyMRZ, "", 1, 1)
+		return ret, errs.err()
 	}
 
 	docType := rune(arr[0][0])
-	charLen := len(strings.TrimSpace(arr[0]))
+	charLen := len(arr[0])
 
 	switch docType {
 	case 'A', 'B', 'C', 'I':
@@ -405,17 +656,18 @@ func ParseMRZ(mrz string) (ret MRZ, err error) {
 		}
 		switch len(arr) {
 		case 3:
-			return TD1MRZ(arr, ret)
+			return td1MRZ(arr, ret, errs)
 		case 2:
-			return TD2MRZ(arr, ret)
+			return td2MRZ(arr, ret, errs)
 		default:
-			return ret, fmt.Errorf("Invalid MRZ line count")
+			errs.add(ErrInvalidLineCount, "", len(arr), 1)
+			return ret, errs.err()
 		}
 	case 'P':
 		if len(arr[0]) > TD3_CHAR_LEN {
 			arr = splitByN(arr[0], TD3_CHAR_LEN)
 		}
-		return PassportMRZ(arr, ret)
+		return passportMRZ(arr, ret, errs)
 	case 'V':
 		if len(arr[0]) > VISA_A_CHAR_LEN {
 			if charLen == (2 * VISA_A_CHAR_LEN) {
@@ -424,30 +676,37 @@ func ParseMRZ(mrz string) (ret MRZ, err error) {
 				arr = splitByN(arr[0], VISA_B_CHAR_LEN)
 			}
 		}
-		if charLen == VISA_A_CHAR_LEN {
-			return VISAAMRZ(arr, ret)
+		if len(arr[0]) == VISA_A_CHAR_LEN {
+			return visaAMRZ(arr, ret, errs)
 		}
-		return VISABMRZ(arr, ret)
+		return visaBMRZ(arr, ret, errs)
 	default:
-		return ret, fmt.Errorf("MRZ not supported (Code: 2)")
+		errs.add(ErrNotSupported, "document_type", 1, 1)
+		return ret, errs.err()
 	}
 }
 
 func PassportMRZ(data []string, ret MRZ) (MRZ, error) {
+	return passportMRZ(data, ret, &mrzErrors{failFast: true})
+}
+
+func passportMRZ(data []string, ret MRZ, errs *mrzErrors) (MRZ, error) {
 	if len(data) < 2 {
-		return ret, fmt.Errorf("Invalid MRZ (Code: 3)")
+		errs.add(ErrInvalidLineCount, "", len(data), 1)
+		return ret, errs.err()
 	}
 	data[0] = strings.TrimSpace(data[0])
 	if len(data[0]) < TD3_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 1 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 1, len(data[0])+1)
+		return ret, errs.err()
 	}
 	ret.DocumentType = clear(data[0][:2])
 	ret.DocumentClass = TD3
 	ret.Passport.Country = clear(data[0][2:5])
 	rawName := data[0][5:44]
 	ret.Passport.ExpectedHash.NameValid = isValidICAOName(rawName)
-	if !ret.Passport.ExpectedHash.NameValid {
-		return ret, fmt.Errorf("Invalid characters in name field")
+	if !ret.Passport.ExpectedHash.NameValid && errs.add(ErrInvalidName, "name", 1, 6) {
+		return ret, errs.err()
 	}
 	parts := strings.SplitN(rawName, "<<", 2)
 	if len(parts) > 0 {
@@ -457,10 +716,12 @@ func PassportMRZ(data []string, ret MRZ) (MRZ, error) {
 		ret.Passport.FirstName = clear(parts[1])
 	}
 	ret.Passport.Name = strings.TrimSpace(ret.Passport.FirstName + " " + ret.Passport.LastName)
+	ret.Passport.NameTruncated = isTruncatedName(rawName)
 
 	data[1] = strings.TrimSpace(data[1])
 	if len(data[1]) < TD3_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 2 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 2, len(data[1])+1)
+		return ret, errs.err()
 	}
 	line2 := data[1]
 	docNumberField := line2[:9]
@@ -482,12 +743,23 @@ func PassportMRZ(data []string, ret MRZ) (MRZ, error) {
 	ret.Passport.FinalHash = clear(line2[43:])
 
 	ret.Passport.ExpectedHash.DOBValid = isValidICAODate(dobField)
-	if !ret.Passport.ExpectedHash.DOBValid {
-		return ret, fmt.Errorf("DOB not valid")
+	if !ret.Passport.ExpectedHash.DOBValid && errs.add(ErrInvalidDOB, "dob", 2, 14) {
+		return ret, errs.err()
 	}
 	ret.Passport.ExpectedHash.ExpiredDateValid = isValidICAODate(expiryField)
-	if !ret.Passport.ExpectedHash.ExpiredDateValid {
-		return ret, fmt.Errorf("ExpiredDate not valid")
+	if !ret.Passport.ExpectedHash.ExpiredDateValid && errs.add(ErrInvalidExpiredDate, "expired_date", 2, 22) {
+		return ret, errs.err()
+	}
+
+	ret.Passport.CountryName = CountryName(ret.Passport.Country)
+	ret.Passport.NationalityName = CountryName(ret.Passport.Nationality)
+	ret.Passport.ExpectedHash.CountryValid = IsValidCountryCode(ret.Passport.Country)
+	if !ret.Passport.ExpectedHash.CountryValid && errs.add(ErrInvalidCountry, "country", 1, 3) {
+		return ret, errs.err()
+	}
+	ret.Passport.ExpectedHash.NationalityValid = IsValidCountryCode(ret.Passport.Nationality)
+	if !ret.Passport.ExpectedHash.NationalityValid && errs.add(ErrInvalidNationality, "nationality", 2, 11) {
+		return ret, errs.err()
 	}
 
 	ret.Passport.ExpectedHash.HashDocNumber = strconv.Itoa(computeCheckDigit(docNumberField))
@@ -495,26 +767,30 @@ func PassportMRZ(data []string, ret MRZ) (MRZ, error) {
 	ret.Passport.ExpectedHash.HashExpiredDate = strconv.Itoa(computeCheckDigit(expiryField))
 	ret.Passport.ExpectedHash.HashPersonalNumber = strconv.Itoa(computeCheckDigit(personalNumberField))
 	ret.Passport.ExpectedHash.FinalHash = strconv.Itoa(computeCheckDigit(compositeField))
-	ret.Passport.ExpectedHash.IsValid = verifyCheckDigit(docNumberField, line2[9:10]) &&
-		verifyCheckDigit(dobField, line2[19:20]) &&
-		verifyCheckDigit(expiryField, line2[27:28]) &&
-		verifyCheckDigit(personalNumberField, line2[42:43]) &&
-		verifyCheckDigit(compositeField, line2[43:44])
-
-	if !ret.Passport.ExpectedHash.IsValid {
-		return ret, fmt.Errorf("Invalid MRZ Checksum")
-	}
+	ret.Passport.ExpectedHash.IsValid = errs.checkDigits(
+		checkField{docNumberField, line2[9:10], "hash_doc_number", 2, 10},
+		checkField{dobField, line2[19:20], "hash_dob", 2, 20},
+		checkField{expiryField, line2[27:28], "hash_expired_date", 2, 28},
+		checkField{personalNumberField, line2[42:43], "hash_personal_number", 2, 43},
+		checkField{compositeField, line2[43:44], "final_hash", 2, 44},
+	)
 
-	return ret, nil
+	return ret, errs.err()
 }
 
 func TD1MRZ(data []string, ret MRZ) (MRZ, error) {
+	return td1MRZ(data, ret, &mrzErrors{failFast: true})
+}
+
+func td1MRZ(data []string, ret MRZ, errs *mrzErrors) (MRZ, error) {
 	if len(data) < 3 {
-		return ret, fmt.Errorf("Invalid MRZ (Code: 3)")
+		errs.add(ErrInvalidLineCount, "", len(data), 1)
+		return ret, errs.err()
 	}
 	data[0] = strings.TrimSpace(data[0])
 	if len(data[0]) < TD1_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 1 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 1, len(data[0])+1)
+		return ret, errs.err()
 	}
 	ret.DocumentType = clear(data[0][:2])
 	ret.DocumentClass = TD1
@@ -526,7 +802,8 @@ func TD1MRZ(data []string, ret MRZ) (MRZ, error) {
 
 	data[1] = strings.TrimSpace(data[1])
 	if len(data[1]) < TD1_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 2 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 2, len(data[1])+1)
+		return ret, errs.err()
 	}
 	dobField := data[1][:6]
 	expiryField := data[1][8:14]
@@ -540,12 +817,23 @@ func TD1MRZ(data []string, ret MRZ) (MRZ, error) {
 	ret.TD1.FinalHash = clear(data[1][29:])
 
 	ret.TD1.ExpectedHash.DOBValid = isValidICAODate(dobField)
-	if !ret.TD1.ExpectedHash.DOBValid {
-		return ret, fmt.Errorf("DOB not valid")
+	if !ret.TD1.ExpectedHash.DOBValid && errs.add(ErrInvalidDOB, "dob", 2, 1) {
+		return ret, errs.err()
 	}
 	ret.TD1.ExpectedHash.ExpiredDateValid = isValidICAODate(expiryField)
-	if !ret.TD1.ExpectedHash.ExpiredDateValid {
-		return ret, fmt.Errorf("ExpiredDate not valid")
+	if !ret.TD1.ExpectedHash.ExpiredDateValid && errs.add(ErrInvalidExpiredDate, "expired_date", 2, 9) {
+		return ret, errs.err()
+	}
+
+	ret.TD1.CountryName = CountryName(ret.TD1.Country)
+	ret.TD1.NationalityName = CountryName(ret.TD1.Nationality)
+	ret.TD1.ExpectedHash.CountryValid = IsValidCountryCode(ret.TD1.Country)
+	if !ret.TD1.ExpectedHash.CountryValid && errs.add(ErrInvalidCountry, "country", 1, 3) {
+		return ret, errs.err()
+	}
+	ret.TD1.ExpectedHash.NationalityValid = IsValidCountryCode(ret.TD1.Nationality)
+	if !ret.TD1.ExpectedHash.NationalityValid && errs.add(ErrInvalidNationality, "nationality", 2, 16) {
+		return ret, errs.err()
 	}
 
 	compositeField := data[0][5:30] + data[1][:7] + data[1][8:15] + data[1][18:29]
@@ -553,23 +841,26 @@ func TD1MRZ(data []string, ret MRZ) (MRZ, error) {
 	ret.TD1.ExpectedHash.HashDOB = strconv.Itoa(computeCheckDigit(dobField))
 	ret.TD1.ExpectedHash.HashExpiredDate = strconv.Itoa(computeCheckDigit(expiryField))
 	ret.TD1.ExpectedHash.FinalHash = strconv.Itoa(computeCheckDigit(compositeField))
-	ret.TD1.ExpectedHash.IsValid = verifyCheckDigit(docNumberField, data[0][14:15]) &&
-		verifyCheckDigit(dobField, data[1][6:7]) &&
-		verifyCheckDigit(expiryField, data[1][14:15]) &&
-		verifyCheckDigit(compositeField, data[1][29:30])
+	ret.TD1.ExpectedHash.IsValid = errs.checkDigits(
+		checkField{docNumberField, data[0][14:15], "hash_doc_number", 1, 15},
+		checkField{dobField, data[1][6:7], "hash_dob", 2, 7},
+		checkField{expiryField, data[1][14:15], "hash_expired_date", 2, 15},
+		checkField{compositeField, data[1][29:30], "final_hash", 2, 30},
+	)
 
 	data[2] = strings.TrimSpace(data[2])
 	if len(data[2]) < TD1_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 3 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 3, len(data[2])+1)
+		return ret, errs.err()
 	}
 
-	if !ret.TD1.ExpectedHash.IsValid {
-		return ret, fmt.Errorf("Invalid MRZ Checksum")
+	if !ret.TD1.ExpectedHash.IsValid && errs.failFast {
+		return ret, errs.err()
 	}
 
 	ret.TD1.ExpectedHash.NameValid = isValidICAOName(data[2])
-	if !ret.TD1.ExpectedHash.NameValid {
-		return ret, fmt.Errorf("Invalid characters in name field")
+	if !ret.TD1.ExpectedHash.NameValid && errs.add(ErrInvalidName, "name", 3, 1) {
+		return ret, errs.err()
 	}
 	parts := strings.SplitN(data[2], "<<", 2)
 	if len(parts) > 0 {
@@ -579,27 +870,46 @@ func TD1MRZ(data []string, ret MRZ) (MRZ, error) {
 		ret.TD1.FirstName = clear(parts[1])
 	}
 	ret.TD1.Name = strings.TrimSpace(ret.TD1.FirstName + " " + ret.TD1.LastName)
-	return ret, nil
+	ret.TD1.NameTruncated = isTruncatedName(data[2])
+	return ret, errs.err()
 }
 
 func TD2MRZ(data []string, ret MRZ) (MRZ, error) {
+	return td2MRZ(data, ret, &mrzErrors{failFast: true})
+}
+
+func td2MRZ(data []string, ret MRZ, errs *mrzErrors) (MRZ, error) {
+	if len(data) < 2 {
+		errs.add(ErrInvalidLineCount, "", len(data), 1)
+		return ret, errs.err()
+	}
 	data[0] = strings.TrimSpace(data[0])
 	if len(data[0]) < TD2_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 1 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 1, len(data[0])+1)
+		return ret, errs.err()
 	}
 	ret.DocumentType = clear(data[0][:2])
 	ret.DocumentClass = TD2
 	ret.TD2.Country = clear(data[0][2:5])
 	rawName := data[0][5:]
 	ret.TD2.ExpectedHash.NameValid = isValidICAOName(rawName)
-	if !ret.TD2.ExpectedHash.NameValid {
-		return ret, fmt.Errorf("Invalid characters in name field")
+	if !ret.TD2.ExpectedHash.NameValid && errs.add(ErrInvalidName, "name", 1, 6) {
+		return ret, errs.err()
 	}
 	ret.TD2.Name = clear(rawName)
+	parts := strings.SplitN(rawName, "<<", 2)
+	if len(parts) > 0 {
+		ret.TD2.LastName = clear(parts[0])
+	}
+	if len(parts) > 1 {
+		ret.TD2.FirstName = clear(parts[1])
+	}
+	ret.TD2.NameTruncated = isTruncatedName(rawName)
 
 	data[1] = strings.TrimSpace(data[1])
 	if len(data[1]) < TD2_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 2 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 2, len(data[1])+1)
+		return ret, errs.err()
 	}
 	docNumberField := data[1][:9]
 	dobField := data[1][13:19]
@@ -616,12 +926,23 @@ func TD2MRZ(data []string, ret MRZ) (MRZ, error) {
 	ret.TD2.FinalHash = clear(data[1][35:])
 
 	ret.TD2.ExpectedHash.DOBValid = isValidICAODate(dobField)
-	if !ret.TD2.ExpectedHash.DOBValid {
-		return ret, fmt.Errorf("DOB not valid")
+	if !ret.TD2.ExpectedHash.DOBValid && errs.add(ErrInvalidDOB, "dob", 2, 14) {
+		return ret, errs.err()
 	}
 	ret.TD2.ExpectedHash.ExpiredDateValid = isValidICAODate(expiryField)
-	if !ret.TD2.ExpectedHash.ExpiredDateValid {
-		return ret, fmt.Errorf("ExpiredDate not valid")
+	if !ret.TD2.ExpectedHash.ExpiredDateValid && errs.add(ErrInvalidExpiredDate, "expired_date", 2, 22) {
+		return ret, errs.err()
+	}
+
+	ret.TD2.CountryName = CountryName(ret.TD2.Country)
+	ret.TD2.NationalityName = CountryName(ret.TD2.Nationality)
+	ret.TD2.ExpectedHash.CountryValid = IsValidCountryCode(ret.TD2.Country)
+	if !ret.TD2.ExpectedHash.CountryValid && errs.add(ErrInvalidCountry, "country", 1, 3) {
+		return ret, errs.err()
+	}
+	ret.TD2.ExpectedHash.NationalityValid = IsValidCountryCode(ret.TD2.Nationality)
+	if !ret.TD2.ExpectedHash.NationalityValid && errs.add(ErrInvalidNationality, "nationality", 2, 11) {
+		return ret, errs.err()
 	}
 
 	compositeField := data[1][:10] + data[1][13:20] + data[1][21:35]
@@ -629,136 +950,332 @@ func TD2MRZ(data []string, ret MRZ) (MRZ, error) {
 	ret.TD2.ExpectedHash.HashDOB = strconv.Itoa(computeCheckDigit(dobField))
 	ret.TD2.ExpectedHash.HashExpiredDate = strconv.Itoa(computeCheckDigit(expiryField))
 	ret.TD2.ExpectedHash.FinalHash = strconv.Itoa(computeCheckDigit(compositeField))
-	ret.TD2.ExpectedHash.IsValid = verifyCheckDigit(docNumberField, data[1][9:10]) &&
-		verifyCheckDigit(dobField, data[1][19:20]) &&
-		verifyCheckDigit(expiryField, data[1][27:28]) &&
-		verifyCheckDigit(compositeField, data[1][35:36])
-
-	if !ret.TD2.ExpectedHash.IsValid {
-		return ret, fmt.Errorf("Invalid MRZ Checksum")
-	}
+	ret.TD2.ExpectedHash.IsValid = errs.checkDigits(
+		checkField{docNumberField, data[1][9:10], "hash_doc_number", 2, 10},
+		checkField{dobField, data[1][19:20], "hash_dob", 2, 20},
+		checkField{expiryField, data[1][27:28], "hash_expired_date", 2, 28},
+		checkField{compositeField, data[1][35:36], "final_hash", 2, 36},
+	)
 
-	return ret, nil
+	return ret, errs.err()
 }
 
 func VISAAMRZ(data []string, ret MRZ) (MRZ, error) {
+	return visaAMRZ(data, ret, &mrzErrors{failFast: true})
+}
+
+func visaAMRZ(data []string, ret MRZ, errs *mrzErrors) (MRZ, error) {
+	if len(data) < 2 {
+		errs.add(ErrInvalidLineCount, "", len(data), 1)
+		return ret, errs.err()
+	}
 	data[0] = strings.TrimSpace(data[0])
 	if len(data[0]) < VISA_A_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 1 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 1, len(data[0])+1)
+		return ret, errs.err()
 	}
 	ret.DocumentType = clear(data[0][:2])
 	ret.DocumentClass = VISA_A
 	ret.VISAA.Country = clear(data[0][2:5])
-	ret.VISAA.Name = clear(data[0][5:])
+	rawName := data[0][5:VISA_A_CHAR_LEN]
+	ret.VISAA.ExpectedHash.NameValid = isValidICAOName(rawName)
+	if !ret.VISAA.ExpectedHash.NameValid && errs.add(ErrInvalidName, "name", 1, 6) {
+		return ret, errs.err()
+	}
+	parts := strings.SplitN(rawName, "<<", 2)
+	if len(parts) > 0 {
+		ret.VISAA.LastName = clear(parts[0])
+	}
+	if len(parts) > 1 {
+		ret.VISAA.FirstName = clear(parts[1])
+	}
+	ret.VISAA.Name = strings.TrimSpace(ret.VISAA.FirstName + " " + ret.VISAA.LastName)
+	ret.VISAA.NameTruncated = isTruncatedName(rawName)
 
 	data[1] = strings.TrimSpace(data[1])
 	if len(data[1]) < VISA_A_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 2 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 2, len(data[1])+1)
+		return ret, errs.err()
 	}
-	ret.VISAA.DocNumber = clear(data[1][:9])
+	docNumberField := data[1][:9]
+	dobField := data[1][13:19]
+	expiryField := data[1][21:27]
+	ret.VISAA.DocNumber = clear(docNumberField)
 	ret.VISAA.HashDocNumber = clear(data[1][9:10])
 	ret.VISAA.Nationality = clear(data[1][10:13])
-	ret.VISAA.DOB = clear(data[1][13:19])
+	ret.VISAA.DOB = clear(dobField)
 	ret.VISAA.HashDOB = clear(data[1][19:20])
 	ret.VISAA.Sex = clear(data[1][20:21])
-	ret.VISAA.ExpiredDate = clear(data[1][21:27])
+	ret.VISAA.ExpiredDate = clear(expiryField)
 	ret.VISAA.HashExpiredDate = clear(data[1][27:28])
-	ret.VISAA.AdditionalInfo = clear(data[1][28:])
-	return ret, nil
+	ret.VISAA.AdditionalInfo = clear(data[1][28:VISA_A_CHAR_LEN])
+
+	ret.VISAA.ExpectedHash.DOBValid = isValidICAODate(dobField)
+	if !ret.VISAA.ExpectedHash.DOBValid && errs.add(ErrInvalidDOB, "dob", 2, 14) {
+		return ret, errs.err()
+	}
+	ret.VISAA.ExpectedHash.ExpiredDateValid = isValidICAODate(expiryField)
+	if !ret.VISAA.ExpectedHash.ExpiredDateValid && errs.add(ErrInvalidExpiredDate, "expired_date", 2, 22) {
+		return ret, errs.err()
+	}
+
+	ret.VISAA.CountryName = CountryName(ret.VISAA.Country)
+	ret.VISAA.NationalityName = CountryName(ret.VISAA.Nationality)
+	ret.VISAA.ExpectedHash.CountryValid = IsValidCountryCode(ret.VISAA.Country)
+	if !ret.VISAA.ExpectedHash.CountryValid && errs.add(ErrInvalidCountry, "country", 1, 3) {
+		return ret, errs.err()
+	}
+	ret.VISAA.ExpectedHash.NationalityValid = IsValidCountryCode(ret.VISAA.Nationality)
+	if !ret.VISAA.ExpectedHash.NationalityValid && errs.add(ErrInvalidNationality, "nationality", 2, 11) {
+		return ret, errs.err()
+	}
+
+	ret.VISAA.ExpectedHash.HashDocNumber = strconv.Itoa(computeCheckDigit(docNumberField))
+	ret.VISAA.ExpectedHash.HashDOB = strconv.Itoa(computeCheckDigit(dobField))
+	ret.VISAA.ExpectedHash.HashExpiredDate = strconv.Itoa(computeCheckDigit(expiryField))
+	ret.VISAA.ExpectedHash.IsValid = errs.checkDigits(
+		checkField{docNumberField, data[1][9:10], "hash_doc_number", 2, 10},
+		checkField{dobField, data[1][19:20], "hash_dob", 2, 20},
+		checkField{expiryField, data[1][27:28], "hash_expired_date", 2, 28},
+	)
+
+	return ret, errs.err()
 }
 
 func VISABMRZ(data []string, ret MRZ) (MRZ, error) {
+	return visaBMRZ(data, ret, &mrzErrors{failFast: true})
+}
+
+func visaBMRZ(data []string, ret MRZ, errs *mrzErrors) (MRZ, error) {
+	if len(data) < 2 {
+		errs.add(ErrInvalidLineCount, "", len(data), 1)
+		return ret, errs.err()
+	}
 	data[0] = strings.TrimSpace(data[0])
 	if len(data[0]) < VISA_B_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 1 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 1, len(data[0])+1)
+		return ret, errs.err()
 	}
 	ret.DocumentType = clear(data[0][:2])
 	ret.DocumentClass = VISA_B
 	ret.VISAB.Country = clear(data[0][2:5])
-	ret.VISAB.Name = clear(data[0][5:])
+	rawName := data[0][5:VISA_B_CHAR_LEN]
+	ret.VISAB.ExpectedHash.NameValid = isValidICAOName(rawName)
+	if !ret.VISAB.ExpectedHash.NameValid && errs.add(ErrInvalidName, "name", 1, 6) {
+		return ret, errs.err()
+	}
+	parts := strings.SplitN(rawName, "<<", 2)
+	if len(parts) > 0 {
+		ret.VISAB.LastName = clear(parts[0])
+	}
+	if len(parts) > 1 {
+		ret.VISAB.FirstName = clear(parts[1])
+	}
+	ret.VISAB.Name = strings.TrimSpace(ret.VISAB.FirstName + " " + ret.VISAB.LastName)
+	ret.VISAB.NameTruncated = isTruncatedName(rawName)
 
 	data[1] = strings.TrimSpace(data[1])
 	if len(data[1]) < VISA_B_CHAR_LEN {
-		return ret, fmt.Errorf("Invalid MRZ in line 2 (Code: 3)")
+		errs.add(ErrInvalidLength, "", 2, len(data[1])+1)
+		return ret, errs.err()
 	}
-	ret.VISAB.DocNumber = clear(data[1][:9])
+	docNumberField := data[1][:9]
+	dobField := data[1][13:19]
+	expiryField := data[1][21:27]
+	ret.VISAB.DocNumber = clear(docNumberField)
 	ret.VISAB.HashDocNumber = clear(data[1][9:10])
 	ret.VISAB.Nationality = clear(data[1][10:13])
-	ret.VISAB.DOB = clear(data[1][13:19])
+	ret.VISAB.DOB = clear(dobField)
 	ret.VISAB.HashDOB = clear(data[1][19:20])
 	ret.VISAB.Sex = clear(data[1][20:21])
-	ret.VISAB.ExpiredDate = clear(data[1][21:27])
+	ret.VISAB.ExpiredDate = clear(expiryField)
 	ret.VISAB.HashExpiredDate = clear(data[1][27:28])
-	ret.VISAB.AdditionalInfo = clear(data[1][28:])
-	return ret, nil
+	ret.VISAB.AdditionalInfo = clear(data[1][28:VISA_B_CHAR_LEN])
+
+	ret.VISAB.ExpectedHash.DOBValid = isValidICAODate(dobField)
+	if !ret.VISAB.ExpectedHash.DOBValid && errs.add(ErrInvalidDOB, "dob", 2, 14) {
+		return ret, errs.err()
+	}
+	ret.VISAB.ExpectedHash.ExpiredDateValid = isValidICAODate(expiryField)
+	if !ret.VISAB.ExpectedHash.ExpiredDateValid && errs.add(ErrInvalidExpiredDate, "expired_date", 2, 22) {
+		return ret, errs.err()
+	}
+
+	ret.VISAB.CountryName = CountryName(ret.VISAB.Country)
+	ret.VISAB.NationalityName = CountryName(ret.VISAB.Nationality)
+	ret.VISAB.ExpectedHash.CountryValid = IsValidCountryCode(ret.VISAB.Country)
+	if !ret.VISAB.ExpectedHash.CountryValid && errs.add(ErrInvalidCountry, "country", 1, 3) {
+		return ret, errs.err()
+	}
+	ret.VISAB.ExpectedHash.NationalityValid = IsValidCountryCode(ret.VISAB.Nationality)
+	if !ret.VISAB.ExpectedHash.NationalityValid && errs.add(ErrInvalidNationality, "nationality", 2, 11) {
+		return ret, errs.err()
+	}
+
+	ret.VISAB.ExpectedHash.HashDocNumber = strconv.Itoa(computeCheckDigit(docNumberField))
+	ret.VISAB.ExpectedHash.HashDOB = strconv.Itoa(computeCheckDigit(dobField))
+	ret.VISAB.ExpectedHash.HashExpiredDate = strconv.Itoa(computeCheckDigit(expiryField))
+	ret.VISAB.ExpectedHash.IsValid = errs.checkDigits(
+		checkField{docNumberField, data[1][9:10], "hash_doc_number", 2, 10},
+		checkField{dobField, data[1][19:20], "hash_dob", 2, 20},
+		checkField{expiryField, data[1][27:28], "hash_expired_date", 2, 28},
+	)
+
+	return ret, errs.err()
 }
 
 // --- Date parsing functions ---
 
-func ParseMRZExpiry(expiry string) (time.Time, error) {
-	var ret time.Time
+// maxValidityYears is the longest validity period expected per document
+// class, used to rule out expiry dates a century away.
+var maxValidityYears = map[string]int{
+	TD1:    15,
+	TD2:    15,
+	TD3:    10,
+	VISA_A: 10,
+	VISA_B: 10,
+}
+
+const defaultMaxValidityYears = 15
+
+// maxAgeYears bounds how far back a date of birth may be resolved.
+const maxAgeYears = 130
+
+// plausibleAgeYears is the age a holder is expected to stay under while
+// the document is valid. Two centuries of the same YYMMDD date are 100
+// years apart, so at most one of them normally falls within it.
+const plausibleAgeYears = 100
+
+type ResolvedDates struct {
+	DOB         time.Time `json:"dob"`
+	ExpiredDate time.Time `json:"expired_date"`
+	Ambiguous   bool      `json:"ambiguous"`
+}
+
+// ResolveMRZDates picks the century of the two digit MRZ dates using
+// both dates and the document class: the expiry cannot be further
+// ahead of now than the maximum validity of the class, and the DOB must
+// lie in the past, before the expiry and within maxAgeYears. Among the
+// DOBs that fit, the one making the holder younger than
+// plausibleAgeYears on the expiry date, or now for a valid document, is
+// returned; an older one only when none does. Ambiguous is set when
+// more than one DOB century is plausible.
+func ResolveMRZDates(dob, expiry, documentClass string, now time.Time) (ResolvedDates, error) {
+	var ret ResolvedDates
+	if !isValidICAODate(dob) {
+		return ret, errors.New("invalid dob")
+	}
 	if !isValidICAODate(expiry) {
 		return ret, errors.New("invalid expiry")
 	}
 
-	yearPart := expiry[:2]
-	monthPart := expiry[2:4]
-	dayPart := expiry[4:6]
-	baseCentury := (time.Now().Year() / 100) * 100
+	validity, ok := maxValidityYears[documentClass]
+	if !ok {
+		validity = defaultMaxValidityYears
+	}
+	latestExpiry := now.AddDate(validity, 1, 0)
+	expiryCandidates := mrzDateCandidates(expiry, now)
+	for i := len(expiryCandidates) - 1; i >= 0; i-- {
+		if !expiryCandidates[i].After(latestExpiry) {
+			ret.ExpiredDate = expiryCandidates[i]
+			break
+		}
+	}
+	if ret.ExpiredDate.IsZero() {
+		return ret, errors.New("invalid expiry")
+	}
 
-	yy, err := strconv.Atoi(yearPart)
-	if err != nil {
-		return ret, err
+	earliestDOB := now.AddDate(-maxAgeYears, 0, 0)
+	reference := now
+	if ret.ExpiredDate.Before(now) {
+		reference = ret.ExpiredDate
+	}
+	earliestPlausible := reference.AddDate(-plausibleAgeYears, 0, 0)
+	var oldest time.Time
+	plausible := 0
+	for _, c := range mrzDateCandidates(dob, now) {
+		if c.After(now) || !c.Before(ret.ExpiredDate) || c.Before(earliestDOB) {
+			continue
+		}
+		if c.Before(earliestPlausible) {
+			oldest = c
+			continue
+		}
+		ret.DOB = c
+		plausible++
 	}
-	fullYear := baseCentury + yy
+	if plausible == 0 {
+		ret.DOB = oldest
+	}
+	if ret.DOB.IsZero() {
+		return ret, errors.New("invalid dob")
+	}
+	ret.Ambiguous = plausible > 1
+	return ret, nil
+}
 
-	date := fmt.Sprintf("%d-%s-%s", fullYear, monthPart, dayPart)
+// mrzDateCandidates expands a YYMMDD date into the previous, current and
+// next century relative to now, in ascending order.
+func mrzDateCandidates(date string, now time.Time) []time.Time {
+	yy, _ := strconv.Atoi(date[:2])
+	mm, _ := strconv.Atoi(date[2:4])
+	dd, _ := strconv.Atoi(date[4:6])
+	base := (now.Year() / 100) * 100
+
+	ret := []time.Time{}
+	for _, century := range []int{base - 100, base, base + 100} {
+		full := fmt.Sprintf("%04d-%02d-%02d", century+yy, mm, dd)
+		if t, err := time.Parse("2006-01-02", full); err == nil {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
 
-	ret, err = time.Parse("2006-01-02", date)
-	if err != nil {
-		return ret, err
+// ParseMRZExpiry resolves the century of an MRZ expiry date to the one
+// closest to today. Use ResolveMRZDates when the DOB and document class
+// are known.
+func ParseMRZExpiry(expiry string) (time.Time, error) {
+	var ret time.Time
+	if !isValidICAODate(expiry) {
+		return ret, errors.New("invalid expiry")
+	}
+
+	now := time.Now()
+	for _, c := range mrzDateCandidates(expiry, now) {
+		if ret.IsZero() || absDuration(c.Sub(now)) < absDuration(ret.Sub(now)) {
+			ret = c
+		}
+	}
+	if ret.IsZero() {
+		return ret, errors.New("invalid expiry")
 	}
 	return ret, nil
 }
 
+// ParseMRZDOB resolves the century of an MRZ date of birth to the most
+// recent one that is not in the future.
 func ParseMRZDOB(dob string) (time.Time, error) {
 	var ret time.Time
 	if !isValidICAODate(dob) {
 		return ret, errors.New("invalid dob")
 	}
 
-	yearPart := dob[:2]
-	monthPart := dob[2:4]
-	dayPart := dob[4:6]
-
-	yy, err := strconv.Atoi(yearPart)
-	if err != nil {
-		return ret, err
-	}
-
-	mm, err := strconv.Atoi(monthPart)
-	if err != nil {
-		return ret, err
-	}
-
-	dd, err := strconv.Atoi(dayPart)
-	if err != nil {
-		return ret, err
+	now := time.Now()
+	for _, c := range mrzDateCandidates(dob, now) {
+		if !c.After(now) {
+			ret = c
+		}
 	}
-
-	// Guess century: MRZ DOB is usually 1900s or 2000s
-	// If year > current year (2-digit), assume 1900s, else 2000s
-	currentYear := time.Now().Year() % 100
-	century := 1900
-	if yy <= currentYear {
-		century = 2000
+	if ret.IsZero() {
+		return ret, errors.New("invalid dob")
 	}
-	fullYear := century + yy
+	return ret, nil
+}
 
-	date := fmt.Sprintf("%04d-%02d-%02d", fullYear, mm, dd)
-	ret, err = time.Parse("2006-01-02", date)
-	if err != nil {
-		return ret, err
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
 	}
-	return ret, nil
+	return d
 }
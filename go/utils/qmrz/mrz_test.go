@@ -0,0 +1,275 @@
+package qmrz
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveMRZDates(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		dob       string
+		expiry    string
+		class     string
+		wantDOB   string
+		wantExp   string
+		ambiguous bool
+	}{
+		{"born this century", "010101", "310101", TD3, "2001-01-01", "2031-01-01", false},
+		{"born last year", "250101", "300101", TD3, "2025-01-01", "2030-01-01", false},
+		{"born last century", "740812", "320415", TD3, "1974-08-12", "2032-04-15", false},
+		{"dob would be in the future", "261201", "300101", TD3, "1926-12-01", "2030-01-01", false},
+		{"dob would be after expiry", "200101", "950101", TD3, "1920-01-01", "1995-01-01", false},
+		{"centenarian", "200101", "300101", TD1, "2020-01-01", "2030-01-01", false},
+		{"long validity", "900101", "400101", TD1, "1990-01-01", "2040-01-01", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveMRZDates(tt.dob, tt.expiry, tt.class, now)
+			if err != nil {
+				t.Fatalf("ResolveMRZDates() error = %v", err)
+			}
+			if d := got.DOB.Format("2006-01-02"); d != tt.wantDOB {
+				t.Errorf("DOB = %s, want %s", d, tt.wantDOB)
+			}
+			if d := got.ExpiredDate.Format("2006-01-02"); d != tt.wantExp {
+				t.Errorf("ExpiredDate = %s, want %s", d, tt.wantExp)
+			}
+			if got.Ambiguous != tt.ambiguous {
+				t.Errorf("Ambiguous = %v, want %v", got.Ambiguous, tt.ambiguous)
+			}
+		})
+	}
+}
+
+func TestResolveMRZDatesErrors(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		dob    string
+		expiry string
+	}{
+		{"invalid dob", "741312", "320415"},
+		{"invalid expiry", "740812", "32AB15"},
+		{"dob after expiry", "961017", "961016"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ResolveMRZDates(tt.dob, tt.expiry, TD3, now); err == nil {
+				t.Error("ResolveMRZDates() error = nil, want an error")
+			}
+		})
+	}
+}
+
+// mrzFields are the fields of a parsed MRZ of any layout, with each
+// check digit next to the one expected.
+type mrzFields struct {
+	docType, country, lastName, firstName, docNumber string
+	nationality, dob, sex, expiry, optional          string
+	checks                                           [][2]string
+	valid                                            bool
+}
+
+func fieldsOf(m MRZ) mrzFields {
+	switch m.DocumentClass {
+	case TD1:
+		d := m.TD1
+		return mrzFields{m.DocumentType, d.Country, d.LastName, d.FirstName, d.DocNumber,
+			d.Nationality, d.DOB, d.Sex, d.ExpiredDate, d.AdditionalInfo1 + "|" + d.AdditionalInfo2,
+			[][2]string{
+				{d.HashDocNumber, d.ExpectedHash.HashDocNumber},
+				{d.HashDOB, d.ExpectedHash.HashDOB},
+				{d.HashExpiredDate, d.ExpectedHash.HashExpiredDate},
+				{d.FinalHash, d.ExpectedHash.FinalHash},
+			}, d.ExpectedHash.IsValid}
+	case TD2:
+		d := m.TD2
+		return mrzFields{m.DocumentType, d.Country, d.LastName, d.FirstName, d.DocNumber,
+			d.Nationality, d.DOB, d.Sex, d.ExpiredDate, d.AdditionalInfo,
+			[][2]string{
+				{d.HashDocNumber, d.ExpectedHash.HashDocNumber},
+				{d.HashDOB, d.ExpectedHash.HashDOB},
+				{d.HashExpiredDate, d.ExpectedHash.HashExpiredDate},
+				{d.FinalHash, d.ExpectedHash.FinalHash},
+			}, d.ExpectedHash.IsValid}
+	case TD3:
+		d := m.Passport
+		return mrzFields{m.DocumentType, d.Country, d.LastName, d.FirstName, d.DocNumber,
+			d.Nationality, d.DOB, d.Sex, d.ExpiredDate, d.PersonalNumber,
+			[][2]string{
+				{d.HashDocNumber, d.ExpectedHash.HashDocNumber},
+				{d.HashDOB, d.ExpectedHash.HashDOB},
+				{d.HashExpiredDate, d.ExpectedHash.HashExpiredDate},
+				{d.HashPersonalNumber, d.ExpectedHash.HashPersonalNumber},
+				{d.FinalHash, d.ExpectedHash.FinalHash},
+			}, d.ExpectedHash.IsValid}
+	case VISA_A:
+		d := m.VISAA
+		return mrzFields{m.DocumentType, d.Country, d.LastName, d.FirstName, d.DocNumber,
+			d.Nationality, d.DOB, d.Sex, d.ExpiredDate, d.AdditionalInfo,
+			[][2]string{
+				{d.HashDocNumber, d.ExpectedHash.HashDocNumber},
+				{d.HashDOB, d.ExpectedHash.HashDOB},
+				{d.HashExpiredDate, d.ExpectedHash.HashExpiredDate},
+			}, d.ExpectedHash.IsValid}
+	case VISA_B:
+		d := m.VISAB
+		return mrzFields{m.DocumentType, d.Country, d.LastName, d.FirstName, d.DocNumber,
+			d.Nationality, d.DOB, d.Sex, d.ExpiredDate, d.AdditionalInfo,
+			[][2]string{
+				{d.HashDocNumber, d.ExpectedHash.HashDocNumber},
+				{d.HashDOB, d.ExpectedHash.HashDOB},
+				{d.HashExpiredDate, d.ExpectedHash.HashExpiredDate},
+			}, d.ExpectedHash.IsValid}
+	}
+	return mrzFields{}
+}
+
+// generatorInput fills the layout of mrzType with the ICAO 9303 specimen
+// data and the given name and optional data.
+func generatorInput(mrzType, name, optional string) MRZ {
+	var m MRZ
+	const (
+		country, number, nationality = "UTO", "L898902C3", "UTO"
+		dob, sex, expiry             = "1974-08-12", "F", "2032-04-15"
+	)
+	switch mrzType {
+	case TD1:
+		m.TD1.Country, m.TD1.Name, m.TD1.DocNumber, m.TD1.Nationality = country, name, number, nationality
+		m.TD1.DOB, m.TD1.Sex, m.TD1.ExpiredDate, m.TD1.AdditionalInfo1 = dob, sex, expiry, optional
+	case TD2:
+		m.TD2.Country, m.TD2.Name, m.TD2.DocNumber, m.TD2.Nationality = country, name, number, nationality
+		m.TD2.DOB, m.TD2.Sex, m.TD2.ExpiredDate, m.TD2.AdditionalInfo = dob, sex, expiry, optional
+	case TD3:
+		m.Passport.Country, m.Passport.Name, m.Passport.DocNumber, m.Passport.Nationality = country, name, number, nationality
+		m.Passport.DOB, m.Passport.Sex, m.Passport.ExpiredDate, m.Passport.PersonalNumber = dob, sex, expiry, optional
+	case VISA_A:
+		m.VISAA.Country, m.VISAA.Name, m.VISAA.DocNumber, m.VISAA.Nationality = country, name, number, nationality
+		m.VISAA.DOB, m.VISAA.Sex, m.VISAA.ExpiredDate, m.VISAA.AdditionalInfo = dob, sex, expiry, optional
+	case VISA_B:
+		m.VISAB.Country, m.VISAB.Name, m.VISAB.DocNumber, m.VISAB.Nationality = country, name, number, nationality
+		m.VISAB.DOB, m.VISAB.Sex, m.VISAB.ExpiredDate, m.VISAB.AdditionalInfo = dob, sex, expiry, optional
+	}
+	return m
+}
+
+type roundTripTest struct {
+	name      string
+	mrzType   string
+	in        string
+	optional  string
+	docType   string
+	lastName  string
+	firstName string
+	wantOpt   string
+	truncated bool
+}
+
+func testRoundTrip(t *testing.T, tests []roundTripTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mrz, err := GenerateMRZ(tt.mrzType, generatorInput(tt.mrzType, tt.in, tt.optional))
+			if err != nil {
+				t.Fatalf("GenerateMRZ() error = %v", err)
+			}
+			m, err := ParseMRZ(mrz)
+			if err != nil {
+				t.Fatalf("ParseMRZ(%q) error = %v", mrz, err)
+			}
+			if m.DocumentClass != tt.mrzType {
+				t.Fatalf("DocumentClass = %s, want %s", m.DocumentClass, tt.mrzType)
+			}
+
+			got := fieldsOf(m)
+			want := mrzFields{tt.docType, "UTO", tt.lastName, tt.firstName, "L898902C3",
+				"UTO", "740812", "F", "320415", tt.wantOpt, got.checks, true}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ParseMRZ(%q) = %+v, want %+v", mrz, got, want)
+			}
+			for i, c := range got.checks {
+				if c[0] != c[1] {
+					t.Errorf("check digit %d = %s, want %s", i, c[0], c[1])
+				}
+			}
+
+			truncated, err := NameTruncated(tt.mrzType, tt.in)
+			if err != nil {
+				t.Fatalf("NameTruncated() error = %v", err)
+			}
+			if truncated != tt.truncated {
+				t.Errorf("NameTruncated() = %v, want %v", truncated, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestGenerateMRZRoundTrip(t *testing.T) {
+	const long = "Wolfeschlegelsteinhausenbergerdorff Hubert Blaine"
+	testRoundTrip(t, []roundTripTest{
+		{"TD1", TD1, "Eriksson Anna Maria", "ZE184226B", "I", "ERIKSSON", "ANNA MARIA", "ZE184226B|", false},
+		{"TD1 diacritics", TD1, "Müller Jürgen", "", "I", "MUELLER", "JUERGEN", "|", false},
+		{"TD1 cyrillic", TD1, "Иванов Сергей", "", "I", "IVANOV", "SERGEI", "|", false},
+		{"TD1 truncated", TD1, long, "", "I", "WOLFESCHLEGELSTEINHAUSENBER", "H", "|", true},
+		{"TD2", TD2, "Eriksson Anna Maria", "ZE1842", "I", "ERIKSSON", "ANNA MARIA", "ZE1842", false},
+		{"TD2 cyrillic", TD2, "Иванов Сергей", "", "I", "IVANOV", "SERGEI", "", false},
+		{"TD2 truncated", TD2, long, "", "I", "WOLFESCHLEGELSTEINHAUSENBERG", "H", "", true},
+		{"TD3", TD3, "Eriksson Anna Maria", "ZE184226B", "P", "ERIKSSON", "ANNA MARIA", "ZE184226B", false},
+		{"TD3 arabic", TD3, "حسن علي", "", "P", "XHSN", "ELY", "", false},
+		{"TD3 truncated", TD3, long, "", "P", "WOLFESCHLEGELSTEINHAUSENBERGERDORFF", "HU", "", true},
+	})
+}
+
+func TestGenerateMRZVisaRoundTrip(t *testing.T) {
+	testRoundTrip(t, []roundTripTest{
+		{"MRV-A", VISA_A, "Eriksson Anna Maria", "", "V", "ERIKSSON", "ANNA MARIA", "", false},
+		{"MRV-A optional data", VISA_A, "Eriksson Anna Maria", "8MULTIPLE", "V", "ERIKSSON", "ANNA MARIA", "8MULTIPLE", false},
+		{"MRV-A full optional data", VISA_A, "Eriksson Anna Maria", "AB12CD34EF56GH78", "V", "ERIKSSON", "ANNA MARIA", "AB12CD34EF56GH78", false},
+		{"MRV-A cyrillic", VISA_A, "Иванов Сергей", "", "V", "IVANOV", "SERGEI", "", false},
+		{"MRV-B", VISA_B, "Eriksson Anna Maria", "", "V", "ERIKSSON", "ANNA MARIA", "", false},
+		{"MRV-B optional data", VISA_B, "Eriksson Anna Maria", "8M", "V", "ERIKSSON", "ANNA MARIA", "8M", false},
+		{"MRV-B truncated", VISA_B, "Wolfeschlegelsteinhausenbergerdorff Hubert", "", "V", "WOLFESCHLEGELSTEINHAUSENBERG", "H", "", true},
+	})
+}
+
+func TestParseMRZVisaLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		mrzType  string
+		optional string
+		join     func(line1, line2 string) string
+	}{
+		{"MRV-A", VISA_A, "8M<<", func(a, b string) string { return a + "\n" + b }},
+		{"MRV-A one line", VISA_A, "8M", func(a, b string) string { return a + b }},
+		{"MRV-A padded lines", VISA_A, "8M", func(a, b string) string { return a + "  \n" + b + "  \n" }},
+		{"MRV-B", VISA_B, "8M<<", func(a, b string) string { return a + "\n" + b }},
+		{"MRV-B one line", VISA_B, "8M", func(a, b string) string { return a + b }},
+		{"MRV-B padded lines", VISA_B, "8M", func(a, b string) string { return a + "  \n" + b + "  \n" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mrz, err := GenerateMRZ(tt.mrzType, generatorInput(tt.mrzType, "Eriksson Anna", tt.optional))
+			if err != nil {
+				t.Fatalf("GenerateMRZ() error = %v", err)
+			}
+			line1, line2, _ := strings.Cut(mrz, "\n")
+			if !strings.HasSuffix(line2, "<") {
+				t.Fatalf("optional data of %q does not end in fillers", line2)
+			}
+			m, err := ParseMRZ(tt.join(line1, line2))
+			if err != nil {
+				t.Fatalf("ParseMRZ() error = %v", err)
+			}
+			if m.DocumentClass != tt.mrzType {
+				t.Errorf("DocumentClass = %s, want %s", m.DocumentClass, tt.mrzType)
+			}
+			if got := fieldsOf(m); got.optional != "8M" || !got.valid {
+				t.Errorf("ParseMRZ() = %+v, want optional data 8M and valid", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package qmrz
+
+import (
+	"sort"
+	"strings"
+)
+
+type fieldKind int
+
+const (
+	kindNumeric fieldKind = iota
+	kindAlpha
+	kindAlnum
+)
+
+// OCR confusions that are resolved from the field type alone.
+var (
+	ocrToDigit = map[rune]rune{
+		'O': '0', 'Q': '0', 'D': '0', 'U': '0',
+		'I': '1', 'L': '1', 'J': '1',
+		'Z': '2', 'B': '8', 'S': '5', 'G': '6', 'T': '7',
+	}
+	ocrToAlpha = map[rune]rune{
+		'0': 'O', '1': 'I', '2': 'Z', '5': 'S', '6': 'G', '8': 'B',
+	}
+	ocrAmbiguous = map[rune][]ocrConfusion{
+		'O': {{'0', 0.9}, {'Q', 0.3}, {'D', 0.3}},
+		'0': {{'O', 0.9}, {'Q', 0.3}, {'D', 0.3}},
+		'Q': {{'0', 0.5}, {'O', 0.5}},
+		'D': {{'0', 0.4}, {'O', 0.4}},
+		'I': {{'1', 0.9}, {'L', 0.3}},
+		'1': {{'I', 0.9}, {'L', 0.3}},
+		'L': {{'1', 0.3}, {'I', 0.3}},
+		'B': {{'8', 0.8}}, '8': {{'B', 0.8}},
+		'S': {{'5', 0.8}}, '5': {{'S', 0.8}},
+		'Z': {{'2', 0.7}}, '2': {{'Z', 0.7}},
+		'G': {{'6', 0.6}}, '6': {{'G', 0.6}},
+	}
+)
+
+// ocrConfusion is a character OCR may have read instead of another, with
+// how likely the misreading is relative to the other confusions.
+type ocrConfusion struct {
+	to         rune
+	likelihood float64
+}
+
+type mrzSegment struct {
+	line, start, end int
+	kind             fieldKind
+	check            int // column of the check digit on the same line, -1 if none
+}
+
+type mrzPosition struct {
+	line, col int
+}
+
+// mrzCheck is a check digit and the segments it is computed over.
+type mrzCheck struct {
+	segs []mrzSegment
+	pos  mrzPosition
+}
+
+type mrzLayout struct {
+	class     string
+	lineLen   int
+	lineCount int
+	segments  []mrzSegment
+	composite []mrzSegment
+	final     *mrzPosition
+}
+
+// Visa and TD3 share the second line up to the optional data.
+var line2Head = []mrzSegment{
+	{1, 0, 9, kindAlnum, 9},
+	{1, 9, 10, kindNumeric, -1},
+	{1, 10, 13, kindAlpha, -1},
+	{1, 13, 19, kindNumeric, 19},
+	{1, 19, 20, kindNumeric, -1},
+	{1, 20, 21, kindAlpha, -1},
+	{1, 21, 27, kindNumeric, 27},
+	{1, 27, 28, kindNumeric, -1},
+}
+
+var mrzLayouts = []mrzLayout{
+	{
+		class: TD1, lineLen: TD1_CHAR_LEN, lineCount: 3,
+		segments: []mrzSegment{
+			{0, 0, 5, kindAlpha, -1},
+			{0, 5, 14, kindAlnum, 14},
+			{0, 14, 15, kindNumeric, -1},
+			{0, 15, 30, kindAlnum, -1},
+			{1, 0, 6, kindNumeric, 6},
+			{1, 6, 7, kindNumeric, -1},
+			{1, 7, 8, kindAlpha, -1},
+			{1, 8, 14, kindNumeric, 14},
+			{1, 14, 15, kindNumeric, -1},
+			{1, 15, 18, kindAlpha, -1},
+			{1, 18, 29, kindAlnum, -1},
+			{1, 29, 30, kindNumeric, -1},
+			{2, 0, 30, kindAlpha, -1},
+		},
+		composite: []mrzSegment{{0, 5, 30, kindAlnum, -1}, {1, 0, 7, kindAlnum, -1}, {1, 8, 15, kindAlnum, -1}, {1, 18, 29, kindAlnum, -1}},
+		final:     &mrzPosition{1, 29},
+	},
+	{
+		class: TD2, lineLen: TD2_CHAR_LEN, lineCount: 2,
+		segments: append([]mrzSegment{{0, 0, 36, kindAlpha, -1}}, append(line2Head,
+			mrzSegment{1, 28, 35, kindAlnum, -1},
+			mrzSegment{1, 35, 36, kindNumeric, -1},
+		)...),
+		composite: []mrzSegment{{1, 0, 10, kindAlnum, -1}, {1, 13, 20, kindAlnum, -1}, {1, 21, 35, kindAlnum, -1}},
+		final:     &mrzPosition{1, 35},
+	},
+	{
+		class: TD3, lineLen: TD3_CHAR_LEN, lineCount: 2,
+		segments: append([]mrzSegment{{0, 0, 44, kindAlpha, -1}}, append(line2Head,
+			mrzSegment{1, 28, 42, kindAlnum, 42},
+			mrzSegment{1, 42, 44, kindNumeric, -1},
+		)...),
+		composite: []mrzSegment{{1, 0, 10, kindAlnum, -1}, {1, 13, 20, kindAlnum, -1}, {1, 21, 43, kindAlnum, -1}},
+		final:     &mrzPosition{1, 43},
+	},
+	{
+		class: VISA_A, lineLen: VISA_A_CHAR_LEN, lineCount: 2,
+		segments: append([]mrzSegment{{0, 0, 44, kindAlpha, -1}}, append(line2Head,
+			mrzSegment{1, 28, 44, kindAlnum, -1},
+		)...),
+	},
+	{
+		class: VISA_B, lineLen: VISA_B_CHAR_LEN, lineCount: 2,
+		segments: append([]mrzSegment{{0, 0, 36, kindAlpha, -1}}, append(line2Head,
+			mrzSegment{1, 28, 36, kindAlnum, -1},
+		)...),
+	},
+}
+
+// Correction describes a single character changed by ParseMRZTolerant.
+// Line and Column are zero based.
+type Correction struct {
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+type TolerantMRZ struct {
+	MRZ         MRZ          `json:"mrz"`
+	Raw         string       `json:"raw"`
+	Corrections []Correction `json:"corrections"`
+	Confidence  float64      `json:"confidence"`
+}
+
+// CleanMRZ normalizes raw OCR output into MRZ lines: letters are
+// upper-cased, spaces and characters outside the MRZ alphabet are
+// dropped, and lines too short to be part of an MRZ are discarded.
+func CleanMRZ(raw string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(raw, "\n") {
+		var b strings.Builder
+		for _, r := range strings.ToUpper(line) {
+			switch {
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '<':
+				b.WriteRune(r)
+			case r == '«' || r == '‹':
+				b.WriteRune('<')
+			}
+		}
+		if b.Len() >= TD1_CHAR_LEN-2 {
+			lines = append(lines, b.String())
+		}
+	}
+	return lines
+}
+
+// ParseMRZTolerant parses OCR output that may contain character
+// confusions (O/0, I/1, B/8, S/5, ...). Characters are first fixed by the
+// type of the field they belong to, then check digits are used to pick
+// between remaining ambiguous readings. Every changed character is
+// reported together with a confidence score between 0 and 1. When check
+// digits cannot tell two readings apart the error wraps
+// ErrAmbiguousOCR.
+func ParseMRZTolerant(raw string) (ret TolerantMRZ, err error) {
+	lines := CleanMRZ(raw)
+	if len(lines) == 0 {
+		return ret, ErrEmptyMRZ
+	}
+
+	layout, lines, err := detectLayout(lines)
+	if err != nil {
+		return ret, err
+	}
+
+	ret.Confidence = 1
+	grid := make([][]rune, len(lines))
+	for i, line := range lines {
+		grid[i] = []rune(line)
+	}
+
+	for _, seg := range layout.segments {
+		for col := seg.start; col < seg.end; col++ {
+			from := grid[seg.line][col]
+			to, reason := from, ""
+			switch seg.kind {
+			case kindNumeric:
+				if r, ok := ocrToDigit[from]; ok {
+					to, reason = r, "numeric field"
+				}
+			case kindAlpha:
+				if r, ok := ocrToAlpha[from]; ok {
+					to, reason = r, "alphabetic field"
+				}
+			}
+			if to != from {
+				grid[seg.line][col] = to
+				ret.Corrections = append(ret.Corrections, Correction{seg.line, col, string(from), string(to), reason})
+				ret.Confidence *= 0.95
+			}
+		}
+	}
+
+	var composite *mrzCheck
+	if layout.final != nil {
+		composite = &mrzCheck{layout.composite, *layout.final}
+	}
+	for _, seg := range layout.segments {
+		if seg.check < 0 || seg.kind != kindAlnum {
+			continue
+		}
+		factor, err := repairByCheckDigit(grid, mrzCheck{[]mrzSegment{seg}, mrzPosition{seg.line, seg.check}}, []mrzSegment{seg}, composite, &ret.Corrections)
+		if err != nil {
+			ret.Confidence = 0
+			return ret, err
+		}
+		ret.Confidence *= factor
+	}
+	if composite != nil {
+		// Only optional data without a check digit of its own is left
+		// for the composite check digit to repair.
+		editable := []mrzSegment{}
+		for _, seg := range layout.segments {
+			if seg.kind == kindAlnum && seg.check < 0 {
+				editable = append(editable, seg)
+			}
+		}
+		factor, err := repairByCheckDigit(grid, *composite, editable, nil, &ret.Corrections)
+		if err != nil {
+			ret.Confidence = 0
+			return ret, err
+		}
+		ret.Confidence *= factor
+	}
+
+	for i := range grid {
+		lines[i] = string(grid[i])
+	}
+	ret.Raw = strings.Join(lines, "\n")
+	ret.MRZ, err = ParseMRZ(ret.Raw)
+	if err != nil {
+		ret.Confidence = 0
+		return ret, err
+	}
+	return ret, nil
+}
+
+// detectLayout picks the MRZ layout matching the cleaned lines and
+// returns the lines belonging to it, padded or cut to the layout width.
+func detectLayout(lines []string) (mrzLayout, []string, error) {
+	if len(lines) == 1 {
+		for _, l := range mrzLayouts {
+			if len(lines[0]) == l.lineLen*l.lineCount {
+				lines = splitByN(lines[0], l.lineLen)
+				break
+			}
+		}
+	}
+
+	for i, line := range lines {
+		docType := line[0]
+		if r, ok := ocrToAlpha[rune(docType)]; ok {
+			docType = byte(r)
+		}
+		for _, l := range mrzLayouts {
+			if len(lines)-i < l.lineCount || !layoutAccepts(l, docType, len(line)) {
+				continue
+			}
+			ret := make([]string, l.lineCount)
+			for j := range ret {
+				ret[j] = fitLine(lines[i+j], l.lineLen)
+			}
+			return l, ret, nil
+		}
+	}
+	return mrzLayout{}, nil, ErrNotSupported
+}
+
+func layoutAccepts(l mrzLayout, docType byte, length int) bool {
+	if length < l.lineLen-2 || length > l.lineLen+2 {
+		return false
+	}
+	switch l.class {
+	case TD1, TD2:
+		return strings.IndexByte("ABCI", docType) >= 0
+	case TD3:
+		return docType == 'P'
+	default:
+		return docType == 'V'
+	}
+}
+
+func fitLine(line string, length int) string {
+	if len(line) > length {
+		return line[:length]
+	}
+	return padRight(line, length, '<')
+}
+
+// repairByCheckDigit tries single character substitutions inside
+// editable until check verifies. When several substitutions do, the one
+// that also makes the composite check digit verify is preferred, then
+// the likeliest OCR confusion; a tie is reported as ErrAmbiguousOCR
+// rather than guessed. It returns the confidence factor of the repair:
+// 1 when nothing changed, 0.9 for the only candidate, 0.75 for one
+// picked among several and 0.5 when no substitution helped.
+func repairByCheckDigit(grid [][]rune, check mrzCheck, editable []mrzSegment, composite *mrzCheck, corrections *[]Correction) (float64, error) {
+	verifies := func(c mrzCheck) bool {
+		var b strings.Builder
+		for _, s := range c.segs {
+			b.WriteString(string(grid[s.line][s.start:s.end]))
+		}
+		return verifyCheckDigit(b.String(), string(grid[c.pos.line][c.pos.col]))
+	}
+	if verifies(check) {
+		return 1, nil
+	}
+
+	type candidate struct {
+		Correction
+		likelihood float64
+		composite  bool
+	}
+	var candidates []candidate
+	for _, s := range editable {
+		for col := s.start; col < s.end; col++ {
+			from := grid[s.line][col]
+			for _, c := range ocrAmbiguous[from] {
+				grid[s.line][col] = c.to
+				if verifies(check) {
+					candidates = append(candidates, candidate{
+						Correction: Correction{s.line, col, string(from), string(c.to), "check digit"},
+						likelihood: c.likelihood,
+						composite:  composite != nil && verifies(*composite),
+					})
+				}
+			}
+			grid[s.line][col] = from
+		}
+	}
+	if len(candidates) == 0 {
+		return 0.5, nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].composite != candidates[j].composite {
+			return candidates[i].composite
+		}
+		return candidates[i].likelihood > candidates[j].likelihood
+	})
+	best := candidates[0]
+	if len(candidates) > 1 && candidates[1].composite == best.composite && candidates[1].likelihood == best.likelihood {
+		return 0, &FieldError{
+			Err:    ErrAmbiguousOCR,
+			Code:   errorCodes[ErrAmbiguousOCR],
+			Line:   check.pos.line + 1,
+			Column: check.pos.col + 1,
+		}
+	}
+
+	grid[best.Line][best.Column] = []rune(best.To)[0]
+	*corrections = append(*corrections, best.Correction)
+	if len(candidates) > 1 {
+		return 0.75, nil
+	}
+	return 0.9, nil
+}
@@ -0,0 +1,162 @@
+package qmrz
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ICAO 9303 specimens.
+const (
+	specimenTD1 = "I<UTOD231458907<<<<<<<<<<<<<<<\n7408122F1204159UTO<<<<<<<<<<<6\nERIKSSON<<ANNA<MARIA<<<<<<<<<<"
+	specimenTD2 = "I<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<\nD231458907UTO7408122F1204159<<<<<<<6"
+	specimenTD3 = "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<\nL898902C36UTO7408122F1204159ZE184226B<<<<<10"
+)
+
+func TestCleanMRZ(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{"clean", specimenTD2, strings.Split(specimenTD2, "\n")},
+		{
+			"lower case, spaces and angle quotes",
+			"i<uto eriksson<<anna<maria«<<<<<<<<<<\r\nD231458907 UTO 7408122 F 1204159 ‹<<<<<<6",
+			strings.Split(specimenTD2, "\n"),
+		},
+		{
+			"short lines dropped",
+			"PASSPORT\n" + specimenTD2 + "\n\n",
+			strings.Split(specimenTD2, "\n"),
+		},
+		{"nothing", "no mrz here", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanMRZ(tt.raw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CleanMRZ() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectLayout(t *testing.T) {
+	td3 := strings.Split(specimenTD3, "\n")
+	tests := []struct {
+		name    string
+		lines   []string
+		class   string
+		want    []string
+		wantErr error
+	}{
+		{"TD1", strings.Split(specimenTD1, "\n"), TD1, strings.Split(specimenTD1, "\n"), nil},
+		{"TD2", strings.Split(specimenTD2, "\n"), TD2, strings.Split(specimenTD2, "\n"), nil},
+		{"TD3", td3, TD3, td3, nil},
+		{"one line", []string{td3[0] + td3[1]}, TD3, td3, nil},
+		{"text before the MRZ", append([]string{"REPUBLICOFUTOPIAPASSPORTNUMBER"}, td3...), TD3, td3, nil},
+		{"line too short", []string{td3[0][:43], td3[1]}, TD3, []string{td3[0][:43] + "<", td3[1]}, nil},
+		{"line too long", []string{td3[0] + "<", td3[1] + "<"}, TD3, td3, nil},
+		{"document type misread", []string{"1" + specimenTD2[1:36], specimenTD2[37:]}, TD2,
+			[]string{"1" + specimenTD2[1:36], specimenTD2[37:]}, nil},
+		{"unknown document type", []string{"X" + td3[0][1:], td3[1]}, "", nil, ErrNotSupported},
+		{"missing line", td3[:1], "", nil, ErrNotSupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, lines, err := detectLayout(tt.lines)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("detectLayout() error = %v, want %v", err, tt.wantErr)
+			}
+			if layout.class != tt.class {
+				t.Errorf("detectLayout() class = %q, want %q", layout.class, tt.class)
+			}
+			if !reflect.DeepEqual(lines, tt.want) {
+				t.Errorf("detectLayout() lines = %q, want %q", lines, tt.want)
+			}
+		})
+	}
+}
+
+// noise replaces the character at line and column of mrz.
+func noise(mrz string, line, col int, r byte) string {
+	lines := strings.Split(mrz, "\n")
+	b := []byte(lines[line])
+	b[col] = r
+	lines[line] = string(b)
+	return strings.Join(lines, "\n")
+}
+
+func TestParseMRZTolerant(t *testing.T) {
+	td2Optional := "I<UTOERIKSSON<<ANNA<<<<<<<<<<<<<<<<<\nL898902C36UTO7408122F3204153AB1<<<<8"
+	tests := []struct {
+		name        string
+		raw         string
+		want        string
+		corrections []Correction
+		confidence  float64
+	}{
+		{"TD3 clean", specimenTD3, specimenTD3, nil, 1},
+		{"TD3 letter in date", noise(specimenTD3, 1, 15, 'O'), specimenTD3,
+			[]Correction{{1, 15, "O", "0", "numeric field"}}, 0.95},
+		{"TD3 digit in name", noise(specimenTD3, 0, 11, '0'), specimenTD3,
+			[]Correction{{0, 11, "0", "O", "alphabetic field"}}, 0.95},
+		{"TD3 document number", noise(specimenTD3, 1, 5, 'O'), specimenTD3,
+			[]Correction{{1, 5, "O", "0", "check digit"}}, 0.9},
+		{"TD3 date and document number", noise(noise(specimenTD3, 1, 5, 'O'), 1, 15, 'O'), specimenTD3,
+			[]Correction{{1, 15, "O", "0", "numeric field"}, {1, 5, "O", "0", "check digit"}}, 0.95 * 0.9},
+		{"TD1 document number", noise(specimenTD1, 0, 8, 'I'), specimenTD1,
+			[]Correction{{0, 8, "I", "1", "check digit"}}, 0.75},
+		{"TD1 letter in nationality", noise(specimenTD1, 1, 17, '0'), specimenTD1,
+			[]Correction{{1, 17, "0", "O", "alphabetic field"}}, 0.95},
+		{"TD2 optional data", noise(td2Optional, 1, 29, '8'), td2Optional,
+			[]Correction{{1, 29, "8", "B", "check digit"}}, 0.9},
+		{"TD2 check digit", noise(specimenTD2, 1, 19, 'Z'), specimenTD2,
+			[]Correction{{1, 19, "Z", "2", "numeric field"}}, 0.95},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMRZTolerant(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseMRZTolerant() error = %v", err)
+			}
+			if got.Raw != tt.want {
+				t.Errorf("ParseMRZTolerant() Raw = %q, want %q", got.Raw, tt.want)
+			}
+			if !reflect.DeepEqual(got.Corrections, tt.corrections) {
+				t.Errorf("ParseMRZTolerant() Corrections = %+v, want %+v", got.Corrections, tt.corrections)
+			}
+			if math.Abs(got.Confidence-tt.confidence) > 1e-9 {
+				t.Errorf("ParseMRZTolerant() Confidence = %v, want %v", got.Confidence, tt.confidence)
+			}
+		})
+	}
+}
+
+func TestParseMRZTolerantErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr error
+	}{
+		{"empty", "", ErrEmptyMRZ},
+		{"no MRZ", "boarding pass", ErrEmptyMRZ},
+		{"unknown layout", "X" + specimenTD3[1:], ErrNotSupported},
+		// Either Z of ZE1842Z6B may stand for a 2.
+		{"ambiguous", noise(specimenTD3, 1, 34, 'Z'), ErrAmbiguousOCR},
+		{"beyond repair", noise(specimenTD3, 1, 2, '7'), ErrInvalidChecksum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMRZTolerant(tt.raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseMRZTolerant() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.Confidence != 0 {
+				t.Errorf("ParseMRZTolerant() Confidence = %v, want 0", got.Confidence)
+			}
+		})
+	}
+}
@@ -0,0 +1,494 @@
+package qmrz
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const TagSOD = 0x77
+
+var (
+	oidSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidLDSSecurityObject = asn1.ObjectIdentifier{2, 23, 136, 1, 1, 1}
+	oidCSCAMasterList    = asn1.ObjectIdentifier{2, 23, 136, 1, 1, 2}
+	oidMessageDigest     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA224 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 4}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidRSAPSS          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	oidSHA1WithRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 5}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECPublicKey     = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidECDSAWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// content returns the eContent octets wrapped in the [0] tag.
+func (e encapContentInfo) content() ([]byte, error) {
+	var ret []byte
+	if _, err := asn1.Unmarshal(e.EContent.Bytes, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+type ldsSecurityObject struct {
+	Version             int
+	HashAlgorithm       pkix.AlgorithmIdentifier
+	DataGroupHashValues []dataGroupHash
+	LDSVersionInfo      asn1.RawValue `asn1:"optional"`
+}
+
+type dataGroupHash struct {
+	DataGroupNumber    int
+	DataGroupHashValue []byte
+}
+
+type cscaMasterList struct {
+	Version  int
+	CertList []asn1.RawValue `asn1:"set"`
+}
+
+// PassiveAuthResult is the outcome of VerifySOD. IsValid is only set
+// when the signature, the Document Signer certificate and every
+// supplied data group hash check out.
+type PassiveAuthResult struct {
+	IsValid          bool         `json:"is_valid"`
+	SignatureValid   bool         `json:"signature_valid"`
+	CertificateValid bool         `json:"certificate_valid"`
+	DataGroupsValid  bool         `json:"data_groups_valid"`
+	DataGroupHashes  map[int]bool `json:"data_group_hashes"`
+	DocumentSigner   string       `json:"document_signer"`
+}
+
+// TrustStore holds the CSCA certificates Document Signer certificates
+// are verified against.
+type TrustStore struct {
+	certs []*x509.Certificate
+}
+
+type TrustStoreOption func(*TrustStore) error
+
+// WithCSCAPath loads every PEM or DER certificate file in dir.
+func WithCSCAPath(dir string) TrustStoreOption {
+	return func(t *TrustStore) error {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			if err := t.LoadFile(filepath.Join(dir, f.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithCSCAFile loads a single PEM or DER certificate file.
+func WithCSCAFile(path string) TrustStoreOption {
+	return func(t *TrustStore) error {
+		return t.LoadFile(path)
+	}
+}
+
+// WithMasterList loads the certificates of an ICAO CSCA master list.
+func WithMasterList(path string) TrustStoreOption {
+	return func(t *TrustStore) error {
+		return t.LoadMasterList(path)
+	}
+}
+
+func NewTrustStore(opts ...TrustStoreOption) (*TrustStore, error) {
+	t := &TrustStore{}
+	for _, optFunc := range opts {
+		if err := optFunc(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *TrustStore) AddCertificate(cert *x509.Certificate) {
+	t.certs = append(t.certs, cert)
+}
+
+// LoadFile adds the certificates of a PEM file, or of a single DER
+// encoded certificate.
+func (t *TrustStore) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if block, _ := pem.Decode(data); block == nil {
+		cert, err := x509.ParseCertificate(data)
+		if err != nil {
+			return err
+		}
+		t.AddCertificate(cert)
+		return nil
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return err
+		}
+		t.AddCertificate(cert)
+	}
+}
+
+// LoadMasterList adds the certificates of an ICAO CSCA master list
+// (a CMS SignedData file). The signature of the master list itself is
+// not verified; only load master lists obtained from a trusted source.
+func (t *TrustStore) LoadMasterList(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	sd, err := parseSignedData(data)
+	if err != nil {
+		return err
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidCSCAMasterList) {
+		return errors.New("not a CSCA master list")
+	}
+	content, err := sd.EncapContentInfo.content()
+	if err != nil {
+		return err
+	}
+	var ml cscaMasterList
+	if _, err := asn1.Unmarshal(content, &ml); err != nil {
+		return err
+	}
+	for _, raw := range ml.CertList {
+		cert, err := x509.ParseCertificate(raw.FullBytes)
+		if err != nil {
+			return err
+		}
+		t.AddCertificate(cert)
+	}
+	return nil
+}
+
+// issuerOf returns the CSCA that signed cert, or nil.
+func (t *TrustStore) issuerOf(cert *x509.Certificate) *x509.Certificate {
+	for _, csca := range t.certs {
+		if !bytes.Equal(cert.RawIssuer, csca.RawSubject) {
+			continue
+		}
+		if csca.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil {
+			return csca
+		}
+	}
+	return nil
+}
+
+// VerifySOD performs Passive Authentication (ICAO 9303 Part 11,
+// section 5.1) of an EF.SOD: the CMS signature over the LDS security
+// object, the Document Signer certificate against the CSCA trust store
+// and the hash of every data group in dataGroups, keyed by data group
+// number. DG1 must be supplied.
+func VerifySOD(sod []byte, dataGroups map[int][]byte, store *TrustStore, now time.Time) (PassiveAuthResult, error) {
+	ret := PassiveAuthResult{DataGroupHashes: map[int]bool{}}
+	if _, ok := dataGroups[1]; !ok {
+		return ret, errors.New("DG1 is required")
+	}
+	if len(sod) > 0 && sod[0] == TagSOD {
+		value, err := unwrapTLV(sod, TagSOD)
+		if err != nil {
+			return ret, err
+		}
+		sod = value
+	}
+
+	sd, err := parseSignedData(sod)
+	if err != nil {
+		return ret, err
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidLDSSecurityObject) {
+		return ret, errors.New("not an LDS security object")
+	}
+	if len(sd.SignerInfos) != 1 {
+		return ret, errors.New("expected exactly one signer")
+	}
+	eContent, err := sd.EncapContentInfo.content()
+	if err != nil {
+		return ret, err
+	}
+	signer := sd.SignerInfos[0]
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return ret, err
+	}
+	ds := findSignerCertificate(certs, signer.SID)
+	if ds == nil {
+		return ret, errors.New("document signer certificate not found")
+	}
+	ret.DocumentSigner = ds.Subject.String()
+
+	ret.SignatureValid, err = verifySignerInfo(signer, eContent, ds)
+	if err != nil {
+		return ret, err
+	}
+	ret.CertificateValid = store != nil && store.issuerOf(ds) != nil &&
+		!now.Before(ds.NotBefore) && !now.After(ds.NotAfter)
+
+	var lds ldsSecurityObject
+	if _, err := asn1.Unmarshal(eContent, &lds); err != nil {
+		return ret, err
+	}
+	hash, err := hashFor(lds.HashAlgorithm.Algorithm)
+	if err != nil {
+		return ret, err
+	}
+	expected := map[int][]byte{}
+	for _, dg := range lds.DataGroupHashValues {
+		expected[dg.DataGroupNumber] = dg.DataGroupHashValue
+	}
+	ret.DataGroupsValid = true
+	for n, data := range dataGroups {
+		h := hash.New()
+		h.Write(data)
+		want, ok := expected[n]
+		ret.DataGroupHashes[n] = ok && bytes.Equal(h.Sum(nil), want)
+		ret.DataGroupsValid = ret.DataGroupsValid && ret.DataGroupHashes[n]
+	}
+
+	ret.IsValid = ret.SignatureValid && ret.CertificateValid && ret.DataGroupsValid
+	return ret, nil
+}
+
+// Apply records the passive authentication outcome in the ExpectedHash
+// of the parsed document, next to IsValid. Machine readable visas carry
+// no chip and so no EF.SOD (ICAO 9303 Part 7), which is why the visa
+// classes have no PassiveAuthValid; they are returned unchanged.
+func (r PassiveAuthResult) Apply(m MRZ) MRZ {
+	switch m.DocumentClass {
+	case TD1:
+		m.TD1.ExpectedHash.PassiveAuthValid = r.IsValid
+	case TD2:
+		m.TD2.ExpectedHash.PassiveAuthValid = r.IsValid
+	case TD3:
+		m.Passport.ExpectedHash.PassiveAuthValid = r.IsValid
+	}
+	return m
+}
+
+func parseSignedData(data []byte) (signedData, error) {
+	var ci contentInfo
+	var sd signedData
+	if _, err := asn1.Unmarshal(data, &ci); err != nil {
+		return sd, err
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return sd, errors.New("not a CMS SignedData")
+	}
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return sd, err
+	}
+	return sd, nil
+}
+
+func findSignerCertificate(certs []*x509.Certificate, sid asn1.RawValue) *x509.Certificate {
+	switch {
+	case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+		var ias issuerAndSerialNumber
+		if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err == nil {
+			for _, c := range certs {
+				if c.SerialNumber.Cmp(ias.SerialNumber) == 0 && bytes.Equal(c.RawIssuer, ias.Issuer.FullBytes) {
+					return c
+				}
+			}
+		}
+	case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+		for _, c := range certs {
+			if bytes.Equal(c.SubjectKeyId, sid.Bytes) {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// verifySignerInfo checks the message digest attribute against the
+// content and the signature over the signed attributes (or over the
+// content itself when there are none).
+func verifySignerInfo(signer signerInfo, content []byte, cert *x509.Certificate) (bool, error) {
+	hash, err := hashFor(signer.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return false, err
+	}
+	algo, err := signatureAlgorithm(signer.SignatureAlgorithm.Algorithm, hash)
+	if err != nil {
+		return false, err
+	}
+
+	signed := content
+	if len(signer.SignedAttrs.FullBytes) > 0 {
+		signed = append([]byte{0x31}, signer.SignedAttrs.FullBytes[1:]...)
+		var attrs []attribute
+		if _, err := asn1.UnmarshalWithParams(signed, &attrs, "set"); err != nil {
+			return false, err
+		}
+		h := hash.New()
+		h.Write(content)
+		digestOK := false
+		for _, a := range attrs {
+			if !a.Type.Equal(oidMessageDigest) {
+				continue
+			}
+			var digest []byte
+			if _, err := asn1.Unmarshal(a.Values.Bytes, &digest); err != nil {
+				return false, err
+			}
+			digestOK = bytes.Equal(digest, h.Sum(nil))
+		}
+		if !digestOK {
+			return false, nil
+		}
+	}
+
+	return cert.CheckSignature(algo, signed, signer.Signature) == nil, nil
+}
+
+func hashFor(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA1):
+		return crypto.SHA1, nil
+	case oid.Equal(oidSHA224):
+		return crypto.SHA224, nil
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, errors.New("unsupported hash algorithm")
+}
+
+func signatureAlgorithm(oid asn1.ObjectIdentifier, hash crypto.Hash) (x509.SignatureAlgorithm, error) {
+	byHash := func(m map[crypto.Hash]x509.SignatureAlgorithm) (x509.SignatureAlgorithm, error) {
+		if algo, ok := m[hash]; ok {
+			return algo, nil
+		}
+		return x509.UnknownSignatureAlgorithm, errors.New("unsupported signature algorithm")
+	}
+	rsa := map[crypto.Hash]x509.SignatureAlgorithm{
+		crypto.SHA1: x509.SHA1WithRSA, crypto.SHA256: x509.SHA256WithRSA,
+		crypto.SHA384: x509.SHA384WithRSA, crypto.SHA512: x509.SHA512WithRSA,
+	}
+	ecdsa := map[crypto.Hash]x509.SignatureAlgorithm{
+		crypto.SHA1: x509.ECDSAWithSHA1, crypto.SHA256: x509.ECDSAWithSHA256,
+		crypto.SHA384: x509.ECDSAWithSHA384, crypto.SHA512: x509.ECDSAWithSHA512,
+	}
+	pss := map[crypto.Hash]x509.SignatureAlgorithm{
+		crypto.SHA256: x509.SHA256WithRSAPSS, crypto.SHA384: x509.SHA384WithRSAPSS,
+		crypto.SHA512: x509.SHA512WithRSAPSS,
+	}
+
+	switch {
+	case oid.Equal(oidRSAEncryption):
+		return byHash(rsa)
+	case oid.Equal(oidRSAPSS):
+		return byHash(pss)
+	case oid.Equal(oidECPublicKey):
+		return byHash(ecdsa)
+	case oid.Equal(oidSHA1WithRSA):
+		return x509.SHA1WithRSA, nil
+	case oid.Equal(oidSHA256WithRSA):
+		return x509.SHA256WithRSA, nil
+	case oid.Equal(oidSHA384WithRSA):
+		return x509.SHA384WithRSA, nil
+	case oid.Equal(oidSHA512WithRSA):
+		return x509.SHA512WithRSA, nil
+	case oid.Equal(oidECDSAWithSHA1):
+		return x509.ECDSAWithSHA1, nil
+	case oid.Equal(oidECDSAWithSHA256):
+		return x509.ECDSAWithSHA256, nil
+	case oid.Equal(oidECDSAWithSHA384):
+		return x509.ECDSAWithSHA384, nil
+	case oid.Equal(oidECDSAWithSHA512):
+		return x509.ECDSAWithSHA512, nil
+	}
+	return x509.UnknownSignatureAlgorithm, errors.New("unsupported signature algorithm")
+}
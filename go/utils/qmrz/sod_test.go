@@ -0,0 +1,225 @@
+package qmrz
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+var oidContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+
+type testPKI struct {
+	csca    *x509.Certificate
+	cscaKey *ecdsa.PrivateKey
+}
+
+func newTestCSCA(t *testing.T, name string) testPKI {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Country: []string{"UT"}, CommonName: name},
+		NotBefore:             time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2035, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testPKI{csca: cert, cscaKey: key}
+}
+
+// newDocumentSigner issues a Document Signer certificate valid from
+// notBefore to notAfter.
+func (p testPKI) newDocumentSigner(t *testing.T, notBefore, notAfter time.Time) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{Country: []string{"UT"}, CommonName: "Document Signer"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.csca, &key.PublicKey, p.cscaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func mustMarshal(t *testing.T, v interface{}, params string) []byte {
+	t.Helper()
+	der, err := asn1.MarshalWithParams(v, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// buildSOD signs an LDS security object holding the SHA-256 hash of
+// every data group, the way an issuing state writes EF.SOD.
+func buildSOD(t *testing.T, dataGroups map[int][]byte, ds *x509.Certificate, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	sha256ID := pkix.AlgorithmIdentifier{Algorithm: oidSHA256}
+
+	lds := ldsSecurityObject{HashAlgorithm: sha256ID}
+	for n := 1; n <= 16; n++ {
+		if data, ok := dataGroups[n]; ok {
+			h := sha256.Sum256(data)
+			lds.DataGroupHashValues = append(lds.DataGroupHashValues, dataGroupHash{n, h[:]})
+		}
+	}
+	eContent := mustMarshal(t, mustMarshal(t, lds, ""), "")
+	digest := sha256.Sum256(mustMarshal(t, lds, ""))
+
+	setOf := func(der []byte) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: der}
+	}
+	attrs := mustMarshal(t, []attribute{
+		{Type: oidContentType, Values: setOf(mustMarshal(t, oidLDSSecurityObject, ""))},
+		{Type: oidMessageDigest, Values: setOf(mustMarshal(t, digest[:], ""))},
+	}, "set")
+	attrsDigest := sha256.Sum256(attrs)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, attrsDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sid := mustMarshal(t, issuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: ds.RawIssuer},
+		SerialNumber: ds.SerialNumber,
+	}, "")
+	explicit0 := func(der []byte) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+	}
+	sd := signedData{
+		Version:          3,
+		DigestAlgorithms: asn1.RawValue{FullBytes: mustMarshal(t, []pkix.AlgorithmIdentifier{sha256ID}, "set")},
+		EncapContentInfo: encapContentInfo{
+			EContentType: oidLDSSecurityObject,
+			EContent:     explicit0(eContent),
+		},
+		Certificates: explicit0(ds.Raw),
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    sha256ID,
+			SignedAttrs:        asn1.RawValue{FullBytes: append([]byte{0xA0}, attrs[1:]...)},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          signature,
+		}},
+	}
+	return mustMarshal(t, contentInfo{
+		ContentType: oidSignedData,
+		Content:     explicit0(mustMarshal(t, sd, "")),
+	}, "")
+}
+
+func TestVerifySOD(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	pki := newTestCSCA(t, "CSCA Utopia")
+	ds, dsKey := pki.newDocumentSigner(t, now.AddDate(-1, 0, 0), now.AddDate(1, 0, 0))
+	expiredDS, expiredKey := pki.newDocumentSigner(t, now.AddDate(-3, 0, 0), now.AddDate(0, 0, -1))
+
+	// A CSCA under the same name but with another key: the DS names it
+	// as issuer but does not chain to it.
+	impostor := newTestCSCA(t, "CSCA Utopia")
+	stranger := newTestCSCA(t, "CSCA Elsewhere")
+
+	dataGroups := map[int][]byte{
+		1: []byte("P<UTOERIKSSON<<ANNA<MARIA"),
+		2: []byte("face image"),
+	}
+	sod := buildSOD(t, dataGroups, ds, dsKey)
+
+	tests := []struct {
+		name       string
+		sod        []byte
+		dataGroups map[int][]byte
+		store      []*x509.Certificate
+		signature  bool
+		cert       bool
+		groups     bool
+	}{
+		{"valid", sod, dataGroups, []*x509.Certificate{pki.csca}, true, true, true},
+		{"wrapped in the SOD tag", append([]byte{TagSOD, 0x82, byte(len(sod) >> 8), byte(len(sod))}, sod...), dataGroups, []*x509.Certificate{pki.csca}, true, true, true},
+		{"tampered data group", sod, map[int][]byte{1: []byte("P<UTOERIKSSON<<ANNE<MARIA"), 2: dataGroups[2]}, []*x509.Certificate{pki.csca}, true, true, false},
+		{"data group not in the SOD", sod, map[int][]byte{1: dataGroups[1], 3: []byte("fingerprints")}, []*x509.Certificate{pki.csca}, true, true, false},
+		{"expired document signer", buildSOD(t, dataGroups, expiredDS, expiredKey), dataGroups, []*x509.Certificate{pki.csca}, true, false, true},
+		{"untrusted CSCA", sod, dataGroups, []*x509.Certificate{stranger.csca}, true, false, true},
+		{"empty trust store", sod, dataGroups, nil, true, false, true},
+		{"does not chain to the CSCA", sod, dataGroups, []*x509.Certificate{impostor.csca}, true, false, true},
+		{"signed by another key", buildSOD(t, dataGroups, ds, expiredKey), dataGroups, []*x509.Certificate{pki.csca}, false, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := NewTrustStore()
+			for _, c := range tt.store {
+				store.AddCertificate(c)
+			}
+			got, err := VerifySOD(tt.sod, tt.dataGroups, store, now)
+			if err != nil {
+				t.Fatalf("VerifySOD() error = %v", err)
+			}
+			if got.SignatureValid != tt.signature || got.CertificateValid != tt.cert || got.DataGroupsValid != tt.groups {
+				t.Errorf("VerifySOD() signature, certificate, data groups = %v, %v, %v, want %v, %v, %v",
+					got.SignatureValid, got.CertificateValid, got.DataGroupsValid, tt.signature, tt.cert, tt.groups)
+			}
+			if want := tt.signature && tt.cert && tt.groups; got.IsValid != want {
+				t.Errorf("IsValid = %v, want %v", got.IsValid, want)
+			}
+		})
+	}
+}
+
+func TestVerifySODErrors(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	pki := newTestCSCA(t, "CSCA Utopia")
+	ds, dsKey := pki.newDocumentSigner(t, now.AddDate(-1, 0, 0), now.AddDate(1, 0, 0))
+	sod := buildSOD(t, map[int][]byte{1: []byte("DG1")}, ds, dsKey)
+	store, _ := NewTrustStore()
+	store.AddCertificate(pki.csca)
+
+	if _, err := VerifySOD(sod, map[int][]byte{2: []byte("DG2")}, store, now); err == nil {
+		t.Error("VerifySOD() without DG1 error = nil, want an error")
+	}
+	if _, err := VerifySOD(sod[:len(sod)/2], map[int][]byte{1: []byte("DG1")}, store, now); err == nil {
+		t.Error("VerifySOD() of a cut SOD error = nil, want an error")
+	}
+}
+
+func TestPassiveAuthResultApply(t *testing.T) {
+	r := PassiveAuthResult{IsValid: true}
+	for _, class := range []string{TD1, TD2, TD3, VISA_A, VISA_B} {
+		m := r.Apply(MRZ{DocumentClass: class})
+		got := m.TD1.ExpectedHash.PassiveAuthValid || m.TD2.ExpectedHash.PassiveAuthValid || m.Passport.ExpectedHash.PassiveAuthValid
+		if want := class != VISA_A && class != VISA_B; got != want {
+			t.Errorf("Apply() to %s = %v, want %v", class, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package qmrz
+
+import (
+	"strings"
+)
+
+// Transliterations from ICAO 9303 Part 3, section 6. Latin letters with
+// diacritics use the recommended national forms (Ä→AE, Ö→OE, Ü→UE) and
+// Arabic letters use the reversible table with the X prefix.
+var transliterationTable = map[rune]string{
+	// Latin
+	'Á': "A", 'À': "A", 'Â': "A", 'Ä': "AE", 'Ã': "A", 'Ă': "A", 'Å': "AA", 'Ā': "A", 'Ą': "A",
+	'Ć': "C", 'Ĉ': "C", 'Č': "C", 'Ċ': "C", 'Ç': "C",
+	'Ď': "D", 'Đ': "D", 'Ð': "D",
+	'É': "E", 'È': "E", 'Ê': "E", 'Ë': "E", 'Ě': "E", 'Ė': "E", 'Ē': "E", 'Ę': "E", 'Ĕ': "E",
+	'Ĝ': "G", 'Ğ': "G", 'Ġ': "G", 'Ģ': "G",
+	'Ĥ': "H", 'Ħ': "H",
+	'Í': "I", 'Ì': "I", 'Î': "I", 'Ï': "I", 'Ĩ': "I", 'İ': "I", 'Ī': "I", 'Į': "I", 'Ĭ': "I",
+	'Ĵ': "J",
+	'Ķ': "K",
+	'Ĺ': "L", 'Ļ': "L", 'Ľ': "L", 'Ŀ': "L", 'Ł': "L",
+	'Ń': "N", 'Ñ': "N", 'Ň': "N", 'Ņ': "N", 'Ŋ': "N",
+	'Ó': "O", 'Ò': "O", 'Ô': "O", 'Ö': "OE", 'Õ': "O", 'Ő': "O", 'Ø': "OE", 'Ō': "O", 'Ŏ': "O",
+	'Ŕ': "R", 'Ř': "R", 'Ŗ': "R",
+	'Ś': "S", 'Ŝ': "S", 'Š': "S", 'Ş': "S", 'Ș': "S",
+	'Ť': "T", 'Ţ': "T", 'Ŧ': "T", 'Ț': "T",
+	'Ú': "U", 'Ù': "U", 'Û': "U", 'Ü': "UE", 'Ũ': "U", 'Ŭ': "U", 'Ű': "U", 'Ů': "U", 'Ū': "U", 'Ų': "U",
+	'Ŵ': "W",
+	'Ý': "Y", 'Ŷ': "Y", 'Ÿ': "Y",
+	'Ź': "Z", 'Ž': "Z", 'Ż': "Z",
+	'Þ': "TH", 'Æ': "AE", 'Ĳ': "IJ", 'Œ': "OE", 'ß': "SS", 'ẞ': "SS",
+
+	// Cyrillic
+	'А': "A", 'Б': "B", 'В': "V", 'Г': "G", 'Ґ': "G", 'Ѓ': "G", 'Д': "D", 'Ђ': "D",
+	'Е': "E", 'Ё': "E", 'Є': "IE", 'Ж': "ZH", 'З': "Z", 'Ѕ': "DZ", 'И': "I", 'І': "I",
+	'Ї': "I", 'Й': "I", 'Ј': "J", 'К': "K", 'Ќ': "K", 'Л': "L", 'Љ': "LJ", 'М': "M",
+	'Н': "N", 'Њ': "NJ", 'О': "O", 'П': "P", 'Р': "R", 'С': "S", 'Т': "T", 'Ћ': "C",
+	'У': "U", 'Ў': "U", 'Ф': "F", 'Х': "KH", 'Ц': "TS", 'Ч': "CH", 'Џ': "DZ", 'Ш': "SH",
+	'Щ': "SHCH", 'Ъ': "IE", 'Ы': "Y", 'Ь': "", 'Э': "E", 'Ю': "IU", 'Я': "IA",
+
+	// Arabic
+	'ء': "XE", 'آ': "XAA", 'أ': "XAE", 'ؤ': "U", 'إ': "I", 'ئ': "XI", 'ا': "A", 'ب': "B",
+	'ة': "XTA", 'ت': "T", 'ث': "XTH", 'ج': "J", 'ح': "XH", 'خ': "XKH", 'د': "D", 'ذ': "XDH",
+	'ر': "R", 'ز': "Z", 'س': "S", 'ش': "XSH", 'ص': "XSS", 'ض': "XDZ", 'ط': "XTT", 'ظ': "XZZ",
+	'ع': "E", 'غ': "G", 'ف': "F", 'ق': "Q", 'ك': "K", 'ل': "L", 'م': "M", 'ن': "N",
+	'ه': "H", 'و': "W", 'ى': "XAY", 'ي': "Y", 'ٱ': "XXA", 'پ': "XPE", 'چ': "XCH", 'ژ': "XJE",
+	'ڤ': "XVE", 'ک': "XKK", 'گ': "XGG", 'ی': "XYA",
+}
+
+// Transliterate converts s to the MRZ character set following ICAO 9303
+// Part 3: letters are upper-cased and transliterated, apostrophes are
+// omitted and every other character becomes the filler '<'.
+func Transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		switch {
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '<':
+			b.WriteRune(r)
+		case r == '\'' || r == '’' || r == '`':
+		default:
+			if t, ok := transliterationTable[r]; ok {
+				b.WriteString(t)
+			} else {
+				b.WriteRune('<')
+			}
+		}
+	}
+	return b.String()
+}
+
+// FormatMRZName builds an MRZ name field of the given length from the
+// primary (surname) and secondary (given names) identifiers. When the
+// name does not fit, the secondary identifier is cut first; if the
+// primary identifier alone is too long it is cut so that the initial of
+// the first given name still fits. A truncated field always ends with a
+// letter, which is how ICAO signals truncation to readers. The boolean
+// reports truncation.
+func FormatMRZName(primary, secondary string, length int) (string, bool) {
+	p := strings.Join(nameComponents(primary), "<")
+	s := nameComponents(secondary)
+
+	full := p
+	if len(s) > 0 {
+		full += "<<" + strings.Join(s, "<")
+	}
+	if len(full) <= length {
+		return padRight(full, length, '<'), false
+	}
+
+	if len(s) == 0 || len(p)+3 <= length {
+		if cut := cutName(full, length); !strings.HasSuffix(cut, "<<") {
+			return cut, true
+		}
+	}
+	return cutName(p, length-3) + "<<" + s[0][:1], true
+}
+
+// cutName cuts the filler separated name to length characters. A cut
+// right after a filler would read as an untruncated name, so the part
+// before it gives up its last letter, or is dropped when it is a single
+// letter, until the cut ends on a letter. A cut ending on the
+// primary/secondary separator is returned as is for the caller to
+// handle.
+func cutName(name string, length int) string {
+	if len(name) <= length {
+		return padRight(name, length, '<')
+	}
+	if length < 3 || name[length-1] != '<' || name[length-2] == '<' {
+		return name[:length]
+	}
+	if name[length-3] == '<' {
+		return cutName(name[:length-2]+name[length:], length)
+	}
+	return cutName(name[:length-2]+name[length-1:], length)
+}
+
+// isTruncatedName reports whether a raw MRZ name field may have been
+// truncated, which ICAO signals by a letter in the last position.
+func isTruncatedName(raw string) bool {
+	return len(raw) > 0 && raw[len(raw)-1] != '<'
+}
+
+func nameComponents(s string) []string {
+	ret := []string{}
+	for _, part := range strings.Split(Transliterate(s), "<") {
+		if part != "" {
+			ret = append(ret, part)
+		}
+	}
+	return ret
+}
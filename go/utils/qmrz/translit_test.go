@@ -0,0 +1,97 @@
+package qmrz
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatMRZName(t *testing.T) {
+	tests := []struct {
+		name      string
+		primary   string
+		secondary string
+		length    int
+		want      string
+		truncated bool
+	}{
+		{"fits", "Eriksson", "Anna Maria", 20, "ERIKSSON<<ANNA<MARIA", false},
+		{"padded", "Müller", "Jürgen", 20, "MUELLER<<JUERGEN<<<<", false},
+		{"cut in given name", "Eriksson", "Anna Maria", 18, "ERIKSSON<<ANNA<MAR", true},
+		{"cut after given name", "Eriksson", "Anna Maria", 15, "ERIKSSON<<ANN<M", true},
+		{"cut after one letter given name", "Smith", "J Paul", 9, "SMITH<<PA", true},
+		{"cut after surname", "Eriksson", "Anna", 10, "ERIKSSO<<A", true},
+		{"cut in surname", "Wolfeschlegelsteinhausen", "Hubert", 10, "WOLFESC<<H", true},
+		{"cut in surname part", "Van Der Berg", "Jan", 8, "VAN<D<<J", true},
+		{"surname only", "Van Der Berg", "", 8, "VAN<DE<B", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := FormatMRZName(tt.primary, tt.secondary, tt.length)
+			if got != tt.want || truncated != tt.truncated {
+				t.Errorf("FormatMRZName() = %q, %v, want %q, %v", got, truncated, tt.want, tt.truncated)
+			}
+			if len(got) != tt.length {
+				t.Errorf("len = %d, want %d", len(got), tt.length)
+			}
+			if tt.truncated && !isTruncatedName(got) {
+				t.Errorf("isTruncatedName(%q) = false, want true", got)
+			}
+		})
+	}
+}
+
+func TestGenerateMRZPassportNameRoundTrip(t *testing.T) {
+	long := "Wolfeschlegelsteinhausenbergerdorff Hubert Blaine Charles"
+	tests := []struct {
+		name      string
+		in        string
+		lastName  string
+		firstName string
+		truncated bool
+	}{
+		{"umlaut", "Müller Jürgen", "MUELLER", "JUERGEN", false},
+		{"slashed o", "Ørsted Hans Christian", "OERSTED", "HANS CHRISTIAN", false},
+		{"longer than the field", long, "WOLFESCHLEGELSTEINHAUSENBERGERDORFF", "HU", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			truncated, err := NameTruncated(TD3, tt.in)
+			if err != nil {
+				t.Fatalf("NameTruncated() error = %v", err)
+			}
+			if truncated != tt.truncated {
+				t.Errorf("NameTruncated() = %v, want %v", truncated, tt.truncated)
+			}
+
+			line1, line2, err := GenerateMRZPassport(Passport{
+				Country:     "UTO",
+				Name:        tt.in,
+				DocNumber:   "L898902C3",
+				Nationality: "UTO",
+				DOB:         "1974-08-12",
+				Sex:         "F",
+				ExpiredDate: "2032-04-15",
+			})
+			if err != nil {
+				t.Fatalf("GenerateMRZPassport() error = %v", err)
+			}
+			m, err := ParseMRZ(line1 + "\n" + line2)
+			if err != nil {
+				t.Fatalf("ParseMRZ(%q) error = %v", line1, err)
+			}
+			p := m.Passport
+			if p.LastName != tt.lastName || p.FirstName != tt.firstName {
+				t.Errorf("name = %q, %q, want %q, %q", p.LastName, p.FirstName, tt.lastName, tt.firstName)
+			}
+			if p.NameTruncated != tt.truncated {
+				t.Errorf("NameTruncated = %v, want %v", p.NameTruncated, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestNameTruncatedUnsupportedType(t *testing.T) {
+	if _, err := NameTruncated("TD4", strings.Repeat("A", 50)); err != ErrUnsupportedType {
+		t.Errorf("NameTruncated() error = %v, want %v", err, ErrUnsupportedType)
+	}
+}
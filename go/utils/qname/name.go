@@ -0,0 +1,331 @@
+package qname
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+	"github.com/mhaqqiw/sdk/go/utils/qbcbp"
+	"github.com/mhaqqiw/sdk/go/utils/qmrz"
+	"github.com/mhaqqiw/sdk/go/utils/qnik"
+)
+
+// bcbpNameLength is the size of the BCBP name field.
+const bcbpNameLength = 20
+
+// titles are dropped from names. BCBP names often carry one after the
+// given names, with or without a space.
+var titles = map[string]bool{
+	"MR": true, "MRS": true, "MS": true, "MISS": true, "MSTR": true,
+	"DR": true, "PROF": true, "CHD": true, "INF": true,
+}
+
+// gluedTitles may be appended to the last given name of a BCBP name
+// without a space. MS and DR are left out as too many names end in them.
+var gluedTitles = []string{"MSTR", "MISS", "MRS", "MR"}
+
+// placeholders stand for a missing name part: first or last name
+// unknown, used for passengers with a single name.
+var placeholders = map[string]bool{"FNU": true, "LNU": true}
+
+// Name is a passenger name from one document. Surname and Given may be
+// empty for passengers with a single name, or when the document does
+// not split the name. Truncated marks a name that may have been cut to
+// fit its field.
+type Name struct {
+	Surname   string `json:"surname"`
+	Given     string `json:"given"`
+	Truncated bool   `json:"truncated"`
+	bcbp      bool
+}
+
+// FromBCBP returns the name of a boarding pass.
+func FromBCBP(b qbcbp.BCBP) Name {
+	return Name{
+		Surname:   b.LastName,
+		Given:     b.FirstName,
+		Truncated: len(b.Name) >= bcbpNameLength,
+		bcbp:      true,
+	}
+}
+
+// FromPassport returns the name of a parsed TD3 MRZ.
+func FromPassport(p qmrz.Passport) Name {
+	return Name{Surname: p.LastName, Given: p.FirstName, Truncated: p.NameTruncated}
+}
+
+// FromDocument returns the name of a parsed MRZ of any layout.
+func FromDocument(d qmrz.Document) Name {
+	return Name{Surname: d.LastName(), Given: d.FirstName(), Truncated: d.NameTruncated()}
+}
+
+// FromIDCard returns the name of an Indonesian identity card, which
+// holds the full name in one field.
+func FromIDCard(c qnik.IDCardData) Name {
+	return Name{Given: c.Name}
+}
+
+// tokens returns the transliterated name parts without titles and
+// placeholders, each part once.
+func (n Name) tokens() []string {
+	parts := append(fields(n.Surname), fields(n.Given)...)
+	if n.bcbp && len(parts) > 1 {
+		last := parts[len(parts)-1]
+		for _, title := range gluedTitles {
+			if len(last) > len(title)+1 && strings.HasSuffix(last, title) {
+				parts[len(parts)-1] = strings.TrimSuffix(last, title)
+				break
+			}
+		}
+	}
+
+	ret := []string{}
+	seen := map[string]bool{}
+	for _, part := range parts {
+		if titles[part] || placeholders[part] || seen[part] {
+			continue
+		}
+		seen[part] = true
+		ret = append(ret, part)
+	}
+	return ret
+}
+
+func fields(s string) []string {
+	return strings.FieldsFunc(qmrz.Transliterate(s), func(r rune) bool {
+		return r == '<' || (r >= '0' && r <= '9')
+	})
+}
+
+type Decision string
+
+const (
+	DecisionMatch   Decision = "match"
+	DecisionReview  Decision = "review"
+	DecisionNoMatch Decision = "no_match"
+)
+
+// Thresholds are the lowest scores for a match, and for a name to be
+// referred to an agent rather than rejected.
+type Thresholds struct {
+	Match  float64 `json:"match"`
+	Review float64 `json:"review"`
+}
+
+var DefaultThresholds = Thresholds{Match: 0.9, Review: 0.75}
+
+// Result is the outcome of a comparison. Score ranges from 0 to 1.
+type Result struct {
+	Score    float64  `json:"score"`
+	Decision Decision `json:"decision"`
+}
+
+// Status returns qconstant.PassengerStatusMatch for a match and
+// qconstant.PassengerStatusNameMissmatch otherwise.
+func (r Result) Status() string {
+	if r.Decision == DecisionMatch {
+		return qconstant.PassengerStatusMatch
+	}
+	return qconstant.PassengerStatusNameMissmatch
+}
+
+type Matcher struct {
+	thresholds Thresholds
+}
+
+type Option func(*Matcher)
+
+// WithThresholds sets the decision thresholds. Defaults to
+// DefaultThresholds.
+func WithThresholds(t Thresholds) Option {
+	return func(m *Matcher) {
+		m.thresholds = t
+	}
+}
+
+func NewMatcher(opts ...Option) *Matcher {
+	m := &Matcher{thresholds: DefaultThresholds}
+	for _, optFunc := range opts {
+		optFunc(m)
+	}
+	return m
+}
+
+// Match compares two names. Name parts are matched regardless of order
+// and of the surname/given split, so reordered given names and single
+// names match. A truncated name may end in a partial part and its
+// counterpart may carry parts it lost. The score weighs the share of the
+// better covered name found in the other three to one over the share of
+// the other, so a name missing middle names still scores high. A name
+// with a part left unpaired is at best referred for review, whatever its
+// score, as a different given name or initial is a different person.
+func (m *Matcher) Match(a, b Name) Result {
+	score, complete := tokenScore(a, b)
+	if joinedScore(a, b) == 1 {
+		score, complete = 1, true
+	}
+	ret := Result{Score: score, Decision: DecisionNoMatch}
+	switch {
+	case score >= m.thresholds.Match && complete:
+		ret.Decision = DecisionMatch
+	case score >= m.thresholds.Review:
+		ret.Decision = DecisionReview
+	}
+	return ret
+}
+
+// Match compares two names with the default thresholds.
+func Match(a, b Name) Result {
+	return NewMatcher().Match(a, b)
+}
+
+// minSimilarity is the lowest similarity for two parts to be paired.
+const minSimilarity = 0.85
+
+// tokenScore returns the score of the paired name parts, and whether
+// every part that counts towards it was paired.
+func tokenScore(a, b Name) (float64, bool) {
+	ta, tb := a.tokens(), b.tokens()
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0, false
+	}
+
+	type pair struct {
+		i, j int
+		sim  float64
+	}
+	pairs := []pair{}
+	for i, x := range ta {
+		for j, y := range tb {
+			sim := similarity(x, y, a.Truncated, b.Truncated, i == len(ta)-1, j == len(tb)-1)
+			if sim >= minSimilarity {
+				pairs = append(pairs, pair{i, j, sim})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].sim > pairs[j].sim })
+
+	usedA, usedB := make([]float64, len(ta)), make([]float64, len(tb))
+	pairedA, pairedB := make([]bool, len(ta)), make([]bool, len(tb))
+	for _, p := range pairs {
+		if pairedA[p.i] || pairedB[p.j] {
+			continue
+		}
+		pairedA[p.i], pairedB[p.j] = true, true
+		usedA[p.i], usedB[p.j] = p.sim, p.sim
+	}
+
+	ca, completeA := coverage(ta, usedA, pairedA, b.Truncated)
+	cb, completeB := coverage(tb, usedB, pairedB, a.Truncated)
+	return 0.75*max(ca, cb) + 0.25*min(ca, cb), completeA && completeB
+}
+
+// coverage is the share of the name, weighted by length, found in the
+// other name, and whether all of it was found. Unpaired parts are not
+// counted when the other name was truncated, as they may have been cut
+// from it.
+func coverage(tokens []string, sims []float64, paired []bool, otherTruncated bool) (float64, bool) {
+	found, total := 0.0, 0.0
+	complete := true
+	for i, t := range tokens {
+		if !paired[i] && otherTruncated {
+			continue
+		}
+		complete = complete && paired[i]
+		n := float64(utf8.RuneCountInString(t))
+		found += sims[i] * n
+		total += n
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return found / total, complete
+}
+
+// joinedScore matches names written with and without spaces, such as
+// ABDUL RAHMAN and ABDULRAHMAN, by comparing their parts run together.
+// Only identical names score, as run together names share too long a
+// prefix for a fuzzy comparison to tell them apart.
+func joinedScore(a, b Name) float64 {
+	ja, jb := strings.Join(a.tokens(), ""), strings.Join(b.tokens(), "")
+	if ja == "" || jb == "" {
+		return 0
+	}
+	if ja == jb || (a.Truncated && strings.HasPrefix(jb, ja)) || (b.Truncated && strings.HasPrefix(ja, jb)) {
+		return 1
+	}
+	return 0
+}
+
+// similarity compares two name parts of names that may have been
+// truncated. Parts of complete names only match exactly, as a name one
+// letter apart, such as AN and ANH, is another name. In a truncated name
+// the last part matches any part it is a prefix of, a single letter
+// matches parts starting with it as an initial, and other parts are
+// compared fuzzily.
+func similarity(x, y string, xTruncated, yTruncated, xLast, yLast bool) float64 {
+	if x == y {
+		return 1
+	}
+	if !xTruncated && !yTruncated {
+		return 0
+	}
+	if (xTruncated && xLast && strings.HasPrefix(y, x)) || (yTruncated && yLast && strings.HasPrefix(x, y)) {
+		return 1
+	}
+	if (xTruncated && len(x) == 1 && strings.HasPrefix(y, x)) || (yTruncated && len(y) == 1 && strings.HasPrefix(x, y)) {
+		return minSimilarity
+	}
+	return jaroWinkler(x, y)
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of two strings, which
+// favours strings sharing a prefix and tolerates typos and transposed
+// letters.
+func jaroWinkler(x, y string) float64 {
+	a, b := []rune(x), []rune(y)
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	window := max(len(a), len(b))/2 - 1
+	window = max(window, 0)
+
+	matchedA, matchedB := make([]bool, len(a)), make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		for j := max(0, i-window); j < min(len(b), i+window+1); j++ {
+			if !matchedB[j] && a[i] == b[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range a {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(a), len(b)) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
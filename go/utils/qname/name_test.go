@@ -0,0 +1,186 @@
+package qname
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+	"github.com/mhaqqiw/sdk/go/utils/qbcbp"
+	"github.com/mhaqqiw/sdk/go/utils/qmrz"
+	"github.com/mhaqqiw/sdk/go/utils/qnik"
+)
+
+func bcbpName(name string) Name {
+	last, first, _ := strings.Cut(name, "/")
+	return FromBCBP(qbcbp.BCBP{Name: name, LastName: last, FirstName: first})
+}
+
+func document(t *testing.T, mrz string) qmrz.Document {
+	t.Helper()
+	m, err := qmrz.ParseMRZ(mrz)
+	if err != nil {
+		t.Fatalf("ParseMRZ() error = %v", err)
+	}
+	return m.Document()
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Name
+		want Decision
+	}{
+		{
+			name: "identical",
+			a:    bcbpName("TAN/JOHN"),
+			b:    FromPassport(qmrz.Passport{LastName: "TAN", FirstName: "JOHN"}),
+			want: DecisionMatch,
+		},
+		{
+			name: "title",
+			a:    bcbpName("TAN/JOHN MR"),
+			b:    FromPassport(qmrz.Passport{LastName: "TAN", FirstName: "JOHN"}),
+			want: DecisionMatch,
+		},
+		{
+			name: "glued title",
+			a:    bcbpName("TAN/JOHNMR"),
+			b:    FromPassport(qmrz.Passport{LastName: "TAN", FirstName: "JOHN"}),
+			want: DecisionMatch,
+		},
+		{
+			name: "reordered given names",
+			a:    Name{Surname: "WIJAYA", Given: "BUDI SANTOSO"},
+			b:    Name{Surname: "WIJAYA", Given: "SANTOSO BUDI"},
+			want: DecisionMatch,
+		},
+		{
+			name: "single name",
+			a:    bcbpName("SUKARNO/FNU"),
+			b:    FromIDCard(qnik.IDCardData{Name: "SUKARNO"}),
+			want: DecisionMatch,
+		},
+		{
+			name: "transliteration",
+			a:    bcbpName("MUELLER/HANS"),
+			b:    Name{Surname: "MÜLLER", Given: "HANS"},
+			want: DecisionMatch,
+		},
+		{
+			name: "joined name",
+			a:    Name{Surname: "ABDUL RAHMAN"},
+			b:    Name{Surname: "ABDULRAHMAN"},
+			want: DecisionMatch,
+		},
+		{
+			name: "truncated pass",
+			a:    bcbpName("PRAWIRANEGARA/MUHAMM"),
+			b:    FromPassport(qmrz.Passport{LastName: "PRAWIRANEGARA", FirstName: "MUHAMMAD ARIF"}),
+			want: DecisionMatch,
+		},
+		{
+			name: "truncated passport initial",
+			a:    FromPassport(qmrz.Passport{LastName: "PRAWIRANEGARA", FirstName: "MUHAMMAD A", NameTruncated: true}),
+			b:    Name{Surname: "PRAWIRANEGARA", Given: "MUHAMMAD ARIF"},
+			want: DecisionMatch,
+		},
+		{
+			name: "truncated visa",
+			a:    FromDocument(document(t, "V<EUEPRAWIRANEGARA<<MUHAMMAD<ABDULLA\nL898902C36UTO7408122F3001318AB12<<<<")),
+			b:    Name{Surname: "PRAWIRANEGARA", Given: "MUHAMMAD ABDULLAH"},
+			want: DecisionMatch,
+		},
+		{
+			name: "different initial",
+			a:    FromIDCard(qnik.IDCardData{Name: "NGUYEN VAN A"}),
+			b:    FromIDCard(qnik.IDCardData{Name: "NGUYEN VAN B"}),
+			want: DecisionReview,
+		},
+		{
+			name: "missing given name",
+			a:    bcbpName("SMITH/JOHN"),
+			b:    Name{Surname: "SMITH", Given: "JOHN PAUL"},
+			want: DecisionReview,
+		},
+		{
+			name: "initial of complete name",
+			a:    Name{Surname: "PRAWIRANEGARA", Given: "MUHAMMAD A"},
+			b:    Name{Surname: "PRAWIRANEGARA", Given: "MUHAMMAD ARIF"},
+			want: DecisionReview,
+		},
+		{
+			name: "longer given name",
+			a:    bcbpName("TAN/JOHN"),
+			b:    Name{Surname: "TAN", Given: "JOHNNY"},
+			want: DecisionNoMatch,
+		},
+		{
+			name: "one letter apart",
+			a:    bcbpName("NGUYEN/AN"),
+			b:    Name{Surname: "NGUYEN", Given: "ANH"},
+			want: DecisionNoMatch,
+		},
+		{
+			name: "different person",
+			a:    bcbpName("SMITH/JOHN"),
+			b:    Name{Surname: "DOE", Given: "JANE"},
+			want: DecisionNoMatch,
+		},
+		{
+			name: "empty name",
+			a:    Name{},
+			b:    Name{Surname: "DOE", Given: "JANE"},
+			want: DecisionNoMatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Match(tt.a, tt.b)
+			if got.Decision != tt.want {
+				t.Errorf("Match() = %+v, want %v", got, tt.want)
+			}
+			if rev := Match(tt.b, tt.a); rev != got {
+				t.Errorf("Match() reversed = %+v, want %+v", rev, got)
+			}
+		})
+	}
+}
+
+func TestMatcherThresholds(t *testing.T) {
+	a := bcbpName("SMITH/JOHN")
+	b := Name{Surname: "SMITH", Given: "JOHN PAUL"}
+	tests := []struct {
+		name       string
+		thresholds Thresholds
+		want       Decision
+	}{
+		{"default", DefaultThresholds, DecisionReview},
+		{"lower match threshold", Thresholds{Match: 0.5, Review: 0.4}, DecisionReview},
+		{"higher review threshold", Thresholds{Match: 0.99, Review: 0.95}, DecisionNoMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewMatcher(WithThresholds(tt.thresholds)).Match(a, b); got.Decision != tt.want {
+				t.Errorf("Match() = %+v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultStatus(t *testing.T) {
+	tests := []struct {
+		decision Decision
+		want     string
+	}{
+		{DecisionMatch, qconstant.PassengerStatusMatch},
+		{DecisionReview, qconstant.PassengerStatusNameMissmatch},
+		{DecisionNoMatch, qconstant.PassengerStatusNameMissmatch},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.decision), func(t *testing.T) {
+			if got := (Result{Decision: tt.decision}).Status(); got != tt.want {
+				t.Errorf("Status() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package qpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+	"github.com/mhaqqiw/sdk/go/utils/qmrz"
+)
+
+// Validity is how long a document must remain valid for entry.
+type Validity string
+
+const (
+	// ValidityNone only requires the document to be valid on the travel
+	// date.
+	ValidityNone Validity = "none"
+	// ValiditySixMonths requires Months, usually six, of validity left
+	// on the travel date. Months are calendar months, ending on the last
+	// day of a shorter month: six months from August 31 end on the last
+	// day of February.
+	ValiditySixMonths Validity = "six_months"
+	// ValidityBeyondStay requires the document to remain valid Months,
+	// usually three, beyond the end of the stay.
+	ValidityBeyondStay Validity = "beyond_stay"
+)
+
+// Rule is the entry policy of a country. AcceptedTypes lists the MRZ
+// document type prefixes accepted, "P" for any passport; an empty list
+// accepts every document.
+type Rule struct {
+	Validity      Validity `json:"validity"`
+	Months        int      `json:"months"`
+	MinAge        int      `json:"min_age"`
+	AcceptedTypes []string `json:"accepted_types"`
+}
+
+// ruleFile is the layout of passport_policy.json. Country entries,
+// keyed by ICAO 9303 country code ("D" for Germany), replace the default
+// rule as a whole.
+type ruleFile struct {
+	Default   Rule            `json:"default"`
+	Countries map[string]Rule `json:"countries"`
+}
+
+// Policy holds the rules loaded from passport_policy.json. It is safe
+// for concurrent use and may be reloaded while in use.
+type Policy struct {
+	mu    sync.RWMutex
+	rules ruleFile
+}
+
+type option struct {
+	sdkPath string
+}
+
+type Option func(*option)
+
+// WithPath sets the directory holding passport_policy.json. Defaults to
+// files/etc/sdk of the SDK.
+func WithPath(path string) Option {
+	return func(o *option) {
+		o.sdkPath = path
+	}
+}
+
+// NewPolicy loads the rule file.
+func NewPolicy(opts ...Option) (*Policy, error) {
+	p := &Policy{}
+	if err := p.Load(opts...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Load reads the rule file and replaces the rules of p. On error p is
+// left unchanged.
+func (p *Policy) Load(opts ...Option) error {
+	_, b, _, _ := runtime.Caller(0)
+	opt := &option{
+		sdkPath: filepath.Join(filepath.Dir(b), "../../../files/etc/sdk/"),
+	}
+
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+
+	file, err := os.ReadFile(filepath.Join(opt.sdkPath, "passport_policy.json"))
+	if err != nil {
+		return err
+	}
+	var rules ruleFile
+	err = json.Unmarshal(file, &rules)
+	if err != nil {
+		return err
+	}
+
+	if err := rules.Default.validate(); err != nil {
+		return fmt.Errorf("default rule: %w", err)
+	}
+	countries := make(map[string]Rule, len(rules.Countries))
+	for code, rule := range rules.Countries {
+		country, ok := qmrz.LookupCountry(code)
+		if !ok {
+			return fmt.Errorf("rule of %s: unknown ICAO country code", code)
+		}
+		if _, ok := countries[country.Code]; ok {
+			return fmt.Errorf("rule of %s: duplicate country", code)
+		}
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rule of %s: %w", code, err)
+		}
+		countries[country.Code] = rule
+	}
+	rules.Countries = countries
+
+	p.mu.Lock()
+	p.rules = rules
+	p.mu.Unlock()
+	return nil
+}
+
+func (r Rule) validate() error {
+	switch r.Validity {
+	case ValidityNone, ValiditySixMonths, ValidityBeyondStay:
+	default:
+		return fmt.Errorf("unknown validity %q", r.Validity)
+	}
+	if r.Months < 0 || r.MinAge < 0 {
+		return fmt.Errorf("negative months or minimum age")
+	}
+	return nil
+}
+
+// Rule returns the rule of the destination country, an ICAO country
+// code that may carry fillers, or the default one. It reports false,
+// with the default rule, when destination is not an ICAO country code,
+// such as the ISO code "DEU" for Germany.
+func (p *Policy) Rule(destination string) (Rule, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	country, ok := qmrz.LookupCountry(strings.TrimSpace(destination))
+	if !ok {
+		return p.rules.Default, false
+	}
+	if rule, ok := p.rules.Countries[country.Code]; ok {
+		return rule, true
+	}
+	return p.rules.Default, true
+}
+
+// Finding is a failed check.
+type Finding struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// Result is the outcome of a check. Findings are ordered destination,
+// expiry, remaining validity, minimum age and document type.
+type Result struct {
+	Allowed  bool      `json:"allowed"`
+	Rule     Rule      `json:"rule"`
+	Findings []Finding `json:"findings"`
+}
+
+// Statuses returns the qconstant status of every finding.
+func (r Result) Statuses() []string {
+	ret := make([]string, len(r.Findings))
+	for i, f := range r.Findings {
+		ret[i] = f.Status
+	}
+	return ret
+}
+
+type checkOption struct {
+	stayUntil time.Time
+}
+
+type CheckOption func(*checkOption)
+
+// WithStayUntil sets the last day of the stay, checked by
+// ValidityBeyondStay rules. Defaults to the travel date.
+func WithStayUntil(t time.Time) CheckOption {
+	return func(o *checkOption) {
+		o.stayUntil = t
+	}
+}
+
+// Check applies the rule of the destination country, an ICAO country
+// code, to the document for travel on the given date, against which the
+// centuries of its dates are resolved. An expired
+// document reports qconstant.PassengerStatusPassportExpired, too little
+// validity left qconstant.PassengerStatusPassportExpiredSoon, a holder
+// under the minimum age, a document type not accepted or an unknown
+// destination qconstant.PassengerStatusBoardNo, and unreadable dates
+// qconstant.PassengerStatusInvalidMRZ. The rule of an unknown
+// destination is the default one.
+func (p *Policy) Check(m qmrz.MRZ, travel time.Time, destination string, opts ...CheckOption) Result {
+	opt := &checkOption{}
+	for _, optFunc := range opts {
+		optFunc(opt)
+	}
+
+	rule, ok := p.Rule(destination)
+	ret := Result{Rule: rule}
+	if !ok {
+		ret.add(qconstant.PassengerStatusBoardNo, fmt.Sprintf("unknown destination country %q", destination))
+	}
+	travel = day(travel)
+	doc := m.DocumentAt(travel)
+	stayUntil := travel
+	if !opt.stayUntil.IsZero() && day(opt.stayUntil).After(travel) {
+		stayUntil = day(opt.stayUntil)
+	}
+
+	expiry := doc.ExpiredDate()
+	switch {
+	case expiry.IsZero():
+		ret.add(qconstant.PassengerStatusInvalidMRZ, "document expiry date unreadable")
+	case expiry.Before(travel):
+		ret.add(qconstant.PassengerStatusPassportExpired, "document expired on "+expiry.Format("2006-01-02"))
+	default:
+		var required time.Time
+		switch rule.Validity {
+		case ValiditySixMonths:
+			required = addMonths(travel, rule.Months)
+		case ValidityBeyondStay:
+			required = addMonths(stayUntil, rule.Months)
+		}
+		if expiry.Before(required) {
+			ret.add(qconstant.PassengerStatusPassportExpiredSoon,
+				fmt.Sprintf("document expires %s, must be valid until %s", expiry.Format("2006-01-02"), required.Format("2006-01-02")))
+		}
+	}
+
+	if rule.MinAge > 0 {
+		dob := doc.DOB()
+		if dob.IsZero() {
+			ret.add(qconstant.PassengerStatusInvalidMRZ, "date of birth unreadable")
+		} else if age(dob, travel) < rule.MinAge {
+			ret.add(qconstant.PassengerStatusBoardNo, fmt.Sprintf("holder is under %d", rule.MinAge))
+		}
+	}
+
+	if !accepted(doc.Type(), rule.AcceptedTypes) {
+		ret.add(qconstant.PassengerStatusBoardNo, fmt.Sprintf("document type %q not accepted", doc.Type()))
+	}
+
+	ret.Allowed = len(ret.Findings) == 0
+	return ret
+}
+
+func (r *Result) add(status, reason string) {
+	r.Findings = append(r.Findings, Finding{Status: status, Reason: reason})
+}
+
+func accepted(docType string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	docType = strings.ToUpper(strings.TrimSpace(docType))
+	for _, t := range types {
+		if strings.HasPrefix(docType, strings.ToUpper(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// age returns the age in whole years on the given day.
+func age(dob, on time.Time) int {
+	years := on.Year() - dob.Year()
+	if on.Month() < dob.Month() || (on.Month() == dob.Month() && on.Day() < dob.Day()) {
+		years--
+	}
+	return years
+}
+
+// addMonths adds calendar months to a day, clamping to the last day of
+// the month where time.AddDate would roll over into the next.
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	d := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return time.Date(first.Year(), first.Month(), d, 0, 0, 0, 0, t.Location())
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
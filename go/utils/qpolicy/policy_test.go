@@ -0,0 +1,183 @@
+package qpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mhaqqiw/sdk/go/qconstant"
+	"github.com/mhaqqiw/sdk/go/utils/qmrz"
+)
+
+func passport(dob, expiry string) qmrz.MRZ {
+	var m qmrz.MRZ
+	m.DocumentClass = qmrz.TD3
+	m.DocumentType = "P"
+	m.Passport.DOB = dob
+	m.Passport.ExpiredDate = expiry
+	return m
+}
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "passport_policy.json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestRule(t *testing.T) {
+	p, err := NewPolicy()
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+	germany := Rule{Validity: ValidityBeyondStay, Months: 3, AcceptedTypes: []string{"P"}}
+	tests := []struct {
+		name        string
+		destination string
+		want        Rule
+		wantOK      bool
+	}{
+		{"ICAO code", "D", germany, true},
+		{"with fillers", "D<<", germany, true},
+		{"lower case", " d ", germany, true},
+		{"ISO code", "DEU", p.rules.Default, false},
+		{"no country rule", "ZWE", p.rules.Default, true},
+		{"unknown", "QQQ", p.rules.Default, false},
+		{"empty", "", p.rules.Default, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.Rule(tt.destination)
+			if !reflect.DeepEqual(got, tt.want) || ok != tt.wantOK {
+				t.Errorf("Rule(%q) = %+v, %v, want %+v, %v", tt.destination, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	const valid = `{"default": {"validity": "none"}}`
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown country", `{"default": {"validity": "none"}, "countries": {"QQQ": {"validity": "none"}}}`},
+		{"ISO country code", `{"default": {"validity": "none"}, "countries": {"DEU": {"validity": "none"}}}`},
+		{"duplicate country", `{"default": {"validity": "none"}, "countries": {"D": {"validity": "none"}, "D<<": {"validity": "none"}}}`},
+		{"unknown validity", `{"default": {"validity": "forever"}}`},
+		{"negative months", `{"default": {"validity": "six_months", "months": -6}}`},
+		{"malformed", `{"default": `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPolicy(WithPath(writePolicy(t, valid)))
+			if err != nil {
+				t.Fatalf("NewPolicy() error = %v", err)
+			}
+			if err := p.Load(WithPath(writePolicy(t, tt.content))); err == nil {
+				t.Error("Load() error = nil, want an error")
+			}
+			if got, _ := p.Rule("D"); got.Validity != ValidityNone {
+				t.Errorf("Rule() after failed Load = %v, want %v", got.Validity, ValidityNone)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p, err := NewPolicy()
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+	travel := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	stay := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		mrz         qmrz.MRZ
+		destination string
+		opts        []CheckOption
+		want        []string
+	}{
+		{"six months left", passport("900101", "270418"), "IDN", nil, []string{}},
+		{"a day short of six months", passport("900101", "270417"), "IDN", nil,
+			[]string{qconstant.PassengerStatusPassportExpiredSoon}},
+		{"expires on the travel date", passport("900101", "261018"), "IDN", nil,
+			[]string{qconstant.PassengerStatusPassportExpiredSoon}},
+		{"expired the day before", passport("900101", "261017"), "IDN", nil,
+			[]string{qconstant.PassengerStatusPassportExpired}},
+		{"three months beyond the stay", passport("900101", "270201"), "D", []CheckOption{WithStayUntil(stay)}, []string{}},
+		{"a day short beyond the stay", passport("900101", "270131"), "D", []CheckOption{WithStayUntil(stay)},
+			[]string{qconstant.PassengerStatusPassportExpiredSoon}},
+		{"stay defaults to the travel date", passport("900101", "270118"), "D", nil, []string{}},
+		{"stay before the travel date", passport("900101", "270118"), "D",
+			[]CheckOption{WithStayUntil(travel.AddDate(0, 0, -10))}, []string{}},
+		{"unreadable expiry", passport("900101", "27XX18"), "IDN", nil,
+			[]string{qconstant.PassengerStatusInvalidMRZ}},
+		{"ISO destination code", passport("900101", "270418"), "DEU", nil,
+			[]string{qconstant.PassengerStatusBoardNo}},
+		{"unknown destination", passport("900101", "261017"), "QQQ", nil,
+			[]string{qconstant.PassengerStatusBoardNo, qconstant.PassengerStatusPassportExpired}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Check(tt.mrz, travel, tt.destination, tt.opts...).Statuses()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckResolvesDatesAtTravel(t *testing.T) {
+	p, err := NewPolicy(WithPath(writePolicy(t, `{"default": {"validity": "none", "min_age": 18}}`)))
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+	// Born after today but before the travel date. Resolved against
+	// today the holder would be a century old.
+	travel := time.Now().AddDate(0, 3, 0)
+	m := passport(travel.AddDate(0, -1, 0).Format("060102"), travel.AddDate(5, 0, 0).Format("060102"))
+
+	got := p.Check(m, travel, "IDN").Statuses()
+	want := []string{qconstant.PassengerStatusBoardNo}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() = %v, want %v", got, want)
+	}
+}
+
+func TestCheckMonthEnd(t *testing.T) {
+	p, err := NewPolicy()
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+	tests := []struct {
+		name        string
+		travel      time.Time
+		expiry      string
+		destination string
+		opts        []CheckOption
+		want        []string
+	}{
+		{"six months from August 31", time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC), "270228", "IDN", nil, []string{}},
+		{"a day short from August 31", time.Date(2026, 8, 31, 0, 0, 0, 0, time.UTC), "270227", "IDN", nil,
+			[]string{qconstant.PassengerStatusPassportExpiredSoon}},
+		{"six months to a leap February", time.Date(2027, 8, 31, 0, 0, 0, 0, time.UTC), "280229", "IDN", nil, []string{}},
+		{"a day short of a leap February", time.Date(2027, 8, 31, 0, 0, 0, 0, time.UTC), "280228", "IDN", nil,
+			[]string{qconstant.PassengerStatusPassportExpiredSoon}},
+		{"three months beyond November 30", time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC), "270228", "D",
+			[]CheckOption{WithStayUntil(time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC))}, []string{}},
+		{"across the year", time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), "270630", "IDN", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Check(passport("900101", tt.expiry), tt.travel, tt.destination, tt.opts...).Statuses()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
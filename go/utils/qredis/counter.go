@@ -0,0 +1,350 @@
+package qredis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/mhaqqiw/sdk/go/qconstant"
+)
+
+// Checkpoint is a place where a passenger's passes are counted.
+type Checkpoint string
+
+const (
+	CheckpointSCP         Checkpoint = "scp"
+	CheckpointImmigration Checkpoint = "immigration"
+	CheckpointBoarding    Checkpoint = "boarding"
+)
+
+// checkpointStatuses are the status and event reported when a passenger
+// is over the limit of a checkpoint.
+var checkpointStatuses = map[Checkpoint][2]string{
+	CheckpointSCP:         {qconstant.PassengerStatusMaxSCPCount, qconstant.PassengerMaxSCPCount},
+	CheckpointImmigration: {qconstant.PassengerStatusMaxImmigrationCount, qconstant.PassengerMaxImmigrationCount},
+	CheckpointBoarding:    {qconstant.PassengerStatusMaxBoardingCount, qconstant.PassengerMaxBoardingCount},
+}
+
+// CounterStore keeps the counts. Acquire must be atomic: concurrent
+// calls for the same key never let the count exceed max.
+type CounterStore interface {
+	// Acquire increments key unless it already reached max, max <= 0
+	// meaning no limit, and makes it expire at expireAt. It returns the
+	// count after the call and whether it was incremented.
+	Acquire(key string, max int64, expireAt time.Time) (int64, bool, error)
+	Count(key string) (int64, error)
+	Reset(key string) error
+}
+
+// acquireScript checks and increments in one step, so gates racing on
+// the same passenger cannot both get in.
+var acquireScript = redis.NewScript(`
+local n = tonumber(redis.call('GET', KEYS[1]) or '0')
+local max = tonumber(ARGV[1])
+if max > 0 and n >= max then
+	return {0, n}
+end
+n = redis.call('INCR', KEYS[1])
+redis.call('EXPIREAT', KEYS[1], ARGV[2])
+return {1, n}
+`)
+
+type redisCounterStore struct {
+	conn *redis.Client
+}
+
+// NewRedisCounterStore keeps counts in Redis. A nil conn uses Conn as
+// set by CreateConn at the time of each call.
+func NewRedisCounterStore(conn *redis.Client) CounterStore {
+	return &redisCounterStore{conn: conn}
+}
+
+func (s *redisCounterStore) client() (*redis.Client, error) {
+	if s.conn != nil {
+		return s.conn, nil
+	}
+	if Conn == nil {
+		return nil, errors.New("redis connection not created")
+	}
+	return Conn, nil
+}
+
+func (s *redisCounterStore) Acquire(key string, max int64, expireAt time.Time) (int64, bool, error) {
+	conn, err := s.client()
+	if err != nil {
+		return 0, false, err
+	}
+	res, err := acquireScript.Run(conn, []string{key}, max, expireAt.Unix()).Result()
+	if err != nil {
+		return 0, false, errors.New("failed to count pass")
+	}
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, false, errors.New("unexpected counter reply")
+	}
+	acquired, _ := values[0].(int64)
+	count, _ := values[1].(int64)
+	return count, acquired == 1, nil
+}
+
+func (s *redisCounterStore) Count(key string) (int64, error) {
+	conn, err := s.client()
+	if err != nil {
+		return 0, err
+	}
+	count, err := conn.Get(key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.New("failed to get count")
+	}
+	return count, nil
+}
+
+func (s *redisCounterStore) Reset(key string) error {
+	conn, err := s.client()
+	if err != nil {
+		return err
+	}
+	if err := conn.Del(key).Err(); err != nil {
+		return errors.New("failed to reset count")
+	}
+	return nil
+}
+
+// MemoryCounterStore is an in-process stand-in for Redis, for tests and
+// single gate setups.
+type MemoryCounterStore struct {
+	mu     sync.Mutex
+	counts map[string]memoryCount
+	now    func() time.Time
+}
+
+type memoryCount struct {
+	n        int64
+	expireAt time.Time
+}
+
+func NewMemoryCounterStore() *MemoryCounterStore {
+	return &MemoryCounterStore{counts: map[string]memoryCount{}, now: time.Now}
+}
+
+// SetClock sets the clock expiries are checked against, to simulate time
+// passing in tests.
+func (s *MemoryCounterStore) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+func (s *MemoryCounterStore) Acquire(key string, max int64, expireAt time.Time) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.get(key)
+	if max > 0 && c.n >= max {
+		return c.n, false, nil
+	}
+	c.n++
+	c.expireAt = expireAt
+	s.counts[key] = c
+	return c.n, true, nil
+}
+
+func (s *MemoryCounterStore) Count(key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(key).n, nil
+}
+
+func (s *MemoryCounterStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counts, key)
+	return nil
+}
+
+func (s *MemoryCounterStore) get(key string) memoryCount {
+	c, ok := s.counts[key]
+	if ok && !s.now().Before(c.expireAt) {
+		delete(s.counts, key)
+		return memoryCount{}
+	}
+	return c
+}
+
+// CounterConfig sets how many passes each checkpoint allows per
+// passenger and flight, 0 meaning no limit, and how long after departure
+// the counts are kept.
+type CounterConfig struct {
+	Max              map[Checkpoint]int64 `json:"max"`
+	RetentionMinutes int                  `json:"retention_minutes"`
+}
+
+// DefaultCounterConfig allows two security screenings, for passengers
+// sent back through, one immigration and one boarding pass, and keeps
+// counts for a day after departure.
+var DefaultCounterConfig = CounterConfig{
+	Max: map[Checkpoint]int64{
+		CheckpointSCP:         2,
+		CheckpointImmigration: 1,
+		CheckpointBoarding:    1,
+	},
+	RetentionMinutes: 24 * 60,
+}
+
+// CounterKey identifies the passes counted together: one passenger on
+// one flight. Departure is required: its UTC date tells apart flights
+// with the same number on different days, and the count expires
+// RetentionMinutes after it.
+type CounterKey struct {
+	PassengerID string    `json:"passenger_id"`
+	Flight      string    `json:"flight"`
+	Departure   time.Time `json:"departure"`
+}
+
+// CountResult is the outcome of recording a pass. Status and Event are
+// the qconstant max count status and event of the checkpoint when the
+// pass was refused, and empty otherwise.
+type CountResult struct {
+	Count   int64  `json:"count"`
+	Max     int64  `json:"max"`
+	Allowed bool   `json:"allowed"`
+	Status  string `json:"status"`
+	Event   string `json:"event"`
+}
+
+// Counter enforces the pass limits of checkpoints across gates.
+type Counter struct {
+	store  CounterStore
+	config CounterConfig
+	now    func() time.Time
+}
+
+type CounterOption func(*Counter)
+
+// WithCounterStore sets where counts are kept. Defaults to Redis through
+// Conn.
+func WithCounterStore(store CounterStore) CounterOption {
+	return func(c *Counter) {
+		c.store = store
+	}
+}
+
+// WithCounterConfig sets the limits and retention. Defaults to
+// DefaultCounterConfig.
+func WithCounterConfig(config CounterConfig) CounterOption {
+	return func(c *Counter) {
+		c.config = config
+	}
+}
+
+// WithCounterClock sets the clock passes are recorded at. Passes for a
+// flight that departed longer ago than the retention are refused, as
+// their count would already have expired. Defaults to time.Now.
+func WithCounterClock(now func() time.Time) CounterOption {
+	return func(c *Counter) {
+		c.now = now
+	}
+}
+
+func NewCounter(opts ...CounterOption) *Counter {
+	c := &Counter{
+		store:  NewRedisCounterStore(nil),
+		config: DefaultCounterConfig,
+		now:    time.Now,
+	}
+	for _, optFunc := range opts {
+		optFunc(c)
+	}
+	return c
+}
+
+// Record counts a pass through the checkpoint, refusing it when the
+// passenger already reached the limit.
+func (c *Counter) Record(key CounterKey, checkpoint Checkpoint) (CountResult, error) {
+	ret := CountResult{Max: c.config.Max[checkpoint]}
+	k, err := c.key(key, checkpoint)
+	if err != nil {
+		return ret, err
+	}
+	if !c.expireAt(key).After(c.now()) {
+		return ret, errors.New("flight departed before the retention period")
+	}
+	ret.Count, ret.Allowed, err = c.store.Acquire(k, ret.Max, c.expireAt(key))
+	if err != nil {
+		return ret, err
+	}
+	if !ret.Allowed {
+		ret.Status = checkpointStatuses[checkpoint][0]
+		ret.Event = checkpointStatuses[checkpoint][1]
+	}
+	return ret, nil
+}
+
+// Count returns how many passes through the checkpoint were recorded.
+func (c *Counter) Count(key CounterKey, checkpoint Checkpoint) (int64, error) {
+	k, err := c.key(key, checkpoint)
+	if err != nil {
+		return 0, err
+	}
+	return c.store.Count(k)
+}
+
+// Reset clears the count of the checkpoint, e.g. after an agent lets a
+// passenger through again.
+func (c *Counter) Reset(key CounterKey, checkpoint Checkpoint) error {
+	k, err := c.key(key, checkpoint)
+	if err != nil {
+		return err
+	}
+	return c.store.Reset(k)
+}
+
+func (c *Counter) key(key CounterKey, checkpoint Checkpoint) (string, error) {
+	if _, ok := checkpointStatuses[checkpoint]; !ok {
+		return "", fmt.Errorf("unknown checkpoint %q", checkpoint)
+	}
+	if key.PassengerID == "" || key.Flight == "" {
+		return "", errors.New("missing passenger or flight")
+	}
+	// Without a date a gate knowing the departure and one that does not
+	// would count the same flight under different keys.
+	if key.Departure.IsZero() {
+		return "", errors.New("missing flight departure")
+	}
+	flight := flightKey(key.Flight) + ":" + key.Departure.UTC().Format("20060102")
+	return concatKey(Prefix, "counter", fmt.Sprintf("%s:%s:%s", checkpoint, flight, key.PassengerID)), nil
+}
+
+// flightKey normalizes a flight designator, so that GA404, GA 404 and
+// GA0404 share a count: the airline code, two characters or three
+// letters, followed by the flight number without leading zeros.
+func flightKey(flight string) string {
+	flight = strings.ToUpper(strings.ReplaceAll(flight, " ", ""))
+	if len(flight) < 3 {
+		return flight
+	}
+	n := 2
+	if isLetter(flight[0]) && isLetter(flight[1]) && isLetter(flight[2]) {
+		n = 3
+	}
+	number := strings.TrimLeft(flight[n:], "0")
+	if number == "" {
+		number = "0"
+	}
+	return flight[:n] + number
+}
+
+func isLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+// expireAt is the retention period after departure, or after now when
+// the departure is not known.
+func (c *Counter) expireAt(key CounterKey) time.Time {
+	return key.Departure.Add(time.Duration(c.config.RetentionMinutes) * time.Minute)
+}
@@ -0,0 +1,254 @@
+package qredis
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"github.com/mhaqqiw/sdk/go/qconstant"
+)
+
+var (
+	testNow       = time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	testDeparture = testNow.Add(2 * time.Hour)
+)
+
+func newTestCounter(t *testing.T) (*Counter, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	mr.SetTime(testNow)
+	conn := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { conn.Close() })
+	c := NewCounter(
+		WithCounterStore(NewRedisCounterStore(conn)),
+		WithCounterClock(func() time.Time { return testNow }),
+	)
+	return c, mr
+}
+
+func TestCounterRecord(t *testing.T) {
+	c, _ := newTestCounter(t)
+	key := CounterKey{PassengerID: "P1", Flight: "GA404", Departure: testDeparture}
+	tests := []struct {
+		name       string
+		checkpoint Checkpoint
+		want       CountResult
+	}{
+		{"first screening", CheckpointSCP, CountResult{Count: 1, Max: 2, Allowed: true}},
+		{"second screening", CheckpointSCP, CountResult{Count: 2, Max: 2, Allowed: true}},
+		{"third screening", CheckpointSCP, CountResult{Count: 2, Max: 2,
+			Status: qconstant.PassengerStatusMaxSCPCount, Event: qconstant.PassengerMaxSCPCount}},
+		{"boarding", CheckpointBoarding, CountResult{Count: 1, Max: 1, Allowed: true}},
+		{"boarding again", CheckpointBoarding, CountResult{Count: 1, Max: 1,
+			Status: qconstant.PassengerStatusMaxBoardingCount, Event: qconstant.PassengerMaxBoardingCount}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Record(key, tt.checkpoint)
+			if err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Record() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCounterRecordConcurrent(t *testing.T) {
+	c, _ := newTestCounter(t)
+	key := CounterKey{PassengerID: "P1", Flight: "GA404", Departure: testDeparture}
+
+	const gates = 20
+	var wg sync.WaitGroup
+	allowed := make(chan bool, gates)
+	for range gates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ret, err := c.Record(key, CheckpointBoarding)
+			if err != nil {
+				t.Errorf("Record() error = %v", err)
+			}
+			allowed <- ret.Allowed
+		}()
+	}
+	wg.Wait()
+	close(allowed)
+
+	n := 0
+	for ok := range allowed {
+		if ok {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("%d passes allowed, want 1", n)
+	}
+	if count, err := c.Count(key, CheckpointBoarding); err != nil || count != 1 {
+		t.Errorf("Count() = %d, %v, want 1", count, err)
+	}
+}
+
+func TestCounterExpiry(t *testing.T) {
+	tests := []struct {
+		name string
+		key  CounterKey
+		want time.Duration
+	}{
+		{"departing", CounterKey{PassengerID: "P1", Flight: "GA404", Departure: testDeparture}, 26 * time.Hour},
+		{"departed", CounterKey{PassengerID: "P1", Flight: "GA404", Departure: testNow.Add(-20 * time.Hour)}, 4 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, mr := newTestCounter(t)
+			if _, err := c.Record(tt.key, CheckpointBoarding); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			k, _ := c.key(tt.key, CheckpointBoarding)
+			if got := mr.TTL(k); got != tt.want {
+				t.Errorf("TTL = %v, want %v", got, tt.want)
+			}
+
+			mr.FastForward(tt.want)
+			if count, err := c.Count(tt.key, CheckpointBoarding); err != nil || count != 0 {
+				t.Errorf("Count() after expiry = %d, %v, want 0", count, err)
+			}
+			if ret, err := c.Record(tt.key, CheckpointBoarding); err != nil || !ret.Allowed {
+				t.Errorf("Record() after expiry = %+v, %v, want allowed", ret, err)
+			}
+		})
+	}
+}
+
+func TestCounterReset(t *testing.T) {
+	c, mr := newTestCounter(t)
+	key := CounterKey{PassengerID: "P1", Flight: "GA404", Departure: testDeparture}
+	for range 2 {
+		if _, err := c.Record(key, CheckpointBoarding); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if err := c.Reset(key, CheckpointBoarding); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	k, _ := c.key(key, CheckpointBoarding)
+	if mr.Exists(k) {
+		t.Errorf("key %s exists after Reset()", k)
+	}
+	if ret, err := c.Record(key, CheckpointBoarding); err != nil || !ret.Allowed || ret.Count != 1 {
+		t.Errorf("Record() after Reset() = %+v, %v, want allowed with count 1", ret, err)
+	}
+}
+
+func TestCounterKey(t *testing.T) {
+	c := NewCounter(WithCounterStore(NewMemoryCounterStore()))
+	want, err := c.key(CounterKey{PassengerID: "P1", Flight: "GA404", Departure: testDeparture}, CheckpointSCP)
+	if err != nil {
+		t.Fatalf("key() error = %v", err)
+	}
+	tests := []struct {
+		flight string
+		same   bool
+	}{
+		{"GA404", true},
+		{"GA0404", true},
+		{"ga 404", true},
+		{"GA 0404", true},
+		{"GA4040", false},
+		{"GA405", false},
+		{"QZ404", false},
+		{"GIA404", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flight, func(t *testing.T) {
+			got, err := c.key(CounterKey{PassengerID: "P1", Flight: tt.flight, Departure: testDeparture}, CheckpointSCP)
+			if err != nil {
+				t.Fatalf("key() error = %v", err)
+			}
+			if (got == want) != tt.same {
+				t.Errorf("key(%q) = %s, same as GA404 = %v, want %v", tt.flight, got, got == want, tt.same)
+			}
+		})
+	}
+}
+
+func TestCounterKeyDate(t *testing.T) {
+	c := NewCounter(WithCounterStore(NewMemoryCounterStore()))
+	want, err := c.key(CounterKey{PassengerID: "P1", Flight: "GA404", Departure: testDeparture}, CheckpointSCP)
+	if err != nil {
+		t.Fatalf("key() error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		departure time.Time
+		same      bool
+	}{
+		{"same departure", testDeparture, true},
+		{"another time zone", testDeparture.In(time.FixedZone("WIB", 7*3600)), true},
+		{"delayed", testDeparture.Add(3 * time.Hour), true},
+		{"next day", testDeparture.AddDate(0, 0, 1), false},
+		{"day before", testDeparture.AddDate(0, 0, -1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.key(CounterKey{PassengerID: "P1", Flight: "GA404", Departure: tt.departure}, CheckpointSCP)
+			if err != nil {
+				t.Fatalf("key() error = %v", err)
+			}
+			if (got == want) != tt.same {
+				t.Errorf("key() = %s, same as %s = %v, want %v", got, want, got == want, tt.same)
+			}
+		})
+	}
+}
+
+func TestFlightKey(t *testing.T) {
+	tests := []struct {
+		flight string
+		want   string
+	}{
+		{"GA404", "GA404"},
+		{"GA0404", "GA404"},
+		{" ga 0404 ", "GA404"},
+		{"3K0123", "3K123"},
+		{"U20001", "U21"},
+		{"GIA0404", "GIA404"},
+		{"GA0404A", "GA404A"},
+		{"GA", "GA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.flight, func(t *testing.T) {
+			if got := flightKey(tt.flight); got != tt.want {
+				t.Errorf("flightKey(%q) = %q, want %q", tt.flight, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCounterErrors(t *testing.T) {
+	c := NewCounter(
+		WithCounterStore(NewMemoryCounterStore()),
+		WithCounterClock(func() time.Time { return testNow }),
+	)
+	tests := []struct {
+		name       string
+		key        CounterKey
+		checkpoint Checkpoint
+	}{
+		{"unknown checkpoint", CounterKey{PassengerID: "P1", Flight: "GA404", Departure: testDeparture}, Checkpoint("lounge")},
+		{"missing passenger", CounterKey{Flight: "GA404", Departure: testDeparture}, CheckpointSCP},
+		{"missing flight", CounterKey{PassengerID: "P1", Departure: testDeparture}, CheckpointSCP},
+		{"missing departure", CounterKey{PassengerID: "P1", Flight: "GA404"}, CheckpointSCP},
+		{"departed before retention", CounterKey{PassengerID: "P1", Flight: "GA404", Departure: testNow.Add(-24 * time.Hour)}, CheckpointSCP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Record(tt.key, tt.checkpoint); err == nil {
+				t.Error("Record() error = nil, want an error")
+			}
+		})
+	}
+}